@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
@@ -21,8 +22,14 @@ import (
 	"be-itts-community/config"
 	"be-itts-community/internal/db"
 	"be-itts-community/internal/repository"
+	"be-itts-community/internal/service"
+	"be-itts-community/pkg/attempt"
+	"be-itts-community/pkg/auth"
 	"be-itts-community/pkg/lock"
+	"be-itts-community/pkg/mailer"
+	"be-itts-community/pkg/oauth"
 	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
 	routes "be-itts-community/route"
 )
 
@@ -54,6 +61,22 @@ func main() {
 	}
 	log.WithFields(map[string]any{"host": cfg.DB.Host}).Info("database connected")
 
+	// Permission catalogue: upsert what internal/rbac declares so route
+	// permissions exist. "rbac-sync" runs only the sync.
+	if len(os.Args) > 1 && os.Args[1] == "rbac-sync" {
+		if err := syncPermissionCatalogue(dbConn, log); err != nil {
+			log.Critical("failed to sync permission catalogue", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if !cfg.RBAC.SkipSync {
+		if err := syncPermissionCatalogue(dbConn, log); err != nil {
+			log.Critical("failed to sync permission catalogue", err)
+			os.Exit(1)
+		}
+	}
+
 	r := chi.NewRouter()
 
 	// Core middlewares
@@ -108,18 +131,70 @@ func main() {
 	// Wire repository tracer for instrumentation
 	repository.RepoTracer = tracer
 
-	// Locker: use Redis if configured; else noop
+	// Locker and login attempt counters: use Redis if configured; else noop/in-memory
 	locker := lock.NewNoopLocker()
+	var attempts attempt.Store = attempt.NewMemoryStore()
+
+	// Permission cache: shared through Redis if configured; else per process
+	permCacheTTL := 5 * time.Minute
+	if cfg.PermissionCache.TTL != "" {
+		if d, err := time.ParseDuration(cfg.PermissionCache.TTL); err == nil {
+			permCacheTTL = d
+		} else {
+			log.WithError(err).Warn("invalid permission cache TTL, using default 5m")
+		}
+	}
+	permCacheSize := cfg.PermissionCache.Size
+	if permCacheSize <= 0 {
+		permCacheSize = 10000
+	}
+	var permStore permcache.Store = permcache.NewLRUStore(permCacheSize, permCacheTTL)
 	if cfg.Redis.Addr != "" {
 		client := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr, Password: cfg.Redis.Password, DB: cfg.Redis.DB})
 		if err := client.Ping(context.Background()).Err(); err != nil {
 			log.WithError(err).Warn("failed to connect redis; using noop locker")
 		} else {
 			locker = lock.NewRedisLocker(client)
+			attempts = attempt.NewRedisStore(client)
+			permStore = permcache.NewRedisStore(client, permCacheTTL)
 			log.Info("redis locker enabled")
 		}
 	}
 
+	loginProtection := service.LoginProtection{
+		Store:              attempts,
+		MaxAccountFailures: cfg.Login.MaxFailures,
+		MaxIPFailures:      cfg.Login.MaxIPFailures,
+	}
+	if cfg.Login.FailureWindow != "" {
+		if d, err := time.ParseDuration(cfg.Login.FailureWindow); err == nil {
+			loginProtection.Window = d
+		} else {
+			log.WithError(err).Warn("invalid login failure window, using default 15m")
+		}
+	}
+	if cfg.Login.LockoutDuration != "" {
+		if d, err := time.ParseDuration(cfg.Login.LockoutDuration); err == nil {
+			loginProtection.LockoutDuration = d
+		} else {
+			log.WithError(err).Warn("invalid login lockout duration, using default 15m")
+		}
+	}
+
+	// Password policy; the breached password check is optional
+	passwordPolicy := auth.PasswordPolicy{
+		MinLength:  cfg.Password.MinLength,
+		MinClasses: cfg.Password.MinClasses,
+	}
+	if cfg.Password.BreachedList != "" {
+		if list, err := auth.NewBreachedPasswordList(cfg.Password.BreachedList); err == nil {
+			passwordPolicy.Breached = list
+			log.WithFields(map[string]any{"path": cfg.Password.BreachedList}).Info("breached password check enabled")
+		} else {
+			log.WithError(err).Warn("breached password list unavailable, check disabled")
+		}
+	}
+
 	// Parse JWT durations
 	jwtAccessDur, err := time.ParseDuration(cfg.JWT.AccessDuration)
 	if err != nil {
@@ -132,20 +207,99 @@ func main() {
 		jwtRefreshDur = 168 * time.Hour
 	}
 
+	// JWT keys: sign with the configured PEM key when set. JWT_SECRET and any
+	// retired keys stay valid for verification so rotation keeps users logged
+	// in; JWT_SECRET stops verifying once its tokens can no longer be live.
+	jwtKeys, err := loadJWTKeys(cfg, max(jwtAccessDur, auth.ImpersonationDuration))
+	if err != nil {
+		log.Critical("failed to load JWT keys", err)
+		os.Exit(1)
+	}
+
+	// MFA secrets are encrypted at rest; keep the key stable or enrolled
+	// users will be unable to complete login. Production needs a dedicated
+	// key so a leaked JWT secret does not also expose TOTP seeds.
+	mfaKey := cfg.MFA.EncryptionKey
+	if mfaKey == "" {
+		if cfg.AppEnv == "production" {
+			log.Critical("MFA_ENCRYPTION_KEY is required in production", nil)
+			os.Exit(1)
+		}
+		log.Warn("MFA_ENCRYPTION_KEY not set; deriving MFA encryption key from JWT_SECRET (not allowed in production)")
+		mfaKey = cfg.JWT.Secret
+	}
+	mfaBox, err := auth.NewSecretBox(mfaKey)
+	if err != nil {
+		log.Critical("failed to init MFA encryption", err)
+		os.Exit(1)
+	}
+
+	// OAuth state cookies are signed so the callback can detect CSRF
+	oauthStateKey := cfg.OAuth.StateSecret
+	if oauthStateKey == "" {
+		oauthStateKey = cfg.JWT.Secret
+	}
+	oauthStates, err := oauth.NewStateCodec(oauthStateKey, 10*time.Minute)
+	if err != nil {
+		log.Critical("failed to init OAuth state signing", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Mailer: SMTP if configured; else emails stay queued in the outbox
+	var transport service.Mailer
+	if cfg.Mail.Host != "" {
+		transport = mailer.NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.User, cfg.Mail.Password, cfg.Mail.From)
+		log.WithFields(map[string]any{"host": cfg.Mail.Host}).Info("smtp mailer enabled")
+	} else {
+		log.Warn("MAIL_HOST not set; emails will be queued but not sent")
+	}
+
+	outboxInterval := 10 * time.Second
+	if cfg.Mail.OutboxInterval != "" {
+		if d, err := time.ParseDuration(cfg.Mail.OutboxInterval); err == nil {
+			outboxInterval = d
+		} else {
+			log.WithError(err).Warn("invalid mail outbox interval, using default 10s")
+		}
+	}
+	emailOutbox := service.NewEmailOutboxService(
+		repository.NewEmailOutboxRepository(dbConn),
+		repository.NewAuditLogRepository(dbConn),
+		transport,
+		cfg.Mail.OutboxMaxAttempts,
+		tracer,
+		log,
+	)
+	go emailOutbox.Run(ctx, outboxInterval)
+
 	// Routes
 	routes.RegisterRoutes(r, routes.RouteDeps{
-		DBConn:             dbConn,
-		VerifyEmailURL:     cfg.VerifyEmailURL,
-		Mailer:             nil,
-		Locker:             locker,
-		Tracer:             tracer,
-		JWTSecret:          cfg.JWT.Secret,
-		JWTAccessDur:       jwtAccessDur,
-		JWTRefreshDur:      jwtRefreshDur,
-		JWTIssuer:          cfg.JWT.Issuer,
-		GitHubClientID:     cfg.OAuth.GitHub.ClientID,
-		GitHubClientSecret: cfg.OAuth.GitHub.ClientSecret,
-		GitHubRedirectURI:  cfg.OAuth.GitHub.RedirectURI,
+		DBConn:               dbConn,
+		VerifyEmailURL:       cfg.VerifyEmailURL,
+		ResetPasswordURL:     cfg.ResetPasswordURL,
+		InviteAcceptURL:      cfg.InviteAcceptURL,
+		MagicLinkURL:         cfg.MagicLinkURL,
+		EmailChangeURL:       cfg.EmailChangeURL,
+		EmailChangeCancelURL: cfg.EmailChangeCancelURL,
+		EmailOutbox:          emailOutbox,
+		Locker:               locker,
+		Tracer:               tracer,
+		JWTAccessDur:         jwtAccessDur,
+		JWTRefreshDur:        jwtRefreshDur,
+		JWTIssuer:            cfg.JWT.Issuer,
+		JWTKeys:              jwtKeys,
+		MFASecretBox:         mfaBox,
+		TrustProxy:           cfg.TrustProxy,
+		LoginProtection:      loginProtection,
+		PasswordPolicy:       passwordPolicy,
+		PermissionCache:      permcache.New(permStore),
+		OAuthStates:          oauthStates,
+		OAuthFrontendURL:     cfg.OAuth.FrontendURL,
+		OAuthProviders:       loadOAuthProviders(cfg),
+		Logger:               log,
 	})
 
 	port := cfg.AppPort
@@ -161,9 +315,6 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	errCh := make(chan error, 1)
 	go func() {
 		log.WithFields(map[string]any{"addr": srv.Addr}).Info("listening")
@@ -192,3 +343,111 @@ func main() {
 	log.Info("server stopped cleanly")
 	os.Exit(0)
 }
+
+// loadJWTKeys builds the JWT key set from config. Without JWT_SIGNING_KEY_FILE
+// tokens are signed with JWT_SECRET (HS256). Otherwise JWT_SECRET is retired at
+// JWT_SECRET_RETIRE_AT, or maxTokenAge after startup when that is unset.
+func loadJWTKeys(cfg *config.Config, maxTokenAge time.Duration) (*auth.KeySet, error) {
+	var legacy *auth.SigningKey
+	if cfg.JWT.Secret != "" {
+		legacy = auth.NewHMACKey(auth.LegacyHMACKeyID, []byte(cfg.JWT.Secret))
+	}
+	if cfg.JWT.SigningKeyFile == "" {
+		if legacy == nil {
+			return nil, fmt.Errorf("JWT_SECRET or JWT_SIGNING_KEY_FILE must be set")
+		}
+		return auth.NewKeySet(legacy)
+	}
+
+	kid := cfg.JWT.SigningKeyID
+	if kid == "" {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_ID is required with JWT_SIGNING_KEY_FILE")
+	}
+	active, err := auth.LoadPEMKeyFile(kid, cfg.JWT.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if legacy != nil {
+		legacy.RetireAt = time.Now().Add(maxTokenAge)
+		if cfg.JWT.SecretRetireAt != "" {
+			legacy.RetireAt, err = time.Parse(time.RFC3339, cfg.JWT.SecretRetireAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JWT_SECRET_RETIRE_AT: %w", err)
+			}
+		}
+	}
+
+	// JWT_VERIFY_KEY_FILES: comma-separated kid:path pairs of retired keys
+	retired := []*auth.SigningKey{legacy}
+	for _, entry := range strings.Split(cfg.JWT.VerifyKeyFiles, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_VERIFY_KEY_FILES entry %q, expected kid:path", entry)
+		}
+		key, err := auth.LoadPEMKeyFile(strings.TrimSpace(id), strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		retired = append(retired, key)
+	}
+
+	return auth.NewKeySet(active, retired...)
+}
+
+// syncPermissionCatalogue upserts the declared permissions and logs what was
+// created and which catalogue permissions no code uses
+func syncPermissionCatalogue(dbConn db.Connection, log *core.Logger) error {
+	permissionSvc := service.NewPermissionService(
+		repository.NewPermissionRepository(dbConn),
+		repository.NewAuditLogRepository(dbConn),
+		nil,
+		nil,
+		log,
+	)
+	report, err := permissionSvc.SyncCatalogue(context.Background())
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(map[string]any{
+		"created_resources":   report.CreatedResources,
+		"created_actions":     report.CreatedActions,
+		"created_permissions": report.CreatedPermissions,
+	}).Info("permission catalogue synced")
+	if len(report.Orphaned) > 0 {
+		log.WithFields(map[string]any{"permissions": report.Orphaned}).Warn("permissions not used by any route or service")
+	}
+	return nil
+}
+
+// loadOAuthProviders enables each login provider that has a client ID
+func loadOAuthProviders(cfg *config.Config) *oauth.Registry {
+	providers := oauth.NewRegistry()
+
+	if gh := cfg.OAuth.GitHub; gh.ClientID != "" {
+		providers.Register(oauth.NewGitHubOAuthClient(gh.ClientID, gh.ClientSecret, gh.RedirectURI))
+	}
+	if g := cfg.OAuth.Google; g.ClientID != "" {
+		providers.Register(oauth.NewGoogleProvider(g.ClientID, g.ClientSecret, g.RedirectURI))
+	}
+	if o := cfg.OAuth.OIDC; o.Issuer != "" && o.ClientID != "" {
+		name := o.Name
+		if name == "" {
+			name = "oidc"
+		}
+		providers.Register(oauth.NewOIDCProvider(oauth.OIDCConfig{
+			Name:         name,
+			Issuer:       o.Issuer,
+			ClientID:     o.ClientID,
+			ClientSecret: o.ClientSecret,
+			RedirectURI:  o.RedirectURI,
+			Scopes:       strings.Fields(o.Scopes),
+		}))
+	}
+
+	return providers
+}
@@ -14,6 +14,12 @@ type Config struct {
     Prefork        bool
     Workers        int
     VerifyEmailURL string
+    ResetPasswordURL string
+    InviteAcceptURL string
+    MagicLinkURL   string
+    EmailChangeURL string
+    EmailChangeCancelURL string
+    TrustProxy     bool
 
 	DB struct {
 		Host     string
@@ -31,6 +37,9 @@ type Config struct {
         User     string
         Password string
         From     string
+
+        OutboxInterval    string
+        OutboxMaxAttempts int
     }
 
     LogLevel string
@@ -52,14 +61,61 @@ type Config struct {
         AccessDuration    string
         RefreshDuration   string
         Issuer            string
+
+        SigningKeyID      string
+        SigningKeyFile    string
+        VerifyKeyFiles    string
+        SecretRetireAt    string
+    }
+
+    MFA struct {
+        EncryptionKey string
+    }
+
+    Password struct {
+        MinLength    int
+        MinClasses   int
+        BreachedList string
+    }
+
+    Login struct {
+        MaxFailures     int
+        MaxIPFailures   int
+        FailureWindow   string
+        LockoutDuration string
+    }
+
+    PermissionCache struct {
+        TTL  string
+        Size int
+    }
+
+    RBAC struct {
+        SkipSync bool
     }
 
     OAuth struct {
+        StateSecret string
+        FrontendURL string
         GitHub struct {
             ClientID      string
             ClientSecret  string
             RedirectURI   string
         }
+        Google struct {
+            ClientID      string
+            ClientSecret  string
+            RedirectURI   string
+        }
+        // OIDC is an additional generic OpenID Connect provider
+        OIDC struct {
+            Name          string
+            Issuer        string
+            ClientID      string
+            ClientSecret  string
+            RedirectURI   string
+            Scopes        string
+        }
     }
 }
 
@@ -80,6 +136,12 @@ func LoadConfig() *Config {
 	cfg.Prefork = viper.GetBool("APP_PREFORK")
 	cfg.Workers = viper.GetInt("APP_WORKERS")
 	cfg.VerifyEmailURL = viper.GetString("VERIFY_EMAIL_URL")
+	cfg.ResetPasswordURL = viper.GetString("RESET_PASSWORD_URL")
+	cfg.InviteAcceptURL = viper.GetString("INVITE_ACCEPT_URL")
+	cfg.MagicLinkURL = viper.GetString("MAGIC_LINK_URL")
+	cfg.EmailChangeURL = viper.GetString("EMAIL_CHANGE_CONFIRM_URL")
+	cfg.EmailChangeCancelURL = viper.GetString("EMAIL_CHANGE_CANCEL_URL")
+	cfg.TrustProxy = viper.GetBool("APP_TRUST_PROXY")
 
 	cfg.DB.Host = viper.GetString("DB_HOST")
 	cfg.DB.Port = viper.GetString("DB_PORT")
@@ -94,6 +156,8 @@ func LoadConfig() *Config {
 	cfg.Mail.User = viper.GetString("MAIL_USER")
 	cfg.Mail.Password = viper.GetString("MAIL_PASSWORD")
 	cfg.Mail.From = viper.GetString("MAIL_FROM")
+	cfg.Mail.OutboxInterval = viper.GetString("MAIL_OUTBOX_INTERVAL")
+	cfg.Mail.OutboxMaxAttempts = viper.GetInt("MAIL_OUTBOX_MAX_ATTEMPTS")
 
     cfg.LogLevel = viper.GetString("LOG_LEVEL")
 
@@ -109,10 +173,41 @@ func LoadConfig() *Config {
     cfg.JWT.AccessDuration = viper.GetString("JWT_ACCESS_DURATION")
     cfg.JWT.RefreshDuration = viper.GetString("JWT_REFRESH_DURATION")
     cfg.JWT.Issuer = viper.GetString("JWT_ISSUER")
+    cfg.JWT.SigningKeyID = viper.GetString("JWT_SIGNING_KEY_ID")
+    cfg.JWT.SigningKeyFile = viper.GetString("JWT_SIGNING_KEY_FILE")
+    cfg.JWT.VerifyKeyFiles = viper.GetString("JWT_VERIFY_KEY_FILES")
+    cfg.JWT.SecretRetireAt = viper.GetString("JWT_SECRET_RETIRE_AT")
+
+    cfg.MFA.EncryptionKey = viper.GetString("MFA_ENCRYPTION_KEY")
+
+    cfg.Password.MinLength = viper.GetInt("PASSWORD_MIN_LENGTH")
+    cfg.Password.MinClasses = viper.GetInt("PASSWORD_MIN_CLASSES")
+    cfg.Password.BreachedList = viper.GetString("PASSWORD_BREACHED_LIST")
+
+    cfg.Login.MaxFailures = viper.GetInt("LOGIN_MAX_FAILURES")
+    cfg.Login.MaxIPFailures = viper.GetInt("LOGIN_MAX_IP_FAILURES")
+    cfg.Login.FailureWindow = viper.GetString("LOGIN_FAILURE_WINDOW")
+    cfg.Login.LockoutDuration = viper.GetString("LOGIN_LOCKOUT_DURATION")
+
+    cfg.PermissionCache.TTL = viper.GetString("PERMISSION_CACHE_TTL")
+    cfg.PermissionCache.Size = viper.GetInt("PERMISSION_CACHE_SIZE")
+
+    cfg.RBAC.SkipSync = viper.GetBool("RBAC_SKIP_SYNC")
 
+    cfg.OAuth.StateSecret = viper.GetString("OAUTH_STATE_SECRET")
+    cfg.OAuth.FrontendURL = viper.GetString("OAUTH_FRONTEND_URL")
     cfg.OAuth.GitHub.ClientID = viper.GetString("GITHUB_CLIENT_ID")
     cfg.OAuth.GitHub.ClientSecret = viper.GetString("GITHUB_CLIENT_SECRET")
     cfg.OAuth.GitHub.RedirectURI = viper.GetString("GITHUB_REDIRECT_URI")
+    cfg.OAuth.Google.ClientID = viper.GetString("GOOGLE_CLIENT_ID")
+    cfg.OAuth.Google.ClientSecret = viper.GetString("GOOGLE_CLIENT_SECRET")
+    cfg.OAuth.Google.RedirectURI = viper.GetString("GOOGLE_REDIRECT_URI")
+    cfg.OAuth.OIDC.Name = viper.GetString("OIDC_PROVIDER_NAME")
+    cfg.OAuth.OIDC.Issuer = viper.GetString("OIDC_ISSUER")
+    cfg.OAuth.OIDC.ClientID = viper.GetString("OIDC_CLIENT_ID")
+    cfg.OAuth.OIDC.ClientSecret = viper.GetString("OIDC_CLIENT_SECRET")
+    cfg.OAuth.OIDC.RedirectURI = viper.GetString("OIDC_REDIRECT_URI")
+    cfg.OAuth.OIDC.Scopes = viper.GetString("OIDC_SCOPES")
 
     return cfg
 }
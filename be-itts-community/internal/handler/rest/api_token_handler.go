@@ -0,0 +1,66 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+	"github.com/go-chi/chi/v5"
+
+	"be-itts-community/internal/middleware"
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/service"
+)
+
+type APITokenHandler struct {
+	svc service.APITokenService
+}
+
+func NewAPITokenHandler(svc service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{svc: svc}
+}
+
+// List returns the current user's API tokens
+func (h *APITokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	tokens, err := h.svc.ListTokens(r.Context(), authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, tokens)
+}
+
+// Create issues a new API token; the plain token is only shown once
+func (h *APITokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.svc.CreateToken(r.Context(), authCtx.UserID, req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.Created(w, r, resp)
+}
+
+// Revoke revokes one of the current user's API tokens
+func (h *APITokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	tokenID := chi.URLParam(r, "tokenId")
+
+	if err := h.svc.RevokeToken(r.Context(), authCtx.UserID, tokenID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/daisyorscry/itts/core"
+	"github.com/go-chi/chi/v5"
 
 	"be-itts-community/internal/middleware"
 	"be-itts-community/internal/model"
@@ -12,12 +13,20 @@ import (
 )
 
 type AuthHandler struct {
-	authService service.AuthService
+	authService          service.AuthService
+	resetPasswordURL     string
+	magicLinkURL         string
+	emailChangeURL       string
+	emailChangeCancelURL string
 }
 
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
+func NewAuthHandler(authService service.AuthService, resetPasswordURL, magicLinkURL, emailChangeURL, emailChangeCancelURL string) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:          authService,
+		resetPasswordURL:     resetPasswordURL,
+		magicLinkURL:         magicLinkURL,
+		emailChangeURL:       emailChangeURL,
+		emailChangeCancelURL: emailChangeCancelURL,
 	}
 }
 
@@ -29,12 +38,59 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.Login(r.Context(), req)
+	resp, challenge, err := h.authService.Login(r.Context(), req)
 	if err != nil {
 		core.RespondError(w, r, err)
 		return
 	}
 
+	// Password accepted but a second factor is still required
+	if challenge != nil {
+		core.OK(w, r, challenge)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// RequestMagicLink emails a passwordless login link if the email belongs to an account
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req model.MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.RequestMagicLink(r.Context(), req, h.magicLinkURL); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, map[string]any{
+		"message": "If the email is registered, a login link has been sent",
+	})
+}
+
+// ConsumeMagicLink logs in with the token from a magic link email
+func (h *AuthHandler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req model.MagicLinkConsumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, challenge, err := h.authService.ConsumeMagicLink(r.Context(), req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	// Link accepted but a second factor is still required
+	if challenge != nil {
+		core.OK(w, r, challenge)
+		return
+	}
+
 	core.OK(w, r, resp)
 }
 
@@ -84,7 +140,8 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	core.OK(w, r, user)
 }
 
-// UpdateProfile handles current user profile update
+// UpdateProfile handles current user profile update. A new email is only
+// applied once confirmed, see ConfirmEmailChange.
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	authCtx := middleware.MustGetAuthContext(r.Context())
 
@@ -103,6 +160,60 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	core.OK(w, r, user)
 }
 
+// RequestEmailChange starts an email change after checking the current
+// password
+func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	user, err := h.authService.RequestEmailChange(r.Context(), authCtx.UserID, req, h.emailChangeURL, h.emailChangeCancelURL)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, user)
+}
+
+// ConfirmEmailChange applies a pending email change from the link sent to
+// the new address
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req model.ConfirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.ConfirmEmailChange(r.Context(), req); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// CancelEmailChange withdraws a pending email change from the link sent to
+// the current address
+func (h *AuthHandler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req model.CancelEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.CancelEmailChange(r.Context(), req); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
 // ChangePassword handles password change
 func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	authCtx := middleware.MustGetAuthContext(r.Context())
@@ -120,3 +231,239 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 
 	core.NoContent(w, r)
 }
+
+// ForgotPassword sends a password reset link if the email belongs to an account
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), req, h.resetPasswordURL); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, map[string]any{
+		"message": "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password using a reset token from email
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordWithTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.ResetPasswordWithToken(r.Context(), req); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// VerifyMFA completes login with a TOTP or recovery code
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req model.MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.VerifyMFA(r.Context(), req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// BeginMFASetup starts enrollment for a user whose role requires MFA
+func (h *AuthHandler) BeginMFASetup(w http.ResponseWriter, r *http.Request) {
+	var req model.MFASetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.BeginMFASetup(r.Context(), req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// CompleteMFASetup activates MFA and completes login
+func (h *AuthHandler) CompleteMFASetup(w http.ResponseWriter, r *http.Request) {
+	var req model.MFASetupConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.CompleteMFASetup(r.Context(), req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// EnrollMFA generates a TOTP secret and provisioning URI for the current user
+func (h *AuthHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	resp, err := h.authService.EnrollMFA(r.Context(), authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// ActivateMFA confirms enrollment and returns recovery codes
+func (h *AuthHandler) ActivateMFA(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.MFAActivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.ActivateMFA(r.Context(), authCtx.UserID, req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// DisableMFA turns off MFA for the current user
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.DisableMFA(r.Context(), authCtx.UserID, req); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.RegenerateRecoveryCodes(r.Context(), authCtx.UserID, req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
+
+// ListSessions lists the current user's active sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	sessions, err := h.authService.ListSessions(r.Context(), authCtx.UserID, authCtx.SessionID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, sessions)
+}
+
+// RevokeSession revokes one of the current user's sessions
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	if err := h.authService.RevokeSession(r.Context(), authCtx.UserID, sessionID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// RevokeOtherSessions revokes all of the current user's sessions except this one
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	if err := h.authService.RevokeOtherSessions(r.Context(), authCtx.UserID, authCtx.SessionID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// ListIdentities lists the current user's linked login providers
+func (h *AuthHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	identities, err := h.authService.ListIdentities(r.Context(), authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, identities)
+}
+
+// ConfirmIdentityLink links a provider account that was matched by email
+func (h *AuthHandler) ConfirmIdentityLink(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.ConfirmIdentityLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	if err := h.authService.ConfirmIdentityLink(r.Context(), authCtx.UserID, req); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// UnlinkIdentity removes one of the current user's linked providers
+func (h *AuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	identityID := chi.URLParam(r, "identityId")
+
+	if err := h.authService.UnlinkIdentity(r.Context(), authCtx.UserID, identityID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+	"github.com/go-chi/chi/v5"
+
+	"be-itts-community/internal/repository"
+	"be-itts-community/internal/service"
+)
+
+type EmailOutboxHandler struct {
+	svc service.EmailOutboxService
+}
+
+func NewEmailOutboxHandler(svc service.EmailOutboxService) *EmailOutboxHandler {
+	return &EmailOutboxHandler{svc: svc}
+}
+
+// AdminList lists queued, sent and dead-lettered emails
+// GET /admin/email-outbox?status=dead&recipient=...
+func (h *EmailOutboxHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	lp := repository.ListParams{
+		Search:   r.URL.Query().Get("search"),
+		Filters:  make(map[string]any),
+		Sort:     parseSorts(r.URL.Query().Get("sort")),
+		Page:     atoiDefault(r.URL.Query().Get("page"), 1),
+		PageSize: atoiDefault(r.URL.Query().Get("page_size"), 20),
+	}
+	if v := r.URL.Query().Get("status"); v != "" {
+		lp.Filters["status"] = v
+	}
+	if v := r.URL.Query().Get("recipient"); v != "" {
+		lp.Filters["recipient"] = v
+	}
+
+	res, err := h.svc.AdminList(r.Context(), lp)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, res)
+}
+
+// AdminGet returns a single outbox entry
+// GET /admin/email-outbox/{id}
+func (h *EmailOutboxHandler) AdminGet(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	rec, err := h.svc.AdminGet(r.Context(), id)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, rec)
+}
+
+// AdminResend requeues a failed, dead-lettered or stuck (expired lease) email
+// POST /admin/email-outbox/{id}/resend
+func (h *EmailOutboxHandler) AdminResend(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	rec, err := h.svc.AdminResend(r.Context(), id)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, rec)
+}
@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+	"github.com/go-chi/chi/v5"
+
+	"be-itts-community/internal/middleware"
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/internal/service"
+)
+
+type InvitationHandler struct {
+	svc         service.InvitationService
+	authService service.AuthService
+}
+
+func NewInvitationHandler(svc service.InvitationService, authService service.AuthService) *InvitationHandler {
+	return &InvitationHandler{svc: svc, authService: authService}
+}
+
+// AdminCreate invites a new user by email
+// POST /admin/invitations
+func (h *InvitationHandler) AdminCreate(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	var req model.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	inv, err := h.svc.Invite(r.Context(), req, authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.Created(w, r, inv)
+}
+
+// AdminList lists invitations
+// GET /admin/invitations?status=pending|accepted|revoked|expired
+func (h *InvitationHandler) AdminList(w http.ResponseWriter, r *http.Request) {
+	lp := repository.ListParams{
+		Search:   r.URL.Query().Get("search"),
+		Filters:  make(map[string]any),
+		Sort:     parseSorts(r.URL.Query().Get("sort")),
+		Page:     atoiDefault(r.URL.Query().Get("page"), 1),
+		PageSize: atoiDefault(r.URL.Query().Get("page_size"), 20),
+	}
+	if v := r.URL.Query().Get("status"); v != "" {
+		lp.Filters["status"] = v
+	}
+
+	res, err := h.svc.List(r.Context(), lp)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, res)
+}
+
+// AdminResend sends a fresh invitation link
+// POST /admin/invitations/{id}/resend
+func (h *InvitationHandler) AdminResend(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	inv, err := h.svc.Resend(r.Context(), id, authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, inv)
+}
+
+// AdminRevoke cancels a pending invitation
+// DELETE /admin/invitations/{id}
+func (h *InvitationHandler) AdminRevoke(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if err := h.svc.Revoke(r.Context(), id, authCtx.UserID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.NoContent(w, r)
+}
+
+// Preview shows who the invitation is for before it is accepted
+// GET /auth/invitations/{token}
+func (h *InvitationHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	preview, err := h.svc.Preview(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	core.OK(w, r, preview)
+}
+
+// Accept creates the invited account with a password and logs it in
+// POST /auth/invitations/accept
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	var req model.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	user, err := h.svc.AcceptWithPassword(r.Context(), req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	resp, challenge, err := h.authService.Login(r.Context(), model.LoginRequest{
+		Email:    user.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	// An invited role may require MFA enrollment before the first login
+	if challenge != nil {
+		core.OK(w, r, challenge)
+		return
+	}
+	core.OK(w, r, resp)
+}
@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/pkg/auth"
+)
+
+type JWKSHandler struct {
+	keys *auth.KeySet
+}
+
+func NewJWKSHandler(keys *auth.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS publishes the public keys used to sign access tokens. The document is
+// served as-is (no response envelope) as required by JWKS consumers.
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	core.WriteJSON(w, http.StatusOK, h.keys.JWKS())
+}
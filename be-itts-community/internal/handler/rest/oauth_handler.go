@@ -1,130 +1,364 @@
 package rest
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/daisyorscry/itts/core"
+	"github.com/go-chi/chi/v5"
 
+	"be-itts-community/internal/middleware"
+	"be-itts-community/internal/model"
 	"be-itts-community/internal/service"
 	"be-itts-community/pkg/oauth"
 )
 
+const (
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/v1/auth/oauth"
+)
+
 type OAuthHandler struct {
-	authService  service.AuthService
-	githubClient *oauth.GitHubOAuthClient
+	authService       service.AuthService
+	invitationService service.InvitationService
+	providers         *oauth.Registry
+	states            *oauth.StateCodec
+	frontendURL       string
+	log               *core.Logger
 }
 
-// NewOAuthHandler creates a new OAuth handler
-func NewOAuthHandler(authService service.AuthService, githubClient *oauth.GitHubOAuthClient) *OAuthHandler {
+// NewOAuthHandler creates a new OAuth handler. frontendURL is where users
+// land after the callback, e.g. http://localhost:3000.
+func NewOAuthHandler(authService service.AuthService, invitationService service.InvitationService, providers *oauth.Registry, states *oauth.StateCodec, frontendURL string, log *core.Logger) *OAuthHandler {
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "oauth"})
+	}
 	return &OAuthHandler{
-		authService:  authService,
-		githubClient: githubClient,
+		authService:       authService,
+		invitationService: invitationService,
+		providers:         providers,
+		states:            states,
+		frontendURL:       strings.TrimRight(frontendURL, "/"),
+		log:               log,
+	}
+}
+
+// ListProviders returns the names of the enabled login providers
+// GET /api/v1/auth/oauth
+func (h *OAuthHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	if h.providers != nil && h.states != nil {
+		names = h.providers.Names()
 	}
+	core.OK(w, r, map[string]any{"providers": names})
 }
 
-// HandleGitHubAuth redirects to GitHub OAuth page
-// GET /api/v1/auth/oauth/github
-func (h *OAuthHandler) HandleGitHubAuth(w http.ResponseWriter, r *http.Request) {
-	state := r.URL.Query().Get("state")
-	if state == "" {
-		state = generateRandomState()
+// HandleAuth redirects to the provider's login page. The optional
+// redirect_to query parameter is a frontend path to return to after login,
+// and invitation accepts a pending invitation with the provider account.
+// GET /api/v1/auth/oauth/{provider}
+func (h *OAuthHandler) HandleAuth(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.provider(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.states.New(provider.Name(), safeRedirectPath(r.URL.Query().Get("redirect_to")))
+	if err != nil {
+		core.RespondError(w, r, core.InternalServerError("failed to start oauth login").WithError(err))
+		return
+	}
+
+	// Linking a provider to a signed-in user (see StartLink)
+	if ticket := r.URL.Query().Get("link_ticket"); ticket != "" {
+		userID, err := h.states.VerifyLinkTicket(ticket, provider.Name())
+		if err != nil {
+			core.WriteError(w, r, http.StatusBadRequest, "INVALID_STATE", "invalid or expired link ticket", nil)
+			return
+		}
+		state.Purpose = oauth.StatePurposeLink
+		state.LinkUserID = userID
+	} else if token := r.URL.Query().Get("invitation"); token != "" {
+		// Fail before the provider round trip if the invitation is unusable
+		if _, err := h.invitationService.Preview(r.Context(), token); err != nil {
+			core.RespondError(w, r, err)
+			return
+		}
+		state.Purpose = oauth.StatePurposeInvite
+		state.Invitation = token
+	}
+	encoded, err := h.states.Encode(state)
+	if err != nil {
+		core.RespondError(w, r, core.InternalServerError("failed to start oauth login").WithError(err))
+		return
+	}
+
+	authURL, err := provider.AuthURL(r.Context(), state)
+	if err != nil {
+		core.RespondError(w, r, core.ServiceUnavailable("OAuth provider is unavailable").WithError(err))
+		return
 	}
 
-	authURL := h.githubClient.GetAuthURL(state)
+	// Lax so the cookie survives the top-level redirect back from the provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    encoded,
+		Path:     oauthStateCookiePath,
+		MaxAge:   int(h.states.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.frontendURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-// HandleGitHubCallback handles GitHub OAuth callback
-// GET /api/v1/auth/oauth/github/callback
-func (h *OAuthHandler) HandleGitHubCallback(w http.ResponseWriter, r *http.Request) {
+// HandleCallback handles the provider's OAuth callback
+// GET /api/v1/auth/oauth/{provider}/callback
+func (h *OAuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	provider, ok := h.provider(w, r)
+	if !ok {
+		return
+	}
+
 	// Parse query parameters
 	code := r.URL.Query().Get("code")
-	state := r.URL.Query().Get("state")
+	stateParam := r.URL.Query().Get("state")
+
+	// The state cookie is single use
+	cookie, cookieErr := r.Cookie(oauthStateCookie)
+	h.clearStateCookie(w)
+
+	// User denied access or the provider reported an error
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		http.Redirect(w, r, h.frontend("/login", url.Values{"error": {providerErr}}), http.StatusTemporaryRedirect)
+		return
+	}
 
 	if code == "" {
 		core.WriteError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "missing authorization code", nil)
 		return
 	}
 
-	// TODO: Validate state parameter for CSRF protection
-	_ = state
-
-	// Exchange code for access token
-	accessToken, err := h.githubClient.ExchangeCode(ctx, code)
+	// CSRF protection: the state must match the one we issued to this browser
+	if cookieErr != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_STATE", "missing oauth state", nil)
+		return
+	}
+	state, err := h.states.Verify(cookie.Value, provider.Name(), stateParam)
 	if err != nil {
-		core.RespondError(w, r, core.BadRequest(fmt.Sprintf("Failed to exchange code: %v", err)))
+		msg := "oauth state mismatch"
+		if errors.Is(err, oauth.ErrExpiredState) {
+			msg = "oauth login expired, please try again"
+		}
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_STATE", msg, nil)
 		return
 	}
 
-	// Get GitHub user profile
-	githubUser, err := h.githubClient.GetUser(ctx, accessToken)
+	// Exchange code for the user's identity
+	info, err := provider.Exchange(ctx, code, state)
 	if err != nil {
-		core.RespondError(w, r, core.BadRequest(fmt.Sprintf("Failed to get GitHub user: %v", err)))
+		core.RespondError(w, r, core.BadRequest(fmt.Sprintf("Failed to complete %s login: %v", provider.Name(), err)))
+		return
+	}
+
+	// Linking flow: attach the provider account to the user who started it
+	if state.Purpose == oauth.StatePurposeLink {
+		h.finishLink(w, r, provider.Name(), state, info)
 		return
 	}
 
+	// Invitation flow: create the invited user, then log in as usual below
+	if state.Purpose == oauth.StatePurposeInvite {
+		user, err := h.invitationService.AcceptWithOAuth(ctx, state.Invitation, provider.Name(), info.ProviderUserID, info.Name, info.Data)
+		if err != nil {
+			msg := err.Error()
+			if appErr, ok := core.IsAppError(err); ok {
+				msg = appErr.Message
+			}
+			http.Redirect(w, r, h.frontend("/accept-invitation", url.Values{"error": {msg}}), http.StatusTemporaryRedirect)
+			return
+		}
+		info.Email = user.Email
+		info.EmailVerified = true
+		if info.Name == "" {
+			info.Name = user.FullName
+		}
+	}
+
 	// Validate required fields
-	if githubUser.Email == "" {
-		core.RespondError(w, r, core.BadRequest("GitHub account must have a verified email"))
+	if info.Email == "" || !info.EmailVerified {
+		core.RespondError(w, r, core.BadRequest("OAuth account must have a verified email"))
 		return
 	}
 
 	// Prepare full name
-	fullName := githubUser.Name
+	fullName := info.Name
 	if fullName == "" {
-		fullName = githubUser.Login // fallback to login if name not set
-	}
-
-	// Prepare provider data
-	providerData := map[string]interface{}{
-		"login":      githubUser.Login,
-		"avatar_url": githubUser.AvatarURL,
-		"bio":        githubUser.Bio,
-		"location":   githubUser.Location,
-		"company":    githubUser.Company,
+		fullName = info.Email
 	}
 
 	// Handle OAuth callback in auth service
-	response, err := h.authService.HandleOAuthCallback(
+	response, challenge, err := h.authService.HandleOAuthCallback(
 		ctx,
-		"github",
-		strconv.FormatInt(githubUser.ID, 10),
-		githubUser.Email,
+		provider.Name(),
+		info.ProviderUserID,
+		info.Email,
 		fullName,
-		providerData,
+		info.Data,
 	)
 	if err != nil {
+		// Email matches an existing account: the owner must sign in and
+		// confirm the link
+		if appErr, ok := core.IsAppError(err); ok && appErr.Code == service.OAuthLinkRequiredCode {
+			params := url.Values{
+				"provider":   {provider.Name()},
+				"link_token": {fmt.Sprint(appErr.Details["link_token"])},
+			}
+			http.Redirect(w, r, h.frontend("/auth/link", params), http.StatusTemporaryRedirect)
+			return
+		}
+
 		// Redirect to frontend with error
-		errorURL := fmt.Sprintf("http://localhost:3000/login?error=%s", err.Error())
-		http.Redirect(w, r, errorURL, http.StatusTemporaryRedirect)
+		http.Redirect(w, r, h.frontend("/login", url.Values{"error": {err.Error()}}), http.StatusTemporaryRedirect)
+		return
+	}
+
+	// Second factor required: hand the challenge token to the frontend MFA page
+	if challenge != nil {
+		params := url.Values{
+			"mfa_token":           {challenge.MFAToken},
+			"enrollment_required": {strconv.FormatBool(challenge.EnrollmentRequired)},
+		}
+		if state.RedirectTo != "" {
+			params.Set("redirect_to", state.RedirectTo)
+		}
+		http.Redirect(w, r, h.frontend("/auth/mfa", params), http.StatusTemporaryRedirect)
 		return
 	}
 
 	// Redirect to frontend with tokens
-	// Frontend will receive tokens as query params and store them
-	successURL := fmt.Sprintf(
-		"http://localhost:3000/auth/callback?access_token=%s&refresh_token=%s&expires_in=%d",
-		response.AccessToken,
-		response.RefreshToken,
-		response.ExpiresIn,
-	)
-	http.Redirect(w, r, successURL, http.StatusTemporaryRedirect)
+	// Frontend will receive tokens as query params, store them and continue
+	// to redirect_to
+	params := url.Values{
+		"access_token":  {response.AccessToken},
+		"refresh_token": {response.RefreshToken},
+		"expires_in":    {strconv.FormatInt(response.ExpiresIn, 10)},
+	}
+	if state.RedirectTo != "" {
+		params.Set("redirect_to", state.RedirectTo)
+	}
+	http.Redirect(w, r, h.frontend("/auth/callback", params), http.StatusTemporaryRedirect)
+}
+
+// StartLink issues a ticket for linking a provider to the current user. The
+// frontend then opens the returned URL, which starts the provider login. The
+// ticket only starts the flow: the callback hands back a link token that the
+// signed-in user confirms, so a ticket opened in another browser links nothing.
+// POST /api/v1/auth/me/identities/{provider}
+func (h *OAuthHandler) StartLink(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+
+	provider, ok := h.provider(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.states.NewLinkTicket(provider.Name(), authCtx.UserID)
+	if err != nil {
+		core.RespondError(w, r, core.InternalServerError("failed to start linking").WithError(err))
+		return
+	}
+
+	params := url.Values{"link_ticket": {ticket}}
+	if redirectTo := safeRedirectPath(r.URL.Query().Get("redirect_to")); redirectTo != "" {
+		params.Set("redirect_to", redirectTo)
+	}
+
+	core.OK(w, r, model.IdentityLinkStartResponse{
+		LinkTicket: ticket,
+		URL:        oauthStateCookiePath + "/" + provider.Name() + "?" + params.Encode(),
+		ExpiresIn:  int64(oauth.LinkTicketDuration.Seconds()),
+	})
 }
 
-// Helper: generate random state for CSRF protection
-func generateRandomState() string {
-	// Generate cryptographically secure random state
-	b := make([]byte, 16)
-	if _, err := rand.Read(b); err != nil {
-		// Fallback to timestamp if crypto/rand fails
-		return fmt.Sprintf("%d", time.Now().UnixNano())
+// finishLink sends the browser to the frontend link page with a link token.
+// The signed-in user confirms it via POST /auth/me/identities/confirm, which
+// only succeeds for the user who started the link.
+func (h *OAuthHandler) finishLink(w http.ResponseWriter, r *http.Request, provider string, state *oauth.State, info *oauth.UserInfo) {
+	target := state.RedirectTo
+	if target == "" {
+		target = "/settings/identities"
+	}
+
+	linkToken, err := h.authService.StartIdentityLink(r.Context(), state.LinkUserID, provider, info.ProviderUserID, info.Data)
+	if err != nil {
+		msg := "Failed to link " + provider + " account, please try again"
+		if appErr, ok := core.IsAppError(err); ok && appErr.HTTPStatus < http.StatusInternalServerError {
+			msg = appErr.Message
+		} else {
+			h.log.WithError(err).WithFields(map[string]any{"provider": provider, "user_id": state.LinkUserID}).Warn("oauth link failed")
+		}
+		http.Redirect(w, r, h.frontend(target, url.Values{"error": {msg}}), http.StatusTemporaryRedirect)
+		return
+	}
+
+	params := url.Values{
+		"provider":    {provider},
+		"link_token":  {linkToken},
+		"redirect_to": {target},
+	}
+	http.Redirect(w, r, h.frontend("/auth/link", params), http.StatusTemporaryRedirect)
+}
+
+// provider resolves the {provider} path parameter, writing an error response
+// when it is unknown or OAuth is not configured
+func (h *OAuthHandler) provider(w http.ResponseWriter, r *http.Request) (oauth.Provider, bool) {
+	if h.states == nil || h.providers == nil {
+		core.RespondError(w, r, core.ServiceUnavailable("OAuth login is not configured"))
+		return nil, false
+	}
+
+	name := chi.URLParam(r, "provider")
+	provider, ok := h.providers.Get(name)
+	if !ok {
+		core.RespondError(w, r, core.NotFound("oauth_provider", name))
+		return nil, false
+	}
+	return provider, true
+}
+
+func (h *OAuthHandler) clearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    "",
+		Path:     oauthStateCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.frontendURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// frontend builds a frontend URL with query parameters
+func (h *OAuthHandler) frontend(path string, params url.Values) string {
+	return h.frontendURL + path + "?" + params.Encode()
+}
+
+// safeRedirectPath only allows local paths so the login flow cannot be used
+// as an open redirect
+func safeRedirectPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return ""
+	}
+	if u, err := url.Parse(p); err != nil || u.IsAbs() || u.Host != "" {
+		return ""
 	}
-	return hex.EncodeToString(b)
+	return p
 }
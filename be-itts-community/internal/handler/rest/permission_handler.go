@@ -33,6 +33,11 @@ func (h *PermissionHandler) GetPermission(w http.ResponseWriter, r *http.Request
 	core.OK(w, r, permission)
 }
 
+// GetCacheStats reports the permission cache hit/miss counters
+func (h *PermissionHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	core.OK(w, r, h.permissionService.CacheStats())
+}
+
 // ListPermissions lists permissions with pagination
 func (h *PermissionHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 	// Parse query params
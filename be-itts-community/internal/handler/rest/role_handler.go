@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/daisyorscry/itts/core"
+	"go.yaml.in/yaml/v3"
 
 	"be-itts-community/internal/model"
 	"be-itts-community/internal/service"
@@ -113,11 +114,13 @@ func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 	core.NoContent(w, r)
 }
 
-// GetRolePermissions retrieves all permissions for a role
+// GetRolePermissions retrieves what a role allows and denies.
+// ?effective=true includes entries inherited from parent roles.
 func (h *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request) {
 	roleID := chi.URLParam(r, "id")
+	effective, _ := strconv.ParseBool(r.URL.Query().Get("effective"))
 
-	permissions, err := h.permissionService.GetRolePermissions(r.Context(), roleID)
+	permissions, err := h.permissionService.GetRolePermissions(r.Context(), roleID, effective)
 	if err != nil {
 		core.RespondError(w, r, err)
 		return
@@ -126,19 +129,18 @@ func (h *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request)
 	core.OK(w, r, permissions)
 }
 
-// AssignPermissions assigns permissions to a role
+// AssignPermissions assigns permissions to a role by ID or by name; names
+// may use wildcards such as "events:*"
 func (h *RoleHandler) AssignPermissions(w http.ResponseWriter, r *http.Request) {
 	roleID := chi.URLParam(r, "id")
 
-	var req struct {
-		PermissionIDs []string `json:"permission_ids"`
-	}
+	var req model.AssignPermissionsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
 		return
 	}
 
-	if err := h.permissionService.AssignPermissionsToRole(r.Context(), roleID, req.PermissionIDs); err != nil {
+	if err := h.permissionService.AssignPermissionsToRole(r.Context(), roleID, req); err != nil {
 		core.RespondError(w, r, err)
 		return
 	}
@@ -165,3 +167,53 @@ func (h *RoleHandler) RemovePermissions(w http.ResponseWriter, r *http.Request)
 
 	core.NoContent(w, r)
 }
+
+// maxRBACDocumentSize bounds the body of an RBAC import
+const maxRBACDocumentSize = 1 << 20
+
+// ExportRBAC downloads roles, parents and grants as a YAML document, or JSON
+// with ?format=json. The document is served as-is so it can be imported back.
+func (h *RoleHandler) ExportRBAC(w http.ResponseWriter, r *http.Request) {
+	doc, err := h.permissionService.ExportRBAC(r.Context())
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Disposition", `attachment; filename="rbac.json"`)
+		core.WriteJSON(w, http.StatusOK, doc)
+		return
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="rbac.yaml"`)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+// ImportRBAC applies an exported document (YAML or JSON). With
+// ?dry_run=true only the diff is returned.
+func (h *RoleHandler) ImportRBAC(w http.ResponseWriter, r *http.Request) {
+	var doc model.RBACDocument
+	dec := yaml.NewDecoder(http.MaxBytesReader(w, r.Body, maxRBACDocumentSize))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	result, err := h.permissionService.ImportRBAC(r.Context(), doc, dryRun)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, result)
+}
@@ -175,3 +175,98 @@ func (h *UserHandler) RemoveRoles(w http.ResponseWriter, r *http.Request) {
 
 	core.NoContent(w, r)
 }
+
+// ResetMFA clears a user's MFA enrollment (admin only)
+func (h *UserHandler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	if err := h.authService.AdminResetMFA(r.Context(), userID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// ListSessions lists a user's active sessions (admin only)
+func (h *UserHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	sessions, err := h.authService.AdminListUserSessions(r.Context(), userID)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, sessions)
+}
+
+// ExplainPermission tells why a user is or is not granted ?permission=
+func (h *UserHandler) ExplainPermission(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	explanation, err := h.authService.ExplainPermission(r.Context(), userID, r.URL.Query().Get("permission"))
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, explanation)
+}
+
+// RevokeSession revokes one of a user's sessions (admin only)
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	sessionID := chi.URLParam(r, "sessionId")
+
+	if err := h.authService.AdminRevokeUserSession(r.Context(), userID, sessionID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// RevokeAllSessions signs a user out of every session (admin only)
+func (h *UserHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	if err := h.authService.AdminRevokeAllUserSessions(r.Context(), userID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// Unlock lifts a login lockout on a user (admin only)
+func (h *UserHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	if err := h.authService.UnlockUser(r.Context(), userID); err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.NoContent(w, r)
+}
+
+// Impersonate issues a short-lived token to act as the user (admin only)
+func (h *UserHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	authCtx := middleware.MustGetAuthContext(r.Context())
+	userID := chi.URLParam(r, "id")
+
+	var req model.ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		core.WriteError(w, r, http.StatusBadRequest, "INVALID_BODY", "invalid request body", nil)
+		return
+	}
+
+	resp, err := h.authService.Impersonate(r.Context(), authCtx, userID, req)
+	if err != nil {
+		core.RespondError(w, r, err)
+		return
+	}
+
+	core.OK(w, r, resp)
+}
@@ -2,23 +2,37 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
 
 	"be-itts-community/internal/model"
+	"be-itts-community/internal/rbac"
 	"be-itts-community/pkg/auth"
 )
 
-type contextKey string
+// TokenStateSource reports the current token version and active flag of a
+// user; it returns gorm.ErrRecordNotFound for deleted users. IsSessionActive
+// reports whether the session (refresh token family) an access token was
+// issued for still has an unrevoked token.
+type TokenStateSource interface {
+	GetUserTokenState(ctx context.Context, userID string) (tokenVersion int, isActive bool, err error)
+	IsSessionActive(ctx context.Context, userID, sessionID string) (bool, error)
+}
 
-const (
-	authContextKey contextKey = "auth_context"
-)
+// APIKeyAuthenticator resolves a personal API token to an auth context
+type APIKeyAuthenticator interface {
+	AuthenticateAPIToken(ctx context.Context, rawToken string) (*model.AuthContext, error)
+}
 
-// JWTMiddleware validates JWT tokens and sets auth context
-func JWTMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler {
+// JWTMiddleware validates JWT tokens and sets auth context. When tokenState
+// is set, tokens of deactivated users or with an outdated token version are
+// rejected. When apiKeys is set, "ApiKey <token>" headers are accepted too.
+func JWTMiddleware(jwtManager *auth.JWTManager, tokenState TokenStateSource, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract token from Authorization header
@@ -29,8 +43,24 @@ func JWTMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 				return
 			}
 
-			// Check Bearer format
+			// Personal API tokens use their own scheme
 			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) == 2 && parts[0] == auth.APITokenScheme && apiKeys != nil {
+				authCtx, err := apiKeys.AuthenticateAPIToken(r.Context(), strings.TrimSpace(parts[1]))
+				if err != nil {
+					core.RespondError(w, r, err)
+					return
+				}
+
+				ctx := model.WithAuthContext(r.Context(), authCtx)
+				ctx = core.WithUserID(ctx, authCtx.UserID)
+				ctx = auth.WithAPITokenID(ctx, authCtx.APITokenID)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			// Check Bearer format
 			if len(parts) != 2 || parts[0] != "Bearer" {
 				core.WriteAppError(w, r, core.Unauthorized("Invalid authorization header format"))
 				return
@@ -49,6 +79,51 @@ func JWTMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 				return
 			}
 
+			// Reject tokens issued before roles, permissions, activation or
+			// password changed
+			if tokenState != nil {
+				version, active, err := tokenState.GetUserTokenState(r.Context(), claims.UserID)
+				if err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						core.WriteAppError(w, r, core.Unauthorized("Token has been revoked"))
+						return
+					}
+					core.WriteAppError(w, r, core.InternalServerError("failed to validate token").WithError(err))
+					return
+				}
+				if !active || version != claims.TokenVersion {
+					core.WriteAppError(w, r, core.Unauthorized("Token has been revoked"))
+					return
+				}
+
+				// Signing out a device (logout or session revoke) ends its
+				// access token right away, not when it expires
+				if claims.SessionID != "" {
+					ok, err := tokenState.IsSessionActive(r.Context(), claims.UserID, claims.SessionID)
+					if err != nil {
+						core.WriteAppError(w, r, core.InternalServerError("failed to validate token").WithError(err))
+						return
+					}
+					if !ok {
+						core.WriteAppError(w, r, core.Unauthorized("Token has been revoked"))
+						return
+					}
+				}
+
+				// Impersonation ends as soon as the admin loses access
+				if claims.Actor != nil {
+					version, active, err := tokenState.GetUserTokenState(r.Context(), claims.Actor.UserID)
+					if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+						core.WriteAppError(w, r, core.InternalServerError("failed to validate token").WithError(err))
+						return
+					}
+					if err != nil || !active || version != claims.Actor.TokenVersion {
+						core.WriteAppError(w, r, core.Unauthorized("Token has been revoked"))
+						return
+					}
+				}
+			}
+
 			// Create auth context
 			authCtx := &model.AuthContext{
 				UserID:       claims.UserID,
@@ -56,13 +131,21 @@ func JWTMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 				IsSuperAdmin: claims.IsSuperAdmin,
 				Roles:        claims.Roles,
 				Permissions:  claims.Permissions,
+				SessionID:    claims.SessionID,
+			}
+			if claims.Actor != nil {
+				authCtx.ImpersonatorID = claims.Actor.UserID
+				authCtx.ImpersonatorEmail = claims.Actor.Email
 			}
 
 			// Set auth context in request context
-			ctx := context.WithValue(r.Context(), authContextKey, authCtx)
+			ctx := model.WithAuthContext(r.Context(), authCtx)
 
 			// Also set UserID in core context for compatibility
 			ctx = core.WithUserID(ctx, claims.UserID)
+			if claims.Actor != nil {
+				ctx = auth.WithImpersonatorID(ctx, claims.Actor.UserID)
+			}
 
 			// Continue with updated context
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -91,8 +174,24 @@ func RequireAuth() func(http.Handler) http.Handler {
 	}
 }
 
+// DenyAPITokens rejects requests authenticated with an API token, for
+// endpoints that manage credentials and must be used interactively
+func DenyAPITokens() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.APITokenIDFromContext(r.Context()) != "" {
+				core.WriteAppError(w, r, core.Forbidden("This endpoint is not available to API tokens"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequirePermission middleware requires specific permission
 func RequirePermission(permission string) func(http.Handler) http.Handler {
+	mustBeDeclared(permission)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authCtx, err := GetAuthContext(r.Context())
@@ -112,8 +211,20 @@ func RequirePermission(permission string) func(http.Handler) http.Handler {
 	}
 }
 
+// mustBeDeclared fails route registration for permissions missing from the
+// rbac registry; they would not be synced to the catalogue and every
+// request would get a 403
+func mustBeDeclared(permissions ...string) {
+	for _, permission := range permissions {
+		if !rbac.IsDeclared(permission) {
+			panic(fmt.Sprintf("middleware: permission %q is not declared in internal/rbac", permission))
+		}
+	}
+}
+
 // RequireAnyPermission middleware requires any of the specified permissions
 func RequireAnyPermission(permissions ...string) func(http.Handler) http.Handler {
+	mustBeDeclared(permissions...)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authCtx, err := GetAuthContext(r.Context())
@@ -133,6 +244,30 @@ func RequireAnyPermission(permissions ...string) func(http.Handler) http.Handler
 	}
 }
 
+// RequireAllPermissions middleware requires every one of the specified
+// permissions
+func RequireAllPermissions(permissions ...string) func(http.Handler) http.Handler {
+	mustBeDeclared(permissions...)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authCtx, err := GetAuthContext(r.Context())
+			if err != nil {
+				core.WriteAppError(w, r, core.Unauthorized("Authentication required"))
+				return
+			}
+
+			for _, permission := range permissions {
+				if !authCtx.HasPermission(permission) {
+					core.WriteAppError(w, r, core.Forbidden("Insufficient permissions"))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequireRole middleware requires specific role
 func RequireRole(role string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -156,8 +291,8 @@ func RequireRole(role string) func(http.Handler) http.Handler {
 
 // GetAuthContext retrieves auth context from request context
 func GetAuthContext(ctx context.Context) (*model.AuthContext, error) {
-	authCtx, ok := ctx.Value(authContextKey).(*model.AuthContext)
-	if !ok || authCtx == nil {
+	authCtx := model.AuthContextFromContext(ctx)
+	if authCtx == nil {
 		return nil, core.Unauthorized("No authentication context")
 	}
 	return authCtx, nil
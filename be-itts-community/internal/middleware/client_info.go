@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"be-itts-community/pkg/clientinfo"
+)
+
+// ClientInfo records the caller's IP and user agent in the request context
+// for audit logs and session tracking
+func ClientInfo(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := clientinfo.WithInfo(r.Context(), clientinfo.FromRequest(r, trustProxy))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+	chimw "github.com/go-chi/chi/v5/middleware"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/clientinfo"
+)
+
+// AuditRecorder stores audit log entries
+type AuditRecorder interface {
+	CreateAuditLog(ctx context.Context, log *model.AuditLog) error
+}
+
+// DenyImpersonation rejects requests made with an impersonation token, for
+// endpoints that manage the impersonated user's credentials
+func DenyImpersonation() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.ImpersonatorIDFromContext(r.Context()) != "" {
+				core.WriteAppError(w, r, core.Forbidden("This endpoint is not available while impersonating"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuditImpersonation records every request made with an impersonation token
+// in audit_logs, under the impersonated user and tagged with the admin
+func AuditImpersonation(audit AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actorID := auth.ImpersonatorIDFromContext(r.Context())
+			if actorID == "" || audit == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			userID := core.GetUserIDFromContext(r.Context())
+			log := &model.AuditLog{
+				UserID:         &userID,
+				Action:         "impersonation.request",
+				ImpersonatorID: &actorID,
+				Metadata: map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"status": status,
+				},
+			}
+			info := clientinfo.FromContext(r.Context())
+			if info.IP != "" {
+				log.IPAddress = &info.IP
+			}
+			if info.UserAgent != "" {
+				log.UserAgent = &info.UserAgent
+			}
+
+			// Non-blocking audit log (fire and forget)
+			go func() {
+				_ = audit.CreateAuditLog(context.Background(), log)
+			}()
+		})
+	}
+}
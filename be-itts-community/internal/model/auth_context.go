@@ -0,0 +1,17 @@
+package model
+
+import "context"
+
+type authContextKey struct{}
+
+// WithAuthContext stores the authenticated caller in ctx
+func WithAuthContext(ctx context.Context, ac *AuthContext) context.Context {
+	return context.WithValue(ctx, authContextKey{}, ac)
+}
+
+// AuthContextFromContext returns the authenticated caller, or nil outside
+// an authenticated request
+func AuthContextFromContext(ctx context.Context) *AuthContext {
+	ac, _ := ctx.Value(authContextKey{}).(*AuthContext)
+	return ac
+}
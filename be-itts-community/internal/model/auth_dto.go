@@ -21,6 +21,62 @@ type LoginResponse struct {
 	User         UserResponse  `json:"user"`
 }
 
+// MFAChallengeResponse is returned by login instead of LoginResponse when a
+// second factor is needed. EnrollmentRequired means the user's role demands
+// MFA but none is set up yet; the token then only allows MFA setup.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"` // seconds
+}
+
+// MFAVerifyRequest completes a two-step login with a TOTP or recovery code
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFACodeRequest confirms a sensitive MFA operation with a TOTP or recovery code
+type MFACodeRequest struct {
+	Code         string `json:"code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFAActivateRequest confirms enrollment with the first code from the authenticator
+type MFAActivateRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFASetupRequest starts forced enrollment during login
+type MFASetupRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// MFASetupConfirmRequest finishes forced enrollment during login
+type MFASetupConfirmRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFAEnrollResponse carries the secret to load into an authenticator app
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, render as QR code
+}
+
+// MFARecoveryCodesResponse returns freshly generated recovery codes (shown once)
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFASetupConfirmResponse returns recovery codes and the completed login
+type MFASetupConfirmResponse struct {
+	RecoveryCodes []string      `json:"recovery_codes"`
+	Login         LoginResponse `json:"login"`
+}
+
 // RefreshTokenRequest represents token refresh request
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
@@ -36,6 +92,7 @@ type RefreshTokenResponse struct {
 
 // UpdateProfileRequest represents user profile update request
 type UpdateProfileRequest struct {
+	// Email may only repeat the current one; see ChangeEmailRequest
 	Email    *string `json:"email" validate:"omitempty,email"`
 	FullName *string `json:"full_name" validate:"omitempty"`
 }
@@ -46,11 +103,51 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
+// MagicLinkRequest asks for a passwordless login link by email
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// MagicLinkConsumeRequest logs in with the token from a magic link email
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ChangeEmailRequest starts an email change; the current password is
+// required so a hijacked session cannot take over the account
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" validate:"required,email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// ConfirmEmailChangeRequest applies a pending email change with the token
+// sent to the new address
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// CancelEmailChangeRequest withdraws a pending email change with the token
+// sent to the current address
+type CancelEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 // ResetPasswordRequest represents password reset request (admin only)
 type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
+// ForgotPasswordRequest starts the self-service password reset flow
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordWithTokenRequest completes the self-service password reset flow
+type ResetPasswordWithTokenRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
 // OAuthCallbackRequest represents OAuth callback request
 type OAuthCallbackRequest struct {
 	Code  string `json:"code" validate:"required"`
@@ -87,9 +184,11 @@ type UserResponse struct {
 	FullName     string          `json:"full_name"`
 	IsActive     bool            `json:"is_active"`
 	IsSuperAdmin bool            `json:"is_super_admin"`
+	MFAEnabled   bool            `json:"mfa_enabled"`
 	LastLoginAt  *time.Time      `json:"last_login_at"`
 	CreatedAt    time.Time       `json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
+	PendingEmail *string         `json:"pending_email,omitempty"` // awaiting confirmation
 	Roles        []RoleResponse  `json:"roles"` // Always include roles array
 	Permissions  []string        `json:"permissions,omitempty"` // computed permission names
 }
@@ -103,6 +202,7 @@ type CreateRoleRequest struct {
 	Name          string   `json:"name" validate:"required,min=3,max=100"`
 	Description   *string  `json:"description"`
 	ParentRoleID  *string  `json:"parent_role_id" validate:"omitempty,uuid4"`
+	RequiresMFA   bool     `json:"requires_mfa"`
 	PermissionIDs []string `json:"permission_ids" validate:"dive,uuid4"`
 }
 
@@ -111,9 +211,22 @@ type UpdateRoleRequest struct {
 	Name          *string  `json:"name" validate:"omitempty,min=3,max=100"`
 	Description   *string  `json:"description"`
 	ParentRoleID  *string  `json:"parent_role_id" validate:"omitempty,uuid4"`
+	RequiresMFA   *bool    `json:"requires_mfa"`
 	PermissionIDs []string `json:"permission_ids" validate:"omitempty,dive,uuid4"`
 }
 
+// AssignPermissionsRequest assigns permissions to a role by ID or by name.
+// Names may use wildcards ("events:*", "*:read"); they are checked against
+// the resource and action catalogue.
+type AssignPermissionsRequest struct {
+	PermissionIDs []string `json:"permission_ids" validate:"omitempty,dive,uuid4"`
+	Permissions   []string `json:"permissions"`
+	// Scope limits the grants to some instances: "own" or "program:<program>"
+	Scope string `json:"scope"`
+	// Effect is "allow" (default) or "deny"; denies cannot be scoped
+	Effect PermissionEffect `json:"effect" validate:"omitempty,oneof=allow deny"`
+}
+
 // RoleResponse represents role in API response
 type RoleResponse struct {
 	ID           string               `json:"id"`
@@ -121,6 +234,7 @@ type RoleResponse struct {
 	Description  *string              `json:"description"`
 	IsSystem     bool                 `json:"is_system"`
 	ParentRoleID *string              `json:"parent_role_id"`
+	RequiresMFA  bool                 `json:"requires_mfa"`
 	CreatedAt    time.Time            `json:"created_at"`
 	UpdatedAt    time.Time            `json:"updated_at"`
 	Permissions  []PermissionResponse `json:"permissions,omitempty"`
@@ -136,15 +250,60 @@ type AssignRoleRequest struct {
 // Permission DTOs
 // =====================================
 
+// RolePermissionsResponse lists what a role allows and denies. Denies win
+// over allows from any role the user holds.
+type RolePermissionsResponse struct {
+	Allow []PermissionResponse `json:"allow"`
+	Deny  []PermissionResponse `json:"deny"`
+}
+
+// CatalogueSyncReport lists what a sync of the permission catalogue with
+// the code declarations created, and the catalogue permissions no code
+// declares. Orphans are reported only; roles may still reference them.
+type CatalogueSyncReport struct {
+	CreatedResources   []string `json:"created_resources"`
+	CreatedActions     []string `json:"created_actions"`
+	CreatedPermissions []string `json:"created_permissions"`
+	Orphaned           []string `json:"orphaned_permissions"`
+}
+
+// PermissionExplanation tells why a user is or is not granted a permission,
+// following the rules of RequirePermission on the user's current roles
+type PermissionExplanation struct {
+	UserID     string           `json:"user_id"`
+	Permission string           `json:"permission"`
+	Allowed    bool             `json:"allowed"`
+	Reason     string           `json:"reason"` // inactive, super_admin, granted, denied, no_grant
+	SuperAdmin bool             `json:"super_admin"`
+	Paths      []PermissionPath `json:"paths"`
+}
+
+// PermissionPath is a grant or deny reaching the user through a role
+// assignment. Inactive paths come from expired assignments and take no part
+// in the decision.
+type PermissionPath struct {
+	Via       []string         `json:"via"`  // assigned role first, then the parents up to Role
+	Role      string           `json:"role"` // role holding the grant
+	RoleID    string           `json:"role_id"`
+	Grant     string           `json:"grant"` // as in a permission list, e.g. "events:*@own" or "!events:delete"
+	Effect    PermissionEffect `json:"effect"`
+	Scope     string           `json:"scope,omitempty"`
+	Inherited bool             `json:"inherited"`
+	Active    bool             `json:"active"`
+	ExpiresAt *time.Time       `json:"expires_at,omitempty"`
+}
+
 // PermissionResponse represents permission in API response
 type PermissionResponse struct {
-	ID          string           `json:"id"`
-	Name        string           `json:"name"` // e.g., "events:create"
-	Description *string          `json:"description"`
-	Resource    ResourceResponse `json:"resource"`
-	Action      ActionResponse   `json:"action"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
+	ID            string           `json:"id"`
+	Name          string           `json:"name"` // e.g., "events:create"
+	Description   *string          `json:"description"`
+	Resource      ResourceResponse `json:"resource"`
+	Action        ActionResponse   `json:"action"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	Scope         string           `json:"scope,omitempty"`          // set for grants limited to some instances
+	InheritedFrom *string          `json:"inherited_from,omitempty"` // ancestor role name, effective view only
 }
 
 // ResourceResponse represents resource in API response
@@ -180,9 +339,110 @@ type AuditLogResponse struct {
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	IPAddress    *string                `json:"ip_address"`
 	UserAgent    *string                `json:"user_agent"`
+	APITokenID   *string                `json:"api_token_id,omitempty"`
+	ImpersonatorID *string              `json:"impersonator_id,omitempty"`
 	CreatedAt    time.Time              `json:"created_at"`
 }
 
+// =====================================
+// Session DTOs
+// =====================================
+
+// SessionResponse represents a login session (refresh token family).
+// LastUsedAt is when the session last refreshed its access token, so it
+// trails the last request by up to one access token lifetime.
+type SessionResponse struct {
+	ID         string     `json:"id"`
+	IPAddress  *string    `json:"ip_address"`
+	UserAgent  *string    `json:"user_agent"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+// =====================================
+// Impersonation DTOs
+// =====================================
+
+// ImpersonateRequest starts impersonating a user
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationResponse carries a short-lived access token for the
+// impersonated user; there is no refresh token
+type ImpersonationResponse struct {
+	AccessToken    string       `json:"access_token"`
+	TokenType      string       `json:"token_type"` // "Bearer"
+	ExpiresIn      int64        `json:"expires_in"` // seconds
+	User           UserResponse `json:"user"`
+	ImpersonatorID string       `json:"impersonator_id"`
+}
+
+// =====================================
+// Identity DTOs
+// =====================================
+
+// IdentityResponse represents a linked OAuth provider account
+type IdentityResponse struct {
+	ID         string    `json:"id"`
+	Provider   string    `json:"provider"`
+	ProviderID string    `json:"provider_id"`
+	Login      string    `json:"login,omitempty"`
+	AvatarURL  string    `json:"avatar_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IdentitiesResponse lists the ways a user can sign in
+type IdentitiesResponse struct {
+	HasPassword bool               `json:"has_password"`
+	Identities  []IdentityResponse `json:"identities"`
+}
+
+// ConfirmIdentityLinkRequest confirms linking a provider account that was
+// matched to the current user by email
+type ConfirmIdentityLinkRequest struct {
+	LinkToken string `json:"link_token" validate:"required"`
+}
+
+// IdentityLinkStartResponse carries the ticket used to start a link flow
+type IdentityLinkStartResponse struct {
+	LinkTicket string `json:"link_ticket"`
+	URL        string `json:"url"` // API path to open in the browser
+	ExpiresIn  int64  `json:"expires_in"`
+}
+
+// =====================================
+// API Token DTOs
+// =====================================
+
+// CreateAPITokenRequest represents API token creation request
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"` // default 90
+}
+
+// APITokenResponse represents an API token in API response (never the secret)
+type APITokenResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreateAPITokenResponse includes the plain token, shown only once
+type CreateAPITokenResponse struct {
+	APITokenResponse
+	Token string `json:"token"`
+}
+
 // =====================================
 // Pagination
 // =====================================
@@ -207,15 +467,30 @@ type AuthContext struct {
 	IsSuperAdmin bool     `json:"is_super_admin"`
 	Roles        []string `json:"roles"`        // role names
 	Permissions  []string `json:"permissions"`  // permission names like "events:create"
+	SessionID    string   `json:"session_id,omitempty"`
+	APITokenID   string   `json:"api_token_id,omitempty"` // set when authenticated with an API token
+	// Set when an admin is impersonating UserID
+	ImpersonatorID    string `json:"impersonator_id,omitempty"`
+	ImpersonatorEmail string `json:"impersonator_email,omitempty"`
 }
 
-// HasPermission checks if user has specific permission
+// HasPermission checks if user has specific permission, directly or
+// through a wildcard such as "events:*" or "*:read", and no deny covers it.
+// Scoped grants count: the caller may act on some instances, which services
+// check with Can.
 func (ac *AuthContext) HasPermission(permission string) bool {
 	if ac.IsSuperAdmin {
 		return true
 	}
+	if PermissionDenied(ac.Permissions, permission) {
+		return false
+	}
 	for _, p := range ac.Permissions {
-		if p == permission {
+		if isDeny(p) {
+			continue
+		}
+		name, _ := SplitPermissionScope(p)
+		if PermissionCovers(name, permission) {
 			return true
 		}
 	}
@@ -224,14 +499,30 @@ func (ac *AuthContext) HasPermission(permission string) bool {
 
 // HasAnyPermission checks if user has any of the specified permissions
 func (ac *AuthContext) HasAnyPermission(permissions ...string) bool {
+	for _, required := range permissions {
+		if ac.HasPermission(required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can checks if user has permission on a specific resource instance: a grant
+// without scope covers every instance, a scoped grant only matching ones
+func (ac *AuthContext) Can(permission string, attrs ResourceAttrs) bool {
 	if ac.IsSuperAdmin {
 		return true
 	}
-	for _, required := range permissions {
-		for _, p := range ac.Permissions {
-			if p == required {
-				return true
-			}
+	if PermissionDenied(ac.Permissions, permission) {
+		return false
+	}
+	for _, p := range ac.Permissions {
+		if isDeny(p) {
+			continue
+		}
+		name, scope := SplitPermissionScope(p)
+		if PermissionCovers(name, permission) && attrs.InScope(scope, ac.UserID) {
+			return true
 		}
 	}
 	return false
@@ -259,6 +550,7 @@ func (u *User) ToUserResponse() UserResponse {
 		FullName:     u.FullName,
 		IsActive:     u.IsActive,
 		IsSuperAdmin: u.IsSuperAdmin,
+		MFAEnabled:   u.MFAEnabled,
 		LastLoginAt:  u.LastLoginAt,
 		CreatedAt:    u.CreatedAt,
 		UpdatedAt:    u.UpdatedAt,
@@ -284,6 +576,7 @@ func (r *Role) ToRoleResponse() RoleResponse {
 		Description:  r.Description,
 		IsSystem:     r.IsSystem,
 		ParentRoleID: r.ParentRoleID,
+		RequiresMFA:  r.RequiresMFA,
 		CreatedAt:    r.CreatedAt,
 		UpdatedAt:    r.UpdatedAt,
 	}
@@ -335,6 +628,8 @@ func (a *AuditLog) ToAuditLogResponse() AuditLogResponse {
 		Metadata:     a.Metadata,
 		IPAddress:    a.IPAddress,
 		UserAgent:    a.UserAgent,
+		APITokenID:   a.APITokenID,
+		ImpersonatorID: a.ImpersonatorID,
 		CreatedAt:    a.CreatedAt,
 	}
 
@@ -345,3 +640,48 @@ func (a *AuditLog) ToAuditLogResponse() AuditLogResponse {
 
 	return resp
 }
+
+// ToSessionResponse converts Session model to SessionResponse DTO
+func (s *Session) ToSessionResponse(currentSessionID string) SessionResponse {
+	return SessionResponse{
+		ID:         s.FamilyID,
+		IPAddress:  s.IPAddress,
+		UserAgent:  s.UserAgent,
+		StartedAt:  s.StartedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    currentSessionID != "" && s.FamilyID == currentSessionID,
+	}
+}
+
+// ToAPITokenResponse converts APIToken model to APITokenResponse DTO
+func (t *APIToken) ToAPITokenResponse() APITokenResponse {
+	return APITokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.Scopes,
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		LastUsedIP:  t.LastUsedIP,
+		RevokedAt:   t.RevokedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// ToIdentityResponse converts OAuthAccount model to IdentityResponse DTO
+func (a *OAuthAccount) ToIdentityResponse() IdentityResponse {
+	resp := IdentityResponse{
+		ID:         a.ID,
+		Provider:   a.Provider,
+		ProviderID: a.ProviderID,
+		CreatedAt:  a.CreatedAt,
+	}
+	if login, ok := a.ProviderData["login"].(string); ok {
+		resp.Login = login
+	}
+	if avatar, ok := a.ProviderData["avatar_url"].(string); ok {
+		resp.AvatarURL = avatar
+	}
+	return resp
+}
@@ -20,6 +20,16 @@ type User struct {
 	CreatedAt    time.Time  `gorm:"not null;default:now()"`
 	UpdatedAt    time.Time  `gorm:"not null;default:now()"`
 
+	// TOTP MFA
+	MFAEnabled   bool       `gorm:"column:mfa_enabled;default:false"`
+	MFASecret    *string    `gorm:"column:mfa_secret"` // encrypted, set during enrollment
+	MFAEnabledAt *time.Time `gorm:"column:mfa_enabled_at"`
+	MFALastStep  *int64     `gorm:"column:mfa_last_step"` // last accepted TOTP step (replay guard)
+
+	// TokenVersion invalidates issued access tokens when bumped. Read-only
+	// for GORM so saving a stale struct can never roll it back.
+	TokenVersion int `gorm:"column:token_version;not null;default:0;<-:false"`
+
 	// Relations
 	Roles         []Role         `gorm:"many2many:user_roles"`
 	RefreshTokens []RefreshToken `gorm:"foreignKey:UserID"`
@@ -37,6 +47,7 @@ type Role struct {
 	Description  *string   `gorm:"type:text"`
 	IsSystem     bool      `gorm:"column:is_system;default:false"` // system roles can't be deleted
 	ParentRoleID *string   `gorm:"type:uuid;column:parent_role_id"`
+	RequiresMFA  bool      `gorm:"column:requires_mfa;default:false"` // members must enable MFA
 	CreatedAt    time.Time `gorm:"not null;default:now()"`
 	UpdatedAt    time.Time `gorm:"not null;default:now()"`
 
@@ -102,12 +113,27 @@ func (Permission) TableName() string {
 	return "permissions"
 }
 
-// RolePermission junction table
+// PermissionEffect tells whether a role permission grants or denies
+type PermissionEffect string
+
+const (
+	PermissionAllow PermissionEffect = "allow"
+	// PermissionDeny takes precedence over any allow the user holds,
+	// through the same role or another one
+	PermissionDeny PermissionEffect = "deny"
+)
+
+// RolePermission junction table. Scope limits the grant to some instances
+// of the resource, see ResourceAttrs.InScope. Denies are never scoped.
 type RolePermission struct {
-	ID           string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	RoleID       string    `gorm:"type:uuid;not null;uniqueIndex:idx_role_permission,priority:1"`
-	PermissionID string    `gorm:"type:uuid;not null;uniqueIndex:idx_role_permission,priority:2"`
-	CreatedAt    time.Time `gorm:"not null;default:now()"`
+	ID           string           `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	RoleID       string           `gorm:"type:uuid;not null;uniqueIndex:idx_role_permission,priority:1"`
+	PermissionID string           `gorm:"type:uuid;not null;uniqueIndex:idx_role_permission,priority:2"`
+	Scope        string           `gorm:"size:150;not null;default:'';uniqueIndex:idx_role_permission,priority:3"`
+	Effect       PermissionEffect `gorm:"type:varchar(10);not null;default:'allow'"`
+	CreatedAt    time.Time        `gorm:"not null;default:now()"`
+
+	Permission Permission `gorm:"foreignKey:PermissionID"`
 }
 
 func (RolePermission) TableName() string {
@@ -142,6 +168,16 @@ type RefreshToken struct {
 	RevokedAt *time.Time
 	CreatedAt time.Time `gorm:"not null;default:now()"`
 
+	// FamilyID groups a login and all tokens rotated from it; left empty
+	// on insert to start a new family
+	FamilyID     string  `gorm:"type:uuid;not null;default:gen_random_uuid();index"`
+	ReplacedByID *string `gorm:"type:uuid"`
+
+	// Device info of the client that obtained this token
+	IPAddress  *string    `gorm:"type:inet"`
+	UserAgent  *string    `gorm:"type:text"`
+	LastUsedAt *time.Time // last refresh of the session, not its last request
+
 	// Relations
 	User User `gorm:"foreignKey:UserID"`
 }
@@ -150,17 +186,89 @@ func (RefreshToken) TableName() string {
 	return "refresh_tokens"
 }
 
+// Session is a login session: the active refresh token of a family plus
+// when the family started
+type Session struct {
+	RefreshToken
+	StartedAt time.Time
+}
+
+// PasswordReset is a single-use, expiring token for self-service password reset
+type PasswordReset struct {
+	ID        string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string     `gorm:"type:uuid;not null;index"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:""`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+func (PasswordReset) TableName() string {
+	return "password_resets"
+}
+
+// MagicLink is a single-use, short-lived token for passwordless login
+type MagicLink struct {
+	ID        string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string     `gorm:"type:uuid;not null;index"`
+	TokenHash string     `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:""`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+func (MagicLink) TableName() string {
+	return "magic_links"
+}
+
+// EmailChange is a pending change of a user's email. The change is applied
+// only when the token sent to the new address is confirmed; used_at is set
+// when it is confirmed or superseded.
+type EmailChange struct {
+	ID        string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string    `gorm:"type:uuid;not null;index"`
+	OldEmail  string    `gorm:"type:citext;not null"`
+	NewEmail  string    `gorm:"type:citext;not null"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	// CancelTokenHash backs the cancel link sent to the old address
+	CancelTokenHash *string    `gorm:"type:char(64);uniqueIndex"`
+	UsedAt          *time.Time `gorm:""`
+	ConfirmedAt     *time.Time `gorm:""`
+	CancelledAt     *time.Time `gorm:""`
+	CreatedAt       time.Time  `gorm:"not null;default:now()"`
+}
+
+func (EmailChange) TableName() string {
+	return "email_changes"
+}
+
+// MFARecoveryCode is a hashed one-time code for logging in without the authenticator
+type MFARecoveryCode struct {
+	ID        string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string     `gorm:"type:uuid;not null;index"`
+	CodeHash  string     `gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `gorm:""`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
 // AuditLog tracks permission changes and sensitive operations
 type AuditLog struct {
-	ID           string                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID       *string                `gorm:"type:uuid;index"`
-	Action       string                 `gorm:"size:100;not null;index"`
-	ResourceType *string                `gorm:"size:100;index:idx_audit_resource,priority:1"`
-	ResourceID   *string                `gorm:"type:uuid;index:idx_audit_resource,priority:2"`
-	Metadata     map[string]interface{} `gorm:"type:jsonb"`
-	IPAddress    *string                `gorm:"type:inet"`
-	UserAgent    *string                `gorm:"type:text"`
-	CreatedAt    time.Time              `gorm:"not null;default:now();index:,sort:desc"`
+	ID             string                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID         *string                `gorm:"type:uuid;index"`
+	Action         string                 `gorm:"size:100;not null;index"`
+	ResourceType   *string                `gorm:"size:100;index:idx_audit_resource,priority:1"`
+	ResourceID     *string                `gorm:"type:uuid;index:idx_audit_resource,priority:2"`
+	Metadata       map[string]interface{} `gorm:"type:jsonb"`
+	IPAddress      *string                `gorm:"type:inet"`
+	UserAgent      *string                `gorm:"type:text"`
+	APITokenID     *string                `gorm:"type:uuid"` // set when the action was performed with an API token
+	ImpersonatorID *string                `gorm:"type:uuid"` // set when an admin performed the action as UserID
+	CreatedAt      time.Time              `gorm:"not null;default:now();index:,sort:desc"`
 
 	// Relations
 	User *User `gorm:"foreignKey:UserID"`
@@ -187,3 +295,26 @@ type OAuthAccount struct {
 func (OAuthAccount) TableName() string {
 	return "oauth_accounts"
 }
+
+// APIToken is a personal access token used by scripts instead of a password.
+// Only the SHA-256 hash of the token is stored.
+type APIToken struct {
+	ID          string    `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID      string    `gorm:"type:uuid;not null;index"`
+	Name        string    `gorm:"size:100;not null"`
+	TokenPrefix string    `gorm:"size:16;not null"` // shown in listings to identify the token
+	TokenHash   string    `gorm:"size:64;not null;uniqueIndex"`
+	Scopes      []string  `gorm:"type:jsonb;serializer:json;not null"` // permission names the token may use
+	ExpiresAt   time.Time `gorm:"not null"`
+	LastUsedAt  *time.Time
+	LastUsedIP  *string `gorm:"type:inet"`
+	RevokedAt   *time.Time
+	CreatedAt   time.Time `gorm:"not null;default:now()"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Email outbox DTOs
+
+// EmailOutboxResponse deliberately omits the message body: bodies carry
+// single-use links (verification, password reset) that admins must not see.
+type EmailOutboxResponse struct {
+	ID            string            `json:"id"`
+	Recipient     string            `json:"recipient"`
+	Subject       string            `json:"subject"`
+	Status        EmailOutboxStatus `json:"status"`
+	Attempts      int               `json:"attempts"`
+	MaxAttempts   int               `json:"max_attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	LastError     *string           `json:"last_error,omitempty"`
+	SentAt        *time.Time        `json:"sent_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+type EmailOutboxListResponse struct {
+	Data       []EmailOutboxResponse `json:"data"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+func EmailOutboxToResponse(m EmailOutbox) EmailOutboxResponse {
+	return EmailOutboxResponse{
+		ID:            m.ID,
+		Recipient:     m.Recipient,
+		Subject:       m.Subject,
+		Status:        m.Status,
+		Attempts:      m.Attempts,
+		MaxAttempts:   m.MaxAttempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+		SentAt:        m.SentAt,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+}
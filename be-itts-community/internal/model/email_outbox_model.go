@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// =====================================
+// Email Outbox
+// =====================================
+
+type EmailOutboxStatus string
+
+const (
+	EmailOutboxPending EmailOutboxStatus = "pending"
+	EmailOutboxSending EmailOutboxStatus = "sending"
+	EmailOutboxSent    EmailOutboxStatus = "sent"
+	EmailOutboxDead    EmailOutboxStatus = "dead"
+)
+
+// EmailOutbox is a queued outbound email. Rows are written in the same
+// transaction as the business change and delivered by the outbox worker.
+type EmailOutbox struct {
+	ID            string            `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Recipient     string            `gorm:"type:citext;not null;index"`
+	Subject       string            `gorm:"size:255;not null"`
+	Body          string            `gorm:"type:text;not null"`
+	Status        EmailOutboxStatus `gorm:"size:20;not null;default:'pending';index:idx_email_outbox_due,priority:1"`
+	Attempts      int               `gorm:"not null;default:0"`
+	MaxAttempts   int               `gorm:"not null;default:8"`
+	NextAttemptAt time.Time         `gorm:"not null;default:now();index:idx_email_outbox_due,priority:2"`
+	LastError     *string           `gorm:"type:text"`
+	SentAt        *time.Time
+	CreatedAt     time.Time `gorm:"not null;default:now()"`
+	UpdatedAt     time.Time `gorm:"not null;default:now()"`
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
@@ -84,6 +84,7 @@ type EventResponse struct {
 	EndsAt      *time.Time        `json:"ends_at,omitempty"`
 	Venue       string            `json:"venue,omitempty"`
 	Speakers    []SpeakerResponse `json:"speakers,omitempty"`
+	CreatedBy   *string           `json:"created_by,omitempty"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
@@ -192,6 +193,7 @@ func EventToResponse(m Event) EventResponse {
 		Status:    m.Status,
 		StartsAt:  m.StartsAt,
 		EndsAt:    m.EndsAt,
+		CreatedBy: m.CreatedBy,
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
 	}
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// Invitation DTOs
+
+// CreateInvitationRequest represents an admin invitation request
+type CreateInvitationRequest struct {
+	Email         string   `json:"email" validate:"required,email"`
+	FullName      string   `json:"full_name" validate:"omitempty,max=255"`
+	RoleIDs       []string `json:"role_ids" validate:"dive,uuid4"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=30"` // default 7
+}
+
+// AcceptInvitationRequest accepts an invitation by setting a password
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	FullName string `json:"full_name" validate:"omitempty,max=255"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// InvitationResponse represents an invitation in admin API responses
+type InvitationResponse struct {
+	ID             string           `json:"id"`
+	Email          string           `json:"email"`
+	FullName       *string          `json:"full_name"`
+	RoleIDs        []string         `json:"role_ids"`
+	Status         InvitationStatus `json:"status"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	InvitedBy      *string          `json:"invited_by"`
+	AcceptedAt     *time.Time       `json:"accepted_at,omitempty"`
+	AcceptedUserID *string          `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time       `json:"revoked_at,omitempty"`
+	SendCount      int              `json:"send_count"`
+	LastSentAt     time.Time        `json:"last_sent_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type InvitationListResponse struct {
+	Data       []InvitationResponse `json:"data"`
+	Total      int64                `json:"total"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	TotalPages int                  `json:"total_pages"`
+}
+
+// InvitationPreviewResponse is shown to the invitee on the accept page
+type InvitationPreviewResponse struct {
+	Email     string    `json:"email"`
+	FullName  *string   `json:"full_name"`
+	InvitedBy string    `json:"invited_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func InvitationToResponse(m UserInvitation) InvitationResponse {
+	return InvitationResponse{
+		ID:             m.ID,
+		Email:          m.Email,
+		FullName:       m.FullName,
+		RoleIDs:        m.RoleIDs,
+		Status:         m.EffectiveStatus(time.Now()),
+		ExpiresAt:      m.ExpiresAt,
+		InvitedBy:      m.InvitedBy,
+		AcceptedAt:     m.AcceptedAt,
+		AcceptedUserID: m.AcceptedUserID,
+		RevokedAt:      m.RevokedAt,
+		SendCount:      m.SendCount,
+		LastSentAt:     m.LastSentAt,
+		CreatedAt:      m.CreatedAt,
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// =====================================
+// User Invitations
+// =====================================
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	// InvitationExpired is never stored; pending invitations past expires_at
+	// are reported as expired
+	InvitationExpired InvitationStatus = "expired"
+)
+
+// UserInvitation invites an email address to create an account with
+// pre-selected roles. Only the SHA-256 hash of the invite token is stored.
+type UserInvitation struct {
+	ID             string           `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Email          string           `gorm:"type:citext;not null"`
+	FullName       *string          `gorm:"size:255"`
+	RoleIDs        []string         `gorm:"type:jsonb;serializer:json;not null"`
+	TokenHash      string           `gorm:"size:64;not null;uniqueIndex"`
+	Status         InvitationStatus `gorm:"size:20;not null;default:'pending'"`
+	ExpiresAt      time.Time        `gorm:"not null"`
+	InvitedBy      *string          `gorm:"type:uuid"`
+	AcceptedAt     *time.Time
+	AcceptedUserID *string `gorm:"type:uuid"`
+	RevokedAt      *time.Time
+	SendCount      int       `gorm:"not null;default:1"`
+	LastSentAt     time.Time `gorm:"not null;default:now()"`
+	CreatedAt      time.Time `gorm:"not null;default:now()"`
+	UpdatedAt      time.Time `gorm:"not null;default:now()"`
+
+	// Relations
+	Inviter *User `gorm:"foreignKey:InvitedBy"`
+}
+
+func (UserInvitation) TableName() string {
+	return "user_invitations"
+}
+
+// EffectiveStatus reports pending invitations past their expiry as expired
+func (i *UserInvitation) EffectiveStatus(now time.Time) InvitationStatus {
+	if i.Status == InvitationPending && now.After(i.ExpiresAt) {
+		return InvitationExpired
+	}
+	return i.Status
+}
@@ -43,6 +43,7 @@ type MentorResponse struct {
 	Programs  []ProgramEnum `json:"programs,omitempty"`
 	IsActive  bool          `json:"is_active"`
 	Priority  int           `json:"priority"`
+	CreatedBy *string       `json:"created_by,omitempty"`
 	CreatedAt time.Time     `json:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at"`
 }
@@ -87,6 +88,7 @@ func MentorToResponse(m Mentor) MentorResponse {
 		Programs:  m.Programs,
 		IsActive:  m.IsActive,
 		Priority:  m.Priority,
+		CreatedBy: m.CreatedBy,
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
 	}
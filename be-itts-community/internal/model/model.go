@@ -81,8 +81,9 @@ type Roadmap struct {
 	MonthNumber int          `gorm:"not null;check:month_number between 1 and 12"`
 	Title       string       `gorm:"not null"`
 	Description *string
-	SortOrder   int  `gorm:"default:0"`
-	IsActive    bool `gorm:"default:true"`
+	SortOrder   int     `gorm:"default:0"`
+	IsActive    bool    `gorm:"default:true"`
+	CreatedBy   *string `gorm:"type:uuid;index"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
@@ -112,6 +113,7 @@ type Event struct {
 	StartsAt    time.Time    `gorm:"not null;index"`
 	EndsAt      *time.Time
 	Venue       *string
+	CreatedBy   *string `gorm:"type:uuid;index"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
@@ -148,6 +150,7 @@ type Mentor struct {
 	Programs  []ProgramEnum `gorm:"type:program_enum[]"`
 	IsActive  bool          `gorm:"default:true;index"`
 	Priority  int           `gorm:"default:0;index"`
+	CreatedBy *string       `gorm:"type:uuid;index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -164,8 +167,9 @@ type Partner struct {
 	Description *string
 	LogoURL     *string
 	WebsiteURL  *string
-	IsActive    bool `gorm:"default:true;index"`
-	Priority    int  `gorm:"default:0;index"`
+	IsActive    bool    `gorm:"default:true;index"`
+	Priority    int     `gorm:"default:0;index"`
+	CreatedBy   *string `gorm:"type:uuid;index"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
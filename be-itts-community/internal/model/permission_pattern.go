@@ -0,0 +1,198 @@
+package model
+
+import "strings"
+
+// PermissionWildcard stands for any resource or any action in a permission
+// name, as in "events:*" or "*:read"
+const PermissionWildcard = "*"
+
+// Scoped grants are written "name@scope", e.g. "events:update@own" or
+// "events:update@program:devsecops". A grant without a scope applies to
+// every instance of the resource.
+const (
+	PermissionScopeSeparator = "@"
+	PermissionScopeOwn       = "own"
+	PermissionScopeProgram   = "program:"
+)
+
+// PermissionDenyPrefix marks a deny entry in a permission list, as in
+// "!events:delete". A deny covers the same names an allow would and takes
+// precedence over every allow.
+const PermissionDenyPrefix = "!"
+
+// DeniedPermission returns the deny entry for name
+func DeniedPermission(name string) string {
+	return PermissionDenyPrefix + name
+}
+
+func isDeny(entry string) bool {
+	return strings.HasPrefix(entry, PermissionDenyPrefix)
+}
+
+// ResourceAttrs describes a resource instance for checking scoped grants
+type ResourceAttrs struct {
+	CreatedBy *string
+	Programs  []ProgramEnum
+}
+
+// SplitPermission splits a "resource:action" permission name
+func SplitPermission(name string) (resource, action string, ok bool) {
+	resource, action, ok = strings.Cut(name, ":")
+	if !ok || resource == "" || action == "" || strings.Contains(action, ":") {
+		return "", "", false
+	}
+	return resource, action, true
+}
+
+// SplitPermissionScope splits a grant into its permission name and scope
+func SplitPermissionScope(grant string) (name, scope string) {
+	name, scope, _ = strings.Cut(grant, PermissionScopeSeparator)
+	return name, scope
+}
+
+// ScopedPermission joins a permission name and scope into a grant
+func ScopedPermission(name, scope string) string {
+	if scope == "" {
+		return name
+	}
+	return name + PermissionScopeSeparator + scope
+}
+
+// ValidPermissionScope reports whether scope is a known scope
+func ValidPermissionScope(scope string) bool {
+	switch {
+	case scope == "", scope == PermissionScopeOwn:
+		return true
+	case strings.HasPrefix(scope, PermissionScopeProgram):
+		switch ProgramEnum(strings.TrimPrefix(scope, PermissionScopeProgram)) {
+		case ProgramNetworking, ProgramDevSecOps, ProgramProgramming:
+			return true
+		}
+	}
+	return false
+}
+
+// InScope reports whether the instance falls under scope for userID
+func (a ResourceAttrs) InScope(scope, userID string) bool {
+	switch {
+	case scope == "":
+		return true
+	case scope == PermissionScopeOwn:
+		return a.CreatedBy != nil && *a.CreatedBy == userID
+	case strings.HasPrefix(scope, PermissionScopeProgram):
+		program := ProgramEnum(strings.TrimPrefix(scope, PermissionScopeProgram))
+		for _, p := range a.Programs {
+			if p == program {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PermissionCovers reports whether the granted permission covers the
+// required one. Only the granted side expands wildcards; "events:*" covers
+// "events:create" and "*:read" covers "events:read", but "events:create"
+// does not cover "events:*". A scoped grant only covers requirements with the
+// same scope.
+func PermissionCovers(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+	grantedName, grantedScope := SplitPermissionScope(granted)
+	requiredName, requiredScope := SplitPermissionScope(required)
+	if grantedScope != "" && grantedScope != requiredScope {
+		return false
+	}
+	if grantedName == requiredName {
+		return true
+	}
+	gr, ga, ok := SplitPermission(grantedName)
+	if !ok {
+		return false
+	}
+	rr, ra, ok := SplitPermission(requiredName)
+	if !ok {
+		return false
+	}
+	return (gr == PermissionWildcard || gr == rr) && (ga == PermissionWildcard || ga == ra)
+}
+
+// PermissionsCover reports whether any of the granted permissions covers
+// required and none of the deny entries among them does
+func PermissionsCover(granted []string, required string) bool {
+	if PermissionDenied(granted, required) {
+		return false
+	}
+	for _, p := range granted {
+		if !isDeny(p) && PermissionCovers(p, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionDenied reports whether a deny entry in granted covers required,
+// whatever scope required carries
+func PermissionDenied(granted []string, required string) bool {
+	requiredName, _ := SplitPermissionScope(required)
+	for _, p := range granted {
+		if denied, ok := strings.CutPrefix(p, PermissionDenyPrefix); ok && PermissionCovers(denied, requiredName) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionsBeyond returns the grants in permissions that held does not
+// cover, either because held lacks them or because a deny in held removes
+// part of a grant that permissions does not deny as well
+func PermissionsBeyond(permissions, held []string) []string {
+	var out []string
+	for _, p := range permissions {
+		if isDeny(p) {
+			continue
+		}
+		beyond := !PermissionsCover(held, p)
+		if !beyond {
+			name, _ := SplitPermissionScope(p)
+			for _, d := range PermissionDenies(held) {
+				denied := strings.TrimPrefix(d, PermissionDenyPrefix)
+				if PermissionCovers(name, denied) && !PermissionDenied(permissions, denied) {
+					beyond = true
+					break
+				}
+			}
+		}
+		if beyond {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// PermissionDenies returns the deny entries of a permission list
+func PermissionDenies(permissions []string) []string {
+	var out []string
+	for _, p := range permissions {
+		if isDeny(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// PermissionCandidates returns the names a grant can have to cover name:
+// the name itself and its wildcard forms
+func PermissionCandidates(name string) []string {
+	resource, action, ok := SplitPermission(name)
+	if !ok {
+		return []string{name}
+	}
+	return []string{
+		name,
+		resource + ":" + PermissionWildcard,
+		PermissionWildcard + ":" + action,
+		PermissionWildcard + ":" + PermissionWildcard,
+	}
+}
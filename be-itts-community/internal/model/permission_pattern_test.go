@@ -0,0 +1,198 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPermission(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantResource string
+		wantAction   string
+		wantOK       bool
+	}{
+		{"events:create", "events", "create", true},
+		{"events:*", "events", "*", true},
+		{"*:*", "*", "*", true},
+		{"events", "", "", false},
+		{":create", "", "", false},
+		{"events:", "", "", false},
+		{"events:create:extra", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, action, ok := SplitPermission(tt.name)
+			if resource != tt.wantResource || action != tt.wantAction || ok != tt.wantOK {
+				t.Errorf("SplitPermission(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.name, resource, action, ok, tt.wantResource, tt.wantAction, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPermissionCovers(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{"events:create", "events:create", true},
+		{"events:create", "events:update", false},
+		{"events:*", "events:create", true},
+		{"events:*", "mentors:create", false},
+		{"*:read", "events:read", true},
+		{"*:read", "events:update", false},
+		{"*:*", "events:delete", true},
+		// Wildcards on the required side match literally
+		{"events:create", "events:*", false},
+		{"events:*", "events:*", true},
+		{"*:*", "events:*", true},
+		// Scoped grants only cover the same scope
+		{"events:update@own", "events:update@own", true},
+		{"events:update@own", "events:update", false},
+		{"events:update@own", "events:update@program:devsecops", false},
+		{"events:*@own", "events:update@own", true},
+		{"events:update", "events:update@own", true},
+		// Malformed names never match through wildcards
+		{"events", "events:create", false},
+		{"*", "events:create", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granted+"_"+tt.required, func(t *testing.T) {
+			if got := PermissionCovers(tt.granted, tt.required); got != tt.want {
+				t.Errorf("PermissionCovers(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsCover(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{"events:create"}, "events:create", true},
+		{"resource wildcard", []string{"events:*"}, "events:delete", true},
+		{"action wildcard", []string{"*:read"}, "mentors:read", true},
+		{"none", []string{"mentors:read"}, "events:read", false},
+		{"empty", nil, "events:read", false},
+		{"deny wins over allow", []string{"events:*", "!events:delete"}, "events:delete", false},
+		{"deny leaves other actions", []string{"events:*", "!events:delete"}, "events:update", true},
+		{"wildcard deny", []string{"*:*", "!events:*"}, "events:read", false},
+		{"deny ignores scope", []string{"events:update@own", "!events:update"}, "events:update@own", false},
+		{"deny entry alone grants nothing", []string{"!events:delete"}, "events:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PermissionsCover(tt.granted, tt.required); got != tt.want {
+				t.Errorf("PermissionsCover(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionCandidates(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"events:create", []string{"events:create", "events:*", "*:create", "*:*"}},
+		{"malformed", []string{"malformed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PermissionCandidates(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PermissionCandidates(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthContext_HasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		ctx        AuthContext
+		permission string
+		want       bool
+	}{
+		{"super admin", AuthContext{IsSuperAdmin: true}, "events:delete", true},
+		{"wildcard grant", AuthContext{Permissions: []string{"events:*"}}, "events:delete", true},
+		{"scoped grant counts for routes", AuthContext{Permissions: []string{"events:update@own"}}, "events:update", true},
+		{"denied", AuthContext{Permissions: []string{"events:*", "!events:delete"}}, "events:delete", false},
+		{"missing", AuthContext{Permissions: []string{"events:read"}}, "events:delete", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ctx.HasPermission(tt.permission); got != tt.want {
+				t.Errorf("HasPermission(%q) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthContext_Can(t *testing.T) {
+	owner := "user-1"
+	other := "user-2"
+
+	tests := []struct {
+		name        string
+		permissions []string
+		attrs       ResourceAttrs
+		want        bool
+	}{
+		{"unscoped grant", []string{"events:update"}, ResourceAttrs{CreatedBy: &other}, true},
+		{"own scope on own resource", []string{"events:update@own"}, ResourceAttrs{CreatedBy: &owner}, true},
+		{"own scope on other resource", []string{"events:update@own"}, ResourceAttrs{CreatedBy: &other}, false},
+		{"own scope without creator", []string{"events:update@own"}, ResourceAttrs{}, false},
+		{"program scope in program", []string{"events:update@program:devsecops"}, ResourceAttrs{Programs: []ProgramEnum{ProgramDevSecOps}}, true},
+		{"program scope outside program", []string{"events:update@program:devsecops"}, ResourceAttrs{Programs: []ProgramEnum{ProgramNetworking}}, false},
+		{"wildcard with scope", []string{"events:*@own"}, ResourceAttrs{CreatedBy: &owner}, true},
+		{"deny beats scoped grant", []string{"events:update@own", "!events:update"}, ResourceAttrs{CreatedBy: &owner}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := AuthContext{UserID: owner, Permissions: tt.permissions}
+			if got := ctx.Can("events:update", tt.attrs); got != tt.want {
+				t.Errorf("Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionsBeyond(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		held        []string
+		want        []string
+	}{
+		{"subset", []string{"events:read"}, []string{"events:*"}, nil},
+		{"equal", []string{"events:*"}, []string{"events:*"}, nil},
+		{"missing grant", []string{"events:read", "users:update"}, []string{"events:*"}, []string{"users:update"}},
+		{"broader wildcard", []string{"events:*"}, []string{"events:read"}, []string{"events:*"}},
+		{"scoped held covers scoped only", []string{"events:update"}, []string{"events:update@own"}, []string{"events:update"}},
+		{"unscoped held covers scoped", []string{"events:update@own"}, []string{"events:update"}, nil},
+		{"held deny inside grant", []string{"events:*"}, []string{"events:*", "!events:delete"}, []string{"events:*"}},
+		{"held deny matched by deny", []string{"events:*", "!events:delete"}, []string{"events:*", "!events:delete"}, nil},
+		{"held deny on the grant", []string{"events:delete"}, []string{"events:*", "!events:delete"}, []string{"events:delete"}},
+		{"deny entries ignored", []string{"!users:delete"}, nil, nil},
+		{"super admin held", []string{"users:*", "roles:manage"}, []string{"*:*"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PermissionsBeyond(tt.permissions, tt.held); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PermissionsBeyond(%q, %q) = %q, want %q", tt.permissions, tt.held, got, tt.want)
+			}
+		})
+	}
+}
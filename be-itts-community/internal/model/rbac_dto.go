@@ -0,0 +1,46 @@
+package model
+
+// =====================================
+// RBAC Export / Import
+// =====================================
+
+// RBACDocumentVersion is the version written by export and accepted by import
+const RBACDocumentVersion = 1
+
+// RBACDocument is a portable description of roles. Roles and permissions are
+// referenced by name so a document moves between environments.
+type RBACDocument struct {
+	Version int        `json:"version" yaml:"version"`
+	Roles   []RBACRole `json:"roles" yaml:"roles"`
+}
+
+// RBACRole describes one role. Allow entries may carry a scope
+// ("events:update@own"); deny entries cannot.
+type RBACRole struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description *string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Parent      string   `json:"parent,omitempty" yaml:"parent,omitempty"`
+	RequiresMFA bool     `json:"requires_mfa,omitempty" yaml:"requires_mfa,omitempty"`
+	System      bool     `json:"system,omitempty" yaml:"system,omitempty"` // informational; system roles cannot be changed by import
+	Allow       []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny        []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// RBACRoleDiff is what an import changes on one role
+type RBACRoleDiff struct {
+	Role        string   `json:"role"`
+	Op          string   `json:"op"`               // "create" or "update"
+	Fields      []string `json:"fields,omitempty"` // changed attributes: description, parent, requires_mfa
+	AddAllow    []string `json:"add_allow,omitempty"`
+	RemoveAllow []string `json:"remove_allow,omitempty"`
+	AddDeny     []string `json:"add_deny,omitempty"`
+	RemoveDeny  []string `json:"remove_deny,omitempty"`
+}
+
+// RBACImportResult lists the roles an import changes. With DryRun set
+// nothing was written. Roles missing from the document are left as is.
+type RBACImportResult struct {
+	DryRun    bool           `json:"dry_run"`
+	Changes   []RBACRoleDiff `json:"changes"`
+	Unchanged []string       `json:"unchanged"`
+}
@@ -0,0 +1,103 @@
+package rbac
+
+// Resources, with the descriptions of the seeded catalogue
+var (
+	resRegistrations      = Resource("registrations", "Member registration management")
+	resEvents             = Resource("events", "Event management")
+	resEventSpeakers      = Resource("event_speakers", "Event speaker management")
+	resEventRegistrations = Resource("event_registrations", "Event registration management")
+	resRoadmaps           = Resource("roadmaps", "Roadmap management")
+	resRoadmapItems       = Resource("roadmap_items", "Roadmap item management")
+	resMentors            = Resource("mentors", "Mentor management")
+	resPartners           = Resource("partners", "Partner management")
+	resUsers              = Resource("users", "User account management")
+	resRoles              = Resource("roles", "Role management")
+	resPermissions        = Resource("permissions", "Permission management")
+	resEmailOutbox        = Resource("email_outbox", "Outbound email queue")
+)
+
+// Actions
+var (
+	actCreate      = Action("create", "Create new resource")
+	actRead        = Action("read", "Read/view resource")
+	actUpdate      = Action("update", "Update existing resource")
+	actDelete      = Action("delete", "Delete resource")
+	actList        = Action("list", "List/search resources")
+	actApprove     = Action("approve", "Approve resource (e.g., registration)")
+	actReject      = Action("reject", "Reject resource (e.g., registration)")
+	actActivate    = Action("activate", "Activate/deactivate resource")
+	actManage      = Action("manage", "Full management access")
+	actImpersonate = Action("impersonate", "Act as another user")
+)
+
+// Permissions checked by routes and services. Route guards must use these;
+// middleware.RequirePermission panics on an undeclared name.
+var (
+	RegistrationsApprove = Permission(resRegistrations, actApprove)
+	RegistrationsDelete  = Permission(resRegistrations, actDelete)
+	RegistrationsList    = Permission(resRegistrations, actList)
+	RegistrationsRead    = Permission(resRegistrations, actRead)
+	RegistrationsReject  = Permission(resRegistrations, actReject)
+
+	EventsCreate = Permission(resEvents, actCreate)
+	EventsDelete = Permission(resEvents, actDelete)
+	EventsList   = Permission(resEvents, actList)
+	EventsRead   = Permission(resEvents, actRead)
+	EventsUpdate = Permission(resEvents, actUpdate)
+
+	EventSpeakersCreate = Permission(resEventSpeakers, actCreate)
+	EventSpeakersDelete = Permission(resEventSpeakers, actDelete)
+	EventSpeakersList   = Permission(resEventSpeakers, actList)
+	EventSpeakersUpdate = Permission(resEventSpeakers, actUpdate)
+
+	EventRegistrationsDelete = Permission(resEventRegistrations, actDelete)
+	EventRegistrationsList   = Permission(resEventRegistrations, actList)
+
+	RoadmapsCreate = Permission(resRoadmaps, actCreate)
+	RoadmapsDelete = Permission(resRoadmaps, actDelete)
+	RoadmapsList   = Permission(resRoadmaps, actList)
+	RoadmapsRead   = Permission(resRoadmaps, actRead)
+	RoadmapsUpdate = Permission(resRoadmaps, actUpdate)
+
+	RoadmapItemsCreate = Permission(resRoadmapItems, actCreate)
+	RoadmapItemsDelete = Permission(resRoadmapItems, actDelete)
+	RoadmapItemsList   = Permission(resRoadmapItems, actList)
+	RoadmapItemsRead   = Permission(resRoadmapItems, actRead)
+	RoadmapItemsUpdate = Permission(resRoadmapItems, actUpdate)
+
+	MentorsActivate = Permission(resMentors, actActivate)
+	MentorsCreate   = Permission(resMentors, actCreate)
+	MentorsDelete   = Permission(resMentors, actDelete)
+	MentorsList     = Permission(resMentors, actList)
+	MentorsRead     = Permission(resMentors, actRead)
+	MentorsUpdate   = Permission(resMentors, actUpdate)
+
+	PartnersActivate = Permission(resPartners, actActivate)
+	PartnersCreate   = Permission(resPartners, actCreate)
+	PartnersDelete   = Permission(resPartners, actDelete)
+	PartnersList     = Permission(resPartners, actList)
+	PartnersRead     = Permission(resPartners, actRead)
+	PartnersUpdate   = Permission(resPartners, actUpdate)
+
+	UsersCreate      = Permission(resUsers, actCreate)
+	UsersDelete      = Permission(resUsers, actDelete)
+	UsersImpersonate = Permission(resUsers, actImpersonate)
+	UsersList        = Permission(resUsers, actList)
+	UsersManage      = Permission(resUsers, actManage)
+	UsersRead        = Permission(resUsers, actRead)
+	UsersUpdate      = Permission(resUsers, actUpdate)
+
+	RolesCreate = Permission(resRoles, actCreate)
+	RolesDelete = Permission(resRoles, actDelete)
+	RolesList   = Permission(resRoles, actList)
+	RolesManage = Permission(resRoles, actManage)
+	RolesRead   = Permission(resRoles, actRead)
+	RolesUpdate = Permission(resRoles, actUpdate)
+
+	PermissionsList = Permission(resPermissions, actList)
+	PermissionsRead = Permission(resPermissions, actRead)
+
+	EmailOutboxList   = Permission(resEmailOutbox, actList)
+	EmailOutboxManage = Permission(resEmailOutbox, actManage)
+	EmailOutboxRead   = Permission(resEmailOutbox, actRead)
+)
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *apiTokenRepo) Create(ctx context.Context, token *model.APIToken) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "api_tokens", "Create")()
+	}
+	return r.db.Get(ctx).Create(token).Error
+}
+
+// ListByUser returns all tokens of a user, newest first, including revoked
+// and expired ones so the owner can see their history
+func (r *apiTokenRepo) ListByUser(ctx context.Context, userID string) ([]model.APIToken, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "api_tokens", "ListByUser")()
+	}
+	var out []model.APIToken
+	if err := r.db.Get(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *apiTokenRepo) GetActiveByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "api_tokens", "GetActiveByHash")()
+	}
+	var out model.APIToken
+	if err := r.db.Get(ctx).
+		Where("token_hash = ? AND revoked_at IS NULL AND expires_at > now()", tokenHash).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Revoke returns ErrRecordNotFound when the token does not belong to the
+// user or is already revoked
+func (r *apiTokenRepo) Revoke(ctx context.Context, userID, tokenID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "api_tokens", "Revoke")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *apiTokenRepo) TouchLastUsed(ctx context.Context, tokenID string, ip *string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "api_tokens", "TouchLastUsed")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.APIToken{}).
+		Where("id = ?", tokenID).
+		Updates(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_used_ip": ip,
+		}).Error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+// APITokenRepository handles personal API token data operations
+type APITokenRepository interface {
+	Create(ctx context.Context, token *model.APIToken) error
+	ListByUser(ctx context.Context, userID string) ([]model.APIToken, error)
+	// GetActiveByHash returns a token that is neither revoked nor expired
+	GetActiveByHash(ctx context.Context, tokenHash string) (*model.APIToken, error)
+	Revoke(ctx context.Context, userID, tokenID string) error
+	TouchLastUsed(ctx context.Context, tokenID string, ip *string, usedAt time.Time) error
+}
+
+type apiTokenRepo struct{ db db.Connection }
+
+func NewAPITokenRepository(db db.Connection) APITokenRepository {
+	return &apiTokenRepo{db: db}
+}
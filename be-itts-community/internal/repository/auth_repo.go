@@ -7,6 +7,9 @@ import (
 
 	"be-itts-community/internal/db"
 	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type authRepository struct {
@@ -87,6 +90,52 @@ func (r *authRepository) UpdateUser(ctx context.Context, user *model.User) error
 	return r.db.Get(ctx).Save(user).Error
 }
 
+// BumpTokenVersion invalidates all access tokens issued to a user so far
+func (r *authRepository) BumpTokenVersion(ctx context.Context, userID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "users", "UPDATE")()
+	}
+	return r.db.Get(ctx).
+		Exec("UPDATE users SET token_version = token_version + 1 WHERE id = ?", userID).Error
+}
+
+// GetUserTokenState returns what access token validation needs to know about
+// a user. It returns gorm.ErrRecordNotFound if the user no longer exists.
+func (r *authRepository) GetUserTokenState(ctx context.Context, userID string) (int, bool, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "users", "SELECT")()
+	}
+	var row struct {
+		TokenVersion int
+		IsActive     bool
+	}
+	err := r.db.Get(ctx).Model(&model.User{}).
+		Select("token_version", "is_active").
+		Where("id = ?", userID).
+		Take(&row).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return row.TokenVersion, row.IsActive, nil
+}
+
+// IsSessionActive reports whether a session (refresh token family) of a user
+// still holds an unrevoked token. Rotation swaps tokens in one transaction,
+// so a live session always has one.
+func (r *authRepository) IsSessionActive(ctx context.Context, userID, sessionID string) (bool, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "SELECT")()
+	}
+	var active bool
+	err := r.db.Get(ctx).Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens
+			WHERE user_id = ? AND family_id = ? AND revoked_at IS NULL
+		)
+	`, userID, sessionID).Scan(&active).Error
+	return active, err
+}
+
 // DeleteUser deletes a user
 func (r *authRepository) DeleteUser(ctx context.Context, id string) error {
 	if RepoTracer != nil {
@@ -142,17 +191,9 @@ func (r *authRepository) GetUserWithPermissions(ctx context.Context, id string)
 		return &user, []string{"*:*"}, nil
 	}
 
-	// Get all permissions from user's roles
+	// Get all permissions from user's roles and the roles they inherit from
 	var permissions []string
-	err = db.Raw(`
-		SELECT DISTINCT p.name
-		FROM permissions p
-		JOIN role_permissions rp ON rp.permission_id = p.id
-		JOIN user_roles ur ON ur.role_id = rp.role_id
-		WHERE ur.user_id = ?
-		  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
-		ORDER BY p.name
-	`, id).Scan(&permissions).Error
+	err = db.Raw(userRoleTreeCTE+userGrantNamesQuery, id).Scan(&permissions).Error
 
 	if err != nil {
 		return nil, nil, err
@@ -186,6 +227,22 @@ func (r *authRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash st
 	return &token, nil
 }
 
+// GetRefreshTokenByHashAny retrieves a refresh token by hash, including
+// revoked and expired ones (used for reuse detection)
+func (r *authRepository) GetRefreshTokenByHashAny(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "SELECT")()
+	}
+	var token model.RefreshToken
+	err := r.db.Get(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
 // RevokeRefreshToken revokes a refresh token
 func (r *authRepository) RevokeRefreshToken(ctx context.Context, tokenHash string) error {
 	if RepoTracer != nil {
@@ -197,6 +254,89 @@ func (r *authRepository) RevokeRefreshToken(ctx context.Context, tokenHash strin
 		Update("revoked_at", now).Error
 }
 
+// RotateRefreshToken revokes an active token and links it to its successor.
+// It returns gorm.ErrRecordNotFound if the token was already revoked, so
+// concurrent use of the same token is only honoured once.
+func (r *authRepository) RotateRefreshToken(ctx context.Context, oldID, newID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "UPDATE")()
+	}
+	res := r.db.Get(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", oldID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": newID,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every active token in a family
+func (r *authRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "UPDATE")()
+	}
+	now := time.Now()
+	return r.db.Get(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
+
+// ListUserSessions lists the active sessions of a user, most recently used first
+func (r *authRepository) ListUserSessions(ctx context.Context, userID string) ([]model.Session, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "SELECT")()
+	}
+	var sessions []model.Session
+	err := r.db.Get(ctx).Raw(`
+		SELECT rt.*,
+			(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id) AS started_at
+		FROM refresh_tokens rt
+		WHERE rt.user_id = ?
+			AND rt.revoked_at IS NULL
+			AND rt.expires_at > ?
+		ORDER BY COALESCE(rt.last_used_at, rt.created_at) DESC
+	`, userID, time.Now()).Scan(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSession revokes a session (token family) of a user. It returns
+// gorm.ErrRecordNotFound if the user has no such active session.
+func (r *authRepository) RevokeUserSession(ctx context.Context, userID, familyID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "UPDATE")()
+	}
+	res := r.db.Get(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id = ?", userID, familyID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeOtherUserSessions revokes every session of a user except one
+func (r *authRepository) RevokeOtherUserSessions(ctx context.Context, userID, keepFamilyID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "refresh_tokens", "UPDATE")()
+	}
+	now := time.Now()
+	return r.db.Get(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ?", userID, keepFamilyID).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
+
 // RevokeAllUserRefreshTokens revokes all refresh tokens for a user
 func (r *authRepository) RevokeAllUserRefreshTokens(ctx context.Context, userID string) error {
 	if RepoTracer != nil {
@@ -323,6 +463,123 @@ func (r *authRepository) GetUserByOAuth(ctx context.Context, provider, providerI
 	return &user, nil
 }
 
+// ListUserOAuthAccounts lists the provider accounts linked to a user
+func (r *authRepository) ListUserOAuthAccounts(ctx context.Context, userID string) ([]model.OAuthAccount, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "oauth_accounts", "SELECT")()
+	}
+	var accounts []model.OAuthAccount
+	err := r.db.Get(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// DeleteOAuthAccount unlinks a provider account. It returns
+// gorm.ErrRecordNotFound if the account does not belong to the user.
+func (r *authRepository) DeleteOAuthAccount(ctx context.Context, userID, accountID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "oauth_accounts", "DELETE")()
+	}
+	res := r.db.Get(ctx).
+		Where("id = ? AND user_id = ?", accountID, userID).
+		Delete(&model.OAuthAccount{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// LockUser selects the user FOR UPDATE so concurrent changes to the user's
+// login methods are serialized
+func (r *authRepository) LockUser(ctx context.Context, userID string) (*model.User, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "users", "SELECT")()
+	}
+	var user model.User
+	err := r.db.Get(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", userID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ConsumeMFAStep records the TOTP time step just used. It returns
+// gorm.ErrRecordNotFound if the step (or a later one) was already used.
+func (r *authRepository) ConsumeMFAStep(ctx context.Context, userID string, step int64) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "users", "UPDATE")()
+	}
+	res := r.db.Get(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Where("mfa_last_step IS NULL OR mfa_last_step < ?", step).
+		Update("mfa_last_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReplaceMFARecoveryCodes deletes a user's recovery codes and stores new ones
+func (r *authRepository) ReplaceMFARecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "mfa_recovery_codes", "INSERT")()
+	}
+
+	db := r.db.Get(ctx)
+	if err := db.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to remove existing recovery codes: %w", err)
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	codes := make([]model.MFARecoveryCode, len(codeHashes))
+	for i, h := range codeHashes {
+		codes[i] = model.MFARecoveryCode{UserID: userID, CodeHash: h}
+	}
+	return db.Create(&codes).Error
+}
+
+// UseMFARecoveryCode marks an unused recovery code as used. It returns
+// gorm.ErrRecordNotFound if no matching unused code exists.
+func (r *authRepository) UseMFARecoveryCode(ctx context.Context, userID, codeHash string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "mfa_recovery_codes", "UPDATE")()
+	}
+	res := r.db.Get(ctx).Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteMFARecoveryCodes removes all recovery codes of a user
+func (r *authRepository) DeleteMFARecoveryCodes(ctx context.Context, userID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "mfa_recovery_codes", "DELETE")()
+	}
+	return r.db.Get(ctx).Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error
+}
+
 // RunInTransaction executes a function within a transaction
 func (r *authRepository) RunInTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
 	return r.db.Run(ctx, fn)
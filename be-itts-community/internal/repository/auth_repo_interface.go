@@ -16,6 +16,9 @@ type AuthRepository interface {
 	UpdateUser(ctx context.Context, user *model.User) error
 	DeleteUser(ctx context.Context, id string) error
 	UpdateLastLogin(ctx context.Context, userID string) error
+	BumpTokenVersion(ctx context.Context, userID string) error
+	GetUserTokenState(ctx context.Context, userID string) (tokenVersion int, isActive bool, err error)
+	IsSessionActive(ctx context.Context, userID, sessionID string) (bool, error)
 
 	// User with Relations
 	GetUserWithRoles(ctx context.Context, id string) (*model.User, error)
@@ -24,7 +27,13 @@ type AuthRepository interface {
 	// Refresh Token Operations
 	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
 	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	GetRefreshTokenByHashAny(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
+	RotateRefreshToken(ctx context.Context, oldID, newID string) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	ListUserSessions(ctx context.Context, userID string) ([]model.Session, error)
+	RevokeUserSession(ctx context.Context, userID, familyID string) error
+	RevokeOtherUserSessions(ctx context.Context, userID, keepFamilyID string) error
 	RevokeAllUserRefreshTokens(ctx context.Context, userID string) error
 	DeleteExpiredRefreshTokens(ctx context.Context) error
 
@@ -38,6 +47,16 @@ type AuthRepository interface {
 	CreateOAuthAccount(ctx context.Context, account *model.OAuthAccount) error
 	UpdateOAuthAccount(ctx context.Context, account *model.OAuthAccount) error
 	GetUserByOAuth(ctx context.Context, provider, providerID string) (*model.User, error)
+	ListUserOAuthAccounts(ctx context.Context, userID string) ([]model.OAuthAccount, error)
+	DeleteOAuthAccount(ctx context.Context, userID, accountID string) error
+	// LockUser locks the user row until the surrounding transaction ends
+	LockUser(ctx context.Context, userID string) (*model.User, error)
+
+	// MFA Operations
+	ConsumeMFAStep(ctx context.Context, userID string, step int64) error
+	ReplaceMFARecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+	UseMFARecoveryCode(ctx context.Context, userID, codeHash string) error
+	DeleteMFARecoveryCodes(ctx context.Context, userID string) error
 
 	// Transaction support
 	RunInTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
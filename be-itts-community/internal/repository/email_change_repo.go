@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *emailChangeRepo) RunInTransaction(ctx context.Context, f func(tx context.Context) error) error {
+	return r.db.Run(ctx, f)
+}
+
+func (r *emailChangeRepo) Create(ctx context.Context, change *model.EmailChange) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "Create")()
+	}
+	return r.db.Get(ctx).Create(change).Error
+}
+
+func (r *emailChangeRepo) FindValidByHash(ctx context.Context, tokenHash string) (*model.EmailChange, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "FindValidByHash")()
+	}
+	var out model.EmailChange
+	if err := r.db.Get(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > now()", tokenHash).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *emailChangeRepo) FindPendingByUser(ctx context.Context, userID string) (*model.EmailChange, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "FindPendingByUser")()
+	}
+	var out model.EmailChange
+	if err := r.db.Get(ctx).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > now()", userID).
+		Order("created_at DESC").
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkConfirmed only succeeds once per change; a concurrent second use gets ErrRecordNotFound.
+func (r *emailChangeRepo) MarkConfirmed(ctx context.Context, id string, confirmedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "MarkConfirmed")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.EmailChange{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"used_at":      confirmedAt,
+			"confirmed_at": confirmedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *emailChangeRepo) FindCancellableByHash(ctx context.Context, cancelTokenHash string) (*model.EmailChange, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "FindCancellableByHash")()
+	}
+	var out model.EmailChange
+	if err := r.db.Get(ctx).
+		Where("cancel_token_hash = ? AND used_at IS NULL AND expires_at > now()", cancelTokenHash).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkCancelled only succeeds while the change is outstanding; a change
+// confirmed or cancelled first gets ErrRecordNotFound.
+func (r *emailChangeRepo) MarkCancelled(ctx context.Context, id string, cancelledAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "MarkCancelled")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.EmailChange{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"used_at":      cancelledAt,
+			"cancelled_at": cancelledAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *emailChangeRepo) InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_changes", "InvalidateForUser")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.EmailChange{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", usedAt).Error
+}
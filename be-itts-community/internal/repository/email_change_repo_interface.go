@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+type EmailChangeRepository interface {
+	RunInTransaction(ctx context.Context, f func(tx context.Context) error) error
+
+	Create(ctx context.Context, change *model.EmailChange) error
+	FindValidByHash(ctx context.Context, tokenHash string) (*model.EmailChange, error)
+	// FindPendingByUser returns the latest unconfirmed, unexpired change
+	FindPendingByUser(ctx context.Context, userID string) (*model.EmailChange, error)
+	MarkConfirmed(ctx context.Context, id string, confirmedAt time.Time) error
+	// FindCancellableByHash returns the outstanding change a cancel link belongs to
+	FindCancellableByHash(ctx context.Context, cancelTokenHash string) (*model.EmailChange, error)
+	MarkCancelled(ctx context.Context, id string, cancelledAt time.Time) error
+	// InvalidateForUser marks every outstanding change of a user as used
+	InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error
+}
+
+type emailChangeRepo struct{ db db.Connection }
+
+func NewEmailChangeRepository(db db.Connection) EmailChangeRepository {
+	return &emailChangeRepo{db: db}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *emailOutboxRepo) RunInTransaction(ctx context.Context, f func(tx context.Context) error) error {
+	return r.db.Run(ctx, f)
+}
+
+func (r *emailOutboxRepo) Enqueue(ctx context.Context, m *model.EmailOutbox) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "Enqueue")()
+	}
+	return r.db.Get(ctx).Create(m).Error
+}
+
+func (r *emailOutboxRepo) GetByID(ctx context.Context, id string) (*model.EmailOutbox, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "GetByID")()
+	}
+	var out model.EmailOutbox
+	if err := r.db.Get(ctx).First(&out, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *emailOutboxRepo) List(ctx context.Context, p ListParams) (*PageResult[model.EmailOutbox], error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "List")()
+	}
+	searchable := []string{"recipient", "subject", "last_error"}
+	sorts := map[string]string{
+		"recipient":       "recipient",
+		"status":          "status",
+		"attempts":        "attempts",
+		"next_attempt_at": "next_attempt_at",
+		"sent_at":         "sent_at",
+		"created_at":      "created_at",
+		"updated_at":      "updated_at",
+	}
+	if len(p.Sort) == 0 {
+		p.Sort = []string{"created_at:desc"}
+	}
+	q, err := ApplyListQuery(r.db.Get(ctx).Model(&model.EmailOutbox{}), &p, searchable, sorts)
+	if err != nil {
+		return nil, err
+	}
+	var rows []model.EmailOutbox
+	return Paginate[model.EmailOutbox](ctx, q, &p, &rows)
+}
+
+// ClaimDue locks up to limit due messages and leases them to the caller by
+// pushing next_attempt_at forward. A worker that dies mid-send leaves the row
+// in "sending"; it becomes claimable again once the lease expires.
+func (r *emailOutboxRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "ClaimDue")()
+	}
+	var rows []model.EmailOutbox
+	err := r.db.Run(ctx, func(txCtx context.Context) error {
+		tx := r.db.Get(txCtx)
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= now()", []model.EmailOutboxStatus{model.EmailOutboxPending, model.EmailOutboxSending}).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		ids := make([]string, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		leaseUntil := time.Now().Add(lease)
+		if err := tx.Model(&model.EmailOutbox{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":          model.EmailOutboxSending,
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": leaseUntil,
+				"updated_at":      time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].Status = model.EmailOutboxSending
+			rows[i].Attempts++
+			rows[i].NextAttemptAt = leaseUntil
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (r *emailOutboxRepo) MarkSent(ctx context.Context, id string, sentAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "MarkSent")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     model.EmailOutboxSent,
+			"sent_at":    sentAt,
+			"last_error": nil,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+func (r *emailOutboxRepo) MarkFailed(ctx context.Context, id string, lastError string, status model.EmailOutboxStatus, nextAttemptAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "MarkFailed")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+// Requeue resets a failed message so the worker picks it up on its next
+// tick: a dead-lettered one, a pending one waiting for a retry, or one left
+// in "sending" by a worker whose lease has expired. Delivered messages and
+// live leases are never touched; gorm.ErrRecordNotFound is returned instead.
+func (r *emailOutboxRepo) Requeue(ctx context.Context, id string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "email_outbox", "Requeue")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.EmailOutbox{}).
+		Where("id = ?", id).
+		Where("status = ? OR (status = ? AND last_error IS NOT NULL) OR (status = ? AND next_attempt_at <= now())",
+			model.EmailOutboxDead, model.EmailOutboxPending, model.EmailOutboxSending).
+		Updates(map[string]interface{}{
+			"status":          model.EmailOutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"updated_at":      time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
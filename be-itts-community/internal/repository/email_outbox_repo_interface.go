@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+type EmailOutboxRepository interface {
+	RunInTransaction(ctx context.Context, f func(tx context.Context) error) error
+
+	Enqueue(ctx context.Context, m *model.EmailOutbox) error
+	GetByID(ctx context.Context, id string) (*model.EmailOutbox, error)
+	List(ctx context.Context, p ListParams) (*PageResult[model.EmailOutbox], error)
+
+	// Worker operations
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.EmailOutbox, error)
+	MarkSent(ctx context.Context, id string, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id string, lastError string, status model.EmailOutboxStatus, nextAttemptAt time.Time) error
+	Requeue(ctx context.Context, id string) error
+}
+
+type emailOutboxRepo struct{ db db.Connection }
+
+func NewEmailOutboxRepository(db db.Connection) EmailOutboxRepository {
+	return &emailOutboxRepo{db: db}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *invitationRepo) RunInTransaction(ctx context.Context, f func(tx context.Context) error) error {
+	return r.db.Run(ctx, f)
+}
+
+func (r *invitationRepo) Create(ctx context.Context, inv *model.UserInvitation) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "Create")()
+	}
+	return r.db.Get(ctx).Create(inv).Error
+}
+
+func (r *invitationRepo) GetByID(ctx context.Context, id string) (*model.UserInvitation, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "GetByID")()
+	}
+	var out model.UserInvitation
+	if err := r.db.Get(ctx).Where("id = ?", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *invitationRepo) FindPendingByHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "FindPendingByHash")()
+	}
+	var out model.UserInvitation
+	if err := r.db.Get(ctx).
+		Preload("Inviter").
+		Where("token_hash = ? AND status = ? AND expires_at > now()", tokenHash, model.InvitationPending).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *invitationRepo) FindPendingByEmail(ctx context.Context, email string) (*model.UserInvitation, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "FindPendingByEmail")()
+	}
+	var out model.UserInvitation
+	if err := r.db.Get(ctx).
+		Where("email = ? AND status = ?", email, model.InvitationPending).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *invitationRepo) List(ctx context.Context, p ListParams) (*PageResult[model.UserInvitation], error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "List")()
+	}
+	searchable := []string{"email", "full_name"}
+	sorts := map[string]string{
+		"email":      "email",
+		"status":     "status",
+		"expires_at": "expires_at",
+		"created_at": "created_at",
+	}
+	if len(p.Sort) == 0 {
+		p.Sort = []string{"created_at:desc"}
+	}
+	// Expired is derived from expires_at rather than stored
+	base := r.db.Get(ctx).Model(&model.UserInvitation{})
+	switch p.Filters["status"] {
+	case string(model.InvitationPending):
+		delete(p.Filters, "status")
+		base = base.Where("status = ? AND expires_at > now()", model.InvitationPending)
+	case string(model.InvitationExpired):
+		delete(p.Filters, "status")
+		base = base.Where("status = ? AND expires_at <= now()", model.InvitationPending)
+	}
+	q, err := ApplyListQuery(base, &p, searchable, sorts)
+	if err != nil {
+		return nil, err
+	}
+	var rows []model.UserInvitation
+	return Paginate[model.UserInvitation](ctx, q, &p, &rows)
+}
+
+func (r *invitationRepo) Reissue(ctx context.Context, id, tokenHash string, expiresAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "Reissue")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.UserInvitation{}).
+		Where("id = ? AND status = ?", id, model.InvitationPending).
+		Updates(map[string]interface{}{
+			"token_hash":   tokenHash,
+			"expires_at":   expiresAt,
+			"send_count":   gorm.Expr("send_count + 1"),
+			"last_sent_at": time.Now(),
+			"updated_at":   time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Revoke only succeeds for pending invitations; otherwise ErrRecordNotFound.
+func (r *invitationRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "Revoke")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.UserInvitation{}).
+		Where("id = ? AND status = ?", id, model.InvitationPending).
+		Updates(map[string]interface{}{
+			"status":     model.InvitationRevoked,
+			"revoked_at": revokedAt,
+			"updated_at": revokedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkAccepted only succeeds once per invitation and only before it expires;
+// a concurrent second accept gets ErrRecordNotFound.
+func (r *invitationRepo) MarkAccepted(ctx context.Context, id, userID string, acceptedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_invitations", "MarkAccepted")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.UserInvitation{}).
+		Where("id = ? AND status = ? AND expires_at > now()", id, model.InvitationPending).
+		Updates(map[string]interface{}{
+			"status":           model.InvitationAccepted,
+			"accepted_at":      acceptedAt,
+			"accepted_user_id": userID,
+			"updated_at":       acceptedAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
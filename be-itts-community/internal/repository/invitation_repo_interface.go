@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+// InvitationRepository handles user invitation data operations
+type InvitationRepository interface {
+	RunInTransaction(ctx context.Context, f func(tx context.Context) error) error
+
+	Create(ctx context.Context, inv *model.UserInvitation) error
+	GetByID(ctx context.Context, id string) (*model.UserInvitation, error)
+	// FindPendingByHash returns an unexpired pending invitation with its inviter
+	FindPendingByHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error)
+	// FindPendingByEmail returns the open invitation for an email, expired or not
+	FindPendingByEmail(ctx context.Context, email string) (*model.UserInvitation, error)
+	List(ctx context.Context, p ListParams) (*PageResult[model.UserInvitation], error)
+	// Reissue replaces the token of a pending invitation and counts the resend
+	Reissue(ctx context.Context, id, tokenHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	MarkAccepted(ctx context.Context, id, userID string, acceptedAt time.Time) error
+}
+
+type invitationRepo struct{ db db.Connection }
+
+func NewInvitationRepository(db db.Connection) InvitationRepository {
+	return &invitationRepo{db: db}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *magicLinkRepo) RunInTransaction(ctx context.Context, f func(tx context.Context) error) error {
+	return r.db.Run(ctx, f)
+}
+
+func (r *magicLinkRepo) Create(ctx context.Context, link *model.MagicLink) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "magic_links", "Create")()
+	}
+	return r.db.Get(ctx).Create(link).Error
+}
+
+func (r *magicLinkRepo) FindValidByHash(ctx context.Context, tokenHash string) (*model.MagicLink, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "magic_links", "FindValidByHash")()
+	}
+	var out model.MagicLink
+	if err := r.db.Get(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > now()", tokenHash).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkUsed only succeeds once per link; a concurrent second use gets ErrRecordNotFound.
+func (r *magicLinkRepo) MarkUsed(ctx context.Context, id string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "magic_links", "MarkUsed")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.MagicLink{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *magicLinkRepo) InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "magic_links", "InvalidateForUser")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.MagicLink{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", usedAt).Error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+type MagicLinkRepository interface {
+	RunInTransaction(ctx context.Context, f func(tx context.Context) error) error
+
+	Create(ctx context.Context, link *model.MagicLink) error
+	FindValidByHash(ctx context.Context, tokenHash string) (*model.MagicLink, error)
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) error
+	// InvalidateForUser marks every outstanding link of a user as used
+	InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error
+}
+
+type magicLinkRepo struct{ db db.Connection }
+
+func NewMagicLinkRepository(db db.Connection) MagicLinkRepository {
+	return &magicLinkRepo{db: db}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *passwordResetRepo) RunInTransaction(ctx context.Context, f func(tx context.Context) error) error {
+	return r.db.Run(ctx, f)
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, pr *model.PasswordReset) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "password_resets", "Create")()
+	}
+	return r.db.Get(ctx).Create(pr).Error
+}
+
+func (r *passwordResetRepo) FindValidByHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "password_resets", "FindValidByHash")()
+	}
+	var out model.PasswordReset
+	if err := r.db.Get(ctx).
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > now()", tokenHash).
+		First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkUsed only succeeds once per token; a concurrent second use gets ErrRecordNotFound.
+func (r *passwordResetRepo) MarkUsed(ctx context.Context, id string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "password_resets", "MarkUsed")()
+	}
+	res := r.db.Get(ctx).
+		Model(&model.PasswordReset{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *passwordResetRepo) InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "password_resets", "InvalidateForUser")()
+	}
+	return r.db.Get(ctx).
+		Model(&model.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", usedAt).Error
+}
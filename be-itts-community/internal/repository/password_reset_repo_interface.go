@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"be-itts-community/internal/db"
+	"be-itts-community/internal/model"
+)
+
+type PasswordResetRepository interface {
+	RunInTransaction(ctx context.Context, f func(tx context.Context) error) error
+
+	Create(ctx context.Context, pr *model.PasswordReset) error
+	FindValidByHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error)
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) error
+	// InvalidateForUser marks every outstanding token of a user as used
+	InvalidateForUser(ctx context.Context, userID string, usedAt time.Time) error
+}
+
+type passwordResetRepo struct{ db db.Connection }
+
+func NewPasswordResetRepository(db db.Connection) PasswordResetRepository {
+	return &passwordResetRepo{db: db}
+}
@@ -7,6 +7,8 @@ import (
 
 	"be-itts-community/internal/db"
 	"be-itts-community/internal/model"
+
+	"gorm.io/gorm/clause"
 )
 
 type permissionRepository struct {
@@ -80,6 +82,20 @@ func (r *permissionRepository) ListRoles(ctx context.Context, params ListParams)
 	return Paginate(ctx, query, &params, &roles)
 }
 
+// ListAllRoles lists every role ordered by name
+func (r *permissionRepository) ListAllRoles(ctx context.Context) ([]model.Role, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "roles", "SELECT")()
+	}
+
+	var roles []model.Role
+	err := r.db.Get(ctx).Order("name ASC").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
 // UpdateRole updates role information
 func (r *permissionRepository) UpdateRole(ctx context.Context, role *model.Role) error {
 	if RepoTracer != nil {
@@ -109,7 +125,8 @@ func (r *permissionRepository) DeleteRole(ctx context.Context, id string) error
 	return r.db.Get(ctx).Where("id = ?", id).Delete(&model.Role{}).Error
 }
 
-// GetRoleWithPermissions retrieves role with preloaded permissions
+// GetRoleWithPermissions retrieves role with preloaded permissions. Denied
+// permissions are left out; GetRolePermissionGrants lists them.
 func (r *permissionRepository) GetRoleWithPermissions(ctx context.Context, id string) (*model.Role, error) {
 	if RepoTracer != nil {
 		defer RepoTracer.StartDatastoreSegment(ctx, "roles", "SELECT")()
@@ -117,7 +134,7 @@ func (r *permissionRepository) GetRoleWithPermissions(ctx context.Context, id st
 
 	var role model.Role
 	err := r.db.Get(ctx).
-		Preload("Permissions").
+		Preload("Permissions", "permissions.id NOT IN (SELECT permission_id FROM role_permissions WHERE role_id = ? AND effect = ?)", id, model.PermissionDeny).
 		Preload("Permissions.Resource").
 		Preload("Permissions.Action").
 		Where("id = ?", id).
@@ -128,6 +145,48 @@ func (r *permissionRepository) GetRoleWithPermissions(ctx context.Context, id st
 	return &role, nil
 }
 
+// GetRoleAncestors retrieves the parent chain of a role, nearest first. The
+// role itself is not included.
+func (r *permissionRepository) GetRoleAncestors(ctx context.Context, roleID string) ([]model.Role, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "roles", "SELECT")()
+	}
+
+	// depth is capped so a cycle already in the data cannot recurse forever
+	var rows []struct {
+		model.Role
+		Depth int
+	}
+	err := r.db.Get(ctx).Raw(`
+		WITH RECURSIVE ancestors(id, depth) AS (
+			SELECT parent_role_id, 1 FROM roles
+			WHERE id = ? AND parent_role_id IS NOT NULL
+			UNION
+			SELECT r.parent_role_id, a.depth + 1 FROM roles r
+			JOIN ancestors a ON a.id = r.id
+			WHERE r.parent_role_id IS NOT NULL AND a.depth < ?
+		)
+		SELECT roles.*, a.depth
+		FROM roles
+		JOIN ancestors a ON a.id = roles.id
+		ORDER BY a.depth
+	`, roleID, MaxRoleDepth).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(rows))
+	ancestors := make([]model.Role, 0, len(rows))
+	for _, row := range rows {
+		if seen[row.ID] {
+			continue
+		}
+		seen[row.ID] = true
+		ancestors = append(ancestors, row.Role)
+	}
+	return ancestors, nil
+}
+
 // GetRolesByIDs retrieves multiple roles by IDs
 func (r *permissionRepository) GetRolesByIDs(ctx context.Context, roleIDs []string) ([]model.Role, error) {
 	if RepoTracer != nil {
@@ -144,8 +203,10 @@ func (r *permissionRepository) GetRolesByIDs(ctx context.Context, roleIDs []stri
 
 // ===== ROLE PERMISSION OPERATIONS =====
 
-// AssignPermissionsToRole assigns permissions to a role
-func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string) error {
+// AssignPermissionsToRole assigns permissions to a role with the given scope
+// (empty for every instance) and effect. An existing entry for the same
+// permission and scope is replaced, so a deny overrides an unscoped allow.
+func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string, scope string, effect model.PermissionEffect) error {
 	if RepoTracer != nil {
 		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "INSERT")()
 	}
@@ -153,7 +214,7 @@ func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, role
 	db := r.db.Get(ctx)
 
 	// Remove existing permissions first
-	err := db.Where("role_id = ? AND permission_id IN ?", roleID, permissionIDs).
+	err := db.Where("role_id = ? AND permission_id IN ? AND scope = ?", roleID, permissionIDs, scope).
 		Delete(&model.RolePermission{}).Error
 	if err != nil {
 		return fmt.Errorf("failed to remove existing permissions: %w", err)
@@ -164,6 +225,8 @@ func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, role
 		rolePermission := model.RolePermission{
 			RoleID:       roleID,
 			PermissionID: permissionID,
+			Scope:        scope,
+			Effect:       effect,
 		}
 		if err := db.Create(&rolePermission).Error; err != nil {
 			return fmt.Errorf("failed to assign permission %s: %w", permissionID, err)
@@ -173,7 +236,7 @@ func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, role
 	return nil
 }
 
-// RemovePermissionsFromRole removes permissions from a role
+// RemovePermissionsFromRole removes permissions from a role, whatever their scope
 func (r *permissionRepository) RemovePermissionsFromRole(ctx context.Context, roleID string, permissionIDs []string) error {
 	if RepoTracer != nil {
 		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "DELETE")()
@@ -184,7 +247,7 @@ func (r *permissionRepository) RemovePermissionsFromRole(ctx context.Context, ro
 		Delete(&model.RolePermission{}).Error
 }
 
-// GetRolePermissions retrieves all permissions for a role
+// GetRolePermissions retrieves the permissions a role allows
 func (r *permissionRepository) GetRolePermissions(ctx context.Context, roleID string) ([]model.Permission, error) {
 	if RepoTracer != nil {
 		defer RepoTracer.StartDatastoreSegment(ctx, "permissions", "SELECT")()
@@ -193,7 +256,7 @@ func (r *permissionRepository) GetRolePermissions(ctx context.Context, roleID st
 	var permissions []model.Permission
 	err := r.db.Get(ctx).
 		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
-		Where("role_permissions.role_id = ?", roleID).
+		Where("role_permissions.role_id = ? AND role_permissions.effect = ?", roleID, model.PermissionAllow).
 		Preload("Resource").
 		Preload("Action").
 		Find(&permissions).Error
@@ -203,6 +266,87 @@ func (r *permissionRepository) GetRolePermissions(ctx context.Context, roleID st
 	return permissions, nil
 }
 
+// GetRolePermissionGrants retrieves the direct grants of a role with their
+// scopes and permissions
+func (r *permissionRepository) GetRolePermissionGrants(ctx context.Context, roleID string) ([]model.RolePermission, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "SELECT")()
+	}
+
+	var grants []model.RolePermission
+	err := r.db.Get(ctx).
+		Preload("Permission").
+		Preload("Permission.Resource").
+		Preload("Permission.Action").
+		Where("role_id = ?", roleID).
+		Order("effect, scope").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// ListAllRolePermissionGrants retrieves the grants of every role with their
+// permissions
+func (r *permissionRepository) ListAllRolePermissionGrants(ctx context.Context) ([]model.RolePermission, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "SELECT")()
+	}
+
+	var grants []model.RolePermission
+	err := r.db.Get(ctx).
+		Preload("Permission").
+		Order("role_id, effect, scope").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// GetRoleGrantsForPermissions retrieves the grants of the given roles on the
+// given permission names
+func (r *permissionRepository) GetRoleGrantsForPermissions(ctx context.Context, roleIDs []string, permissionNames []string) ([]model.RolePermission, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "SELECT")()
+	}
+
+	var grants []model.RolePermission
+	if len(roleIDs) == 0 || len(permissionNames) == 0 {
+		return grants, nil
+	}
+	err := r.db.Get(ctx).
+		Preload("Permission").
+		Joins("JOIN permissions p ON p.id = role_permissions.permission_id").
+		Where("role_permissions.role_id IN ? AND p.name IN ?", roleIDs, permissionNames).
+		Order("role_permissions.effect, role_permissions.scope").
+		Find(&grants).Error
+	if err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// ReplaceRoleGrants replaces every grant of a role with the given ones
+func (r *permissionRepository) ReplaceRoleGrants(ctx context.Context, roleID string, grants []model.RolePermission) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "role_permissions", "UPDATE")()
+	}
+
+	db := r.db.Get(ctx)
+	if err := db.Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+		return fmt.Errorf("failed to remove existing permissions: %w", err)
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	for i := range grants {
+		grants[i].RoleID = roleID
+	}
+	return db.Omit("Permission").Create(&grants).Error
+}
+
 // ===== PERMISSION OPERATIONS =====
 
 // GetPermissionByID retrieves permission by ID
@@ -269,6 +413,41 @@ func (r *permissionRepository) ListPermissions(ctx context.Context, params ListP
 	return Paginate(ctx, query, &params, &permissions)
 }
 
+// CreatePermission adds a permission to the catalogue
+func (r *permissionRepository) CreatePermission(ctx context.Context, permission *model.Permission) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "permissions", "INSERT")()
+	}
+	return r.db.Get(ctx).Create(permission).Error
+}
+
+// EnsurePermission creates the permission unless one with the same name or
+// resource and action exists; it reports whether a row was created
+func (r *permissionRepository) EnsurePermission(ctx context.Context, permission *model.Permission) (bool, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "permissions", "INSERT")()
+	}
+	res := r.db.Get(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(permission)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ListPermissionNames retrieves the names of every permission in the catalogue
+func (r *permissionRepository) ListPermissionNames(ctx context.Context) ([]string, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "permissions", "SELECT")()
+	}
+
+	var names []string
+	err := r.db.Get(ctx).Model(&model.Permission{}).Order("name ASC").Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 // GetPermissionsByIDs retrieves multiple permissions by IDs
 func (r *permissionRepository) GetPermissionsByIDs(ctx context.Context, permissionIDs []string) ([]model.Permission, error) {
 	if RepoTracer != nil {
@@ -345,32 +524,115 @@ func (r *permissionRepository) GetActionByName(ctx context.Context, name string)
 	return &action, nil
 }
 
+// EnsureResource returns the resource with the given name, creating it if
+// missing; it reports whether a row was created
+func (r *permissionRepository) EnsureResource(ctx context.Context, name, description string) (*model.Resource, bool, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "resources", "INSERT")()
+	}
+
+	resource := model.Resource{Name: name, Description: &description}
+	res := r.db.Get(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&resource)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return &resource, true, nil
+	}
+	existing, err := r.GetResourceByName(ctx, name)
+	return existing, false, err
+}
+
+// EnsureAction returns the action with the given name, creating it if
+// missing; it reports whether a row was created
+func (r *permissionRepository) EnsureAction(ctx context.Context, name, description string) (*model.Action, bool, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "actions", "INSERT")()
+	}
+
+	action := model.Action{Name: name, Description: &description}
+	res := r.db.Get(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(&action)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return &action, true, nil
+	}
+	existing, err := r.GetActionByName(ctx, name)
+	return existing, false, err
+}
+
 // ===== HELPER QUERIES =====
 
-// CheckUserHasPermission checks if user has specific permission
+// userRoleTreeCTE resolves the roles a user holds through unexpired
+// assignments, plus every ancestor reached through parent_role_id. UNION
+// (not UNION ALL) makes the recursion stop even if the data has a cycle.
+// Takes the user ID as its only parameter.
+const userRoleTreeCTE = `
+		WITH RECURSIVE user_role_tree(role_id) AS (
+			SELECT ur.role_id FROM user_roles ur
+			WHERE ur.user_id = ?
+			  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+			UNION
+			SELECT r.parent_role_id FROM roles r
+			JOIN user_role_tree t ON t.role_id = r.id
+			WHERE r.parent_role_id IS NOT NULL
+		)`
+
+// CheckUserHasPermission checks if user has specific permission, directly or
+// through a wildcard grant, and no deny covers it. Scoped grants are not
+// counted since they only apply to some instances.
 func (r *permissionRepository) CheckUserHasPermission(ctx context.Context, userID string, permissionName string) (bool, error) {
 	if RepoTracer != nil {
 		defer RepoTracer.StartDatastoreSegment(ctx, "permissions", "SELECT")()
 	}
 
-	var count int64
-	err := r.db.Get(ctx).Raw(`
-		SELECT COUNT(DISTINCT p.id)
-		FROM permissions p
-		JOIN role_permissions rp ON rp.permission_id = p.id
-		JOIN user_roles ur ON ur.role_id = rp.role_id
-		WHERE ur.user_id = ?
-		  AND p.name = ?
-		  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
-	`, userID, permissionName).Count(&count).Error
+	candidates := model.PermissionCandidates(permissionName)
+	var allowed bool
+	err := r.db.Get(ctx).Raw(userRoleTreeCTE+`
+		SELECT EXISTS (
+			SELECT 1
+			FROM permissions p
+			JOIN role_permissions rp ON rp.permission_id = p.id
+			JOIN user_role_tree t ON t.role_id = rp.role_id
+			WHERE p.name IN ?
+			  AND rp.scope = ''
+			  AND rp.effect = 'allow'
+		) AND NOT EXISTS (
+			SELECT 1
+			FROM permissions p
+			JOIN role_permissions rp ON rp.permission_id = p.id
+			JOIN user_role_tree t ON t.role_id = rp.role_id
+			WHERE p.name IN ?
+			  AND rp.effect = 'deny'
+		)
+	`, userID, candidates, candidates).Scan(&allowed).Error
 
 	if err != nil {
 		return false, err
 	}
 
-	return count > 0, nil
+	return allowed, nil
 }
 
+// userGrantNamesQuery selects the grants of user_role_tree as permission
+// names, with "@scope" appended to scoped grants and "!" prepended to denies
+const userGrantNamesQuery = `
+		SELECT DISTINCT CASE
+			WHEN rp.effect = 'deny' THEN '!' || p.name
+			WHEN rp.scope = '' THEN p.name
+			ELSE p.name || '@' || rp.scope
+		END AS name
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN user_role_tree t ON t.role_id = rp.role_id
+		ORDER BY name
+	`
+
 // GetUserPermissionNames retrieves all permission names for a user
 func (r *permissionRepository) GetUserPermissionNames(ctx context.Context, userID string) ([]string, error) {
 	if RepoTracer != nil {
@@ -378,15 +640,7 @@ func (r *permissionRepository) GetUserPermissionNames(ctx context.Context, userI
 	}
 
 	var permissions []string
-	err := r.db.Get(ctx).Raw(`
-		SELECT DISTINCT p.name
-		FROM permissions p
-		JOIN role_permissions rp ON rp.permission_id = p.id
-		JOIN user_roles ur ON ur.role_id = rp.role_id
-		WHERE ur.user_id = ?
-		  AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
-		ORDER BY p.name
-	`, userID).Scan(&permissions).Error
+	err := r.db.Get(ctx).Raw(userRoleTreeCTE+userGrantNamesQuery, userID).Scan(&permissions).Error
 
 	if err != nil {
 		return nil, err
@@ -395,6 +649,43 @@ func (r *permissionRepository) GetUserPermissionNames(ctx context.Context, userI
 	return permissions, nil
 }
 
+// GetUserRoleAssignments retrieves the role assignments of a user with their
+// roles, expired ones included
+func (r *permissionRepository) GetUserRoleAssignments(ctx context.Context, userID string) ([]model.UserRole, error) {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "user_roles", "SELECT")()
+	}
+
+	var assignments []model.UserRole
+	err := r.db.Get(ctx).
+		Preload("Role").
+		Where("user_id = ?", userID).
+		Order("granted_at").
+		Find(&assignments).Error
+	if err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// BumpRoleMembersTokenVersion invalidates access tokens of every user holding
+// a role, directly or through a role that inherits from it
+func (r *permissionRepository) BumpRoleMembersTokenVersion(ctx context.Context, roleID string) error {
+	if RepoTracer != nil {
+		defer RepoTracer.StartDatastoreSegment(ctx, "users", "UPDATE")()
+	}
+	return r.db.Get(ctx).Exec(`
+		WITH RECURSIVE role_tree(id) AS (
+			SELECT id FROM roles WHERE id = ?
+			UNION
+			SELECT r.id FROM roles r
+			JOIN role_tree t ON r.parent_role_id = t.id
+		)
+		UPDATE users SET token_version = token_version + 1
+		WHERE id IN (SELECT user_id FROM user_roles WHERE role_id IN (SELECT id FROM role_tree))
+	`, roleID).Error
+}
+
 // RunInTransaction executes a function within a transaction
 func (r *permissionRepository) RunInTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
 	return r.db.Run(ctx, fn)
@@ -6,6 +6,10 @@ import (
 	"be-itts-community/internal/model"
 )
 
+// MaxRoleDepth bounds how many parent_role_id hops are followed when
+// resolving a role's ancestors
+const MaxRoleDepth = 32
+
 // PermissionRepository handles role and permission data operations
 type PermissionRepository interface {
 	// Role CRUD
@@ -13,33 +17,46 @@ type PermissionRepository interface {
 	GetRoleByID(ctx context.Context, id string) (*model.Role, error)
 	GetRoleByName(ctx context.Context, name string) (*model.Role, error)
 	ListRoles(ctx context.Context, params ListParams) (*PageResult[model.Role], error)
+	ListAllRoles(ctx context.Context) ([]model.Role, error)
 	UpdateRole(ctx context.Context, role *model.Role) error
 	DeleteRole(ctx context.Context, id string) error
 
 	// Role with Relations
 	GetRoleWithPermissions(ctx context.Context, id string) (*model.Role, error)
 	GetRolesByIDs(ctx context.Context, roleIDs []string) ([]model.Role, error)
+	GetRoleAncestors(ctx context.Context, roleID string) ([]model.Role, error)
 
 	// Role Permission Operations
-	AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string) error
+	AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string, scope string, effect model.PermissionEffect) error
 	RemovePermissionsFromRole(ctx context.Context, roleID string, permissionIDs []string) error
 	GetRolePermissions(ctx context.Context, roleID string) ([]model.Permission, error)
+	GetRolePermissionGrants(ctx context.Context, roleID string) ([]model.RolePermission, error)
+	ListAllRolePermissionGrants(ctx context.Context) ([]model.RolePermission, error)
+	GetRoleGrantsForPermissions(ctx context.Context, roleIDs []string, permissionNames []string) ([]model.RolePermission, error)
+	ReplaceRoleGrants(ctx context.Context, roleID string, grants []model.RolePermission) error
+	BumpRoleMembersTokenVersion(ctx context.Context, roleID string) error
 
-	// Permission CRUD (mostly read-only, permissions are seeded)
+	// Permission CRUD (mostly read-only, permissions are synced from internal/rbac)
 	GetPermissionByID(ctx context.Context, id string) (*model.Permission, error)
 	GetPermissionByName(ctx context.Context, name string) (*model.Permission, error)
 	ListPermissions(ctx context.Context, params ListParams) (*PageResult[model.Permission], error)
 	GetPermissionsByIDs(ctx context.Context, permissionIDs []string) ([]model.Permission, error)
+	CreatePermission(ctx context.Context, permission *model.Permission) error
+	EnsurePermission(ctx context.Context, permission *model.Permission) (bool, error)
+	ListPermissionNames(ctx context.Context) ([]string, error)
 
-	// Resource & Action (read-only)
+	// Resource & Action
 	ListResources(ctx context.Context) ([]model.Resource, error)
 	ListActions(ctx context.Context) ([]model.Action, error)
 	GetResourceByName(ctx context.Context, name string) (*model.Resource, error)
 	GetActionByName(ctx context.Context, name string) (*model.Action, error)
+	EnsureResource(ctx context.Context, name, description string) (*model.Resource, bool, error)
+	EnsureAction(ctx context.Context, name, description string) (*model.Action, bool, error)
 
 	// Helper Queries
 	CheckUserHasPermission(ctx context.Context, userID string, permissionName string) (bool, error)
 	GetUserPermissionNames(ctx context.Context, userID string) ([]string, error)
+	GetUserRoleAssignments(ctx context.Context, userID string) ([]model.UserRole, error)
 
 	// Transaction support
 	RunInTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
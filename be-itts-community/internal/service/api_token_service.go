@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
+	"be-itts-community/pkg/validator"
+)
+
+type apiTokenService struct {
+	repo           repository.APITokenRepository
+	authRepo       repository.AuthRepository
+	permissionRepo repository.PermissionRepository
+	auditRepo      repository.AuditLogRepository
+	permCache      *permcache.Cache
+	defaultTTLDays int
+	touchInterval  time.Duration // minimum gap between last_used_at writes
+	tracer         nr.Tracer
+	log            *core.Logger
+}
+
+// ListTokens lists the user's API tokens without their secrets
+func (s *apiTokenService) ListTokens(ctx context.Context, userID string) ([]model.APITokenResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "APITokenService.ListTokens")()
+	}
+
+	tokens, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+
+	resp := make([]model.APITokenResponse, 0, len(tokens))
+	for i := range tokens {
+		resp = append(resp, tokens[i].ToAPITokenResponse())
+	}
+	return resp, nil
+}
+
+// CreateToken issues a new API token. Scopes must be permissions the user
+// currently holds; the plain token is only returned here.
+func (s *apiTokenService) CreateToken(ctx context.Context, userID string, req model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "APITokenService.CreateToken")()
+	}
+
+	// A leaked token must not be able to mint more tokens
+	if auth.APITokenIDFromContext(ctx) != "" {
+		return nil, core.Forbidden("API tokens cannot create other API tokens")
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	scopes, err := s.validateScopes(ctx, user, permissions, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, prefix, hash, err := auth.GenerateAPIToken()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate api token").WithError(err)
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = s.defaultTTLDays
+	}
+
+	token := &model.APIToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		TokenPrefix: prefix,
+		TokenHash:   hash,
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().AddDate(0, 0, days),
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create api token: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "api_token.created", strPtr("api_tokens"), &token.ID, map[string]interface{}{
+		"name":       token.Name,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+
+	return &model.CreateAPITokenResponse{
+		APITokenResponse: token.ToAPITokenResponse(),
+		Token:            raw,
+	}, nil
+}
+
+// RevokeToken revokes one of the user's API tokens
+func (s *apiTokenService) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "APITokenService.RevokeToken")()
+	}
+
+	if err := s.repo.Revoke(ctx, userID, tokenID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("api_token", tokenID)
+		}
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "api_token.revoked", strPtr("api_tokens"), &tokenID, nil)
+
+	return nil
+}
+
+// AuthenticateAPIToken resolves a raw token to an auth context. The
+// effective permissions are the token scopes the owner still holds, so
+// removing a role from the owner also narrows their tokens.
+func (s *apiTokenService) AuthenticateAPIToken(ctx context.Context, rawToken string) (*model.AuthContext, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "APITokenService.AuthenticateAPIToken")()
+	}
+
+	token, err := s.repo.GetActiveByHash(ctx, auth.HashAPIToken(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.Unauthorized("Invalid or expired API token")
+		}
+		return nil, core.InternalServerError("failed to validate api token").WithError(err)
+	}
+
+	user, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.Unauthorized("Invalid or expired API token")
+		}
+		return nil, core.InternalServerError("failed to validate api token").WithError(err)
+	}
+	if !user.IsActive {
+		return nil, core.Unauthorized("Account is deactivated")
+	}
+
+	effective := token.Scopes
+	if !user.IsSuperAdmin {
+		effective = intersectScopes(token.Scopes, permissions)
+	}
+
+	roles := make([]string, len(user.Roles))
+	for i, role := range user.Roles {
+		roles[i] = role.Name
+	}
+
+	// Throttle last-used bookkeeping so busy scripts don't write on every call
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= s.touchInterval {
+		if err := s.repo.TouchLastUsed(ctx, token.ID, getIPFromContext(ctx), now); err != nil {
+			s.log.WithError(err).WithField("token_id", token.ID).Warn("failed to update api token last use")
+		}
+	}
+
+	return &model.AuthContext{
+		UserID: user.ID,
+		Email:  user.Email,
+		// Never grant the super admin bypass to a token; it is limited to
+		// its scopes
+		IsSuperAdmin: false,
+		Roles:        roles,
+		Permissions:  effective,
+		APITokenID:   token.ID,
+	}, nil
+}
+
+// validateScopes normalizes requested scopes and checks the owner holds each
+// of them, directly or through a wildcard. Super admins may grant any
+// existing permission.
+func (s *apiTokenService) validateScopes(ctx context.Context, user *model.User, permissions, requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		if !user.IsSuperAdmin && !model.PermissionsCover(permissions, scope) {
+			return nil, core.Forbidden("cannot grant a permission you do not have").WithDetail("scope", scope)
+		}
+		// Held through a wildcard does not mean the permission exists
+		name, grantScope := model.SplitPermissionScope(scope)
+		if !model.ValidPermissionScope(grantScope) {
+			return nil, core.BadRequest("unknown permission scope").WithDetail("scope", scope)
+		}
+		if _, err := s.permissionRepo.GetPermissionByName(ctx, name); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, core.BadRequest("unknown permission").WithDetail("scope", scope)
+			}
+			return nil, fmt.Errorf("failed to get permission: %w", err)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	sort.Strings(scopes)
+	return scopes, nil
+}
+
+// intersectScopes returns the scopes that permissions still cover, plus the
+// owner's denies so a wildcard scope cannot reach what the owner is denied
+func intersectScopes(scopes, permissions []string) []string {
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if model.PermissionsCover(permissions, scope) {
+			out = append(out, scope)
+		}
+	}
+	return append(out, model.PermissionDenies(permissions)...)
+}
+
+func (s *apiTokenService) auditLog(ctx context.Context, userID *string, action string, resourceType *string, resourceID *string, metadata map[string]interface{}) {
+	log := &model.AuditLog{
+		UserID:         userID,
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Metadata:       metadata,
+		IPAddress:      getIPFromContext(ctx),
+		UserAgent:      getUserAgentFromContext(ctx),
+		APITokenID:     getAPITokenIDFromContext(ctx),
+		ImpersonatorID: getImpersonatorIDFromContext(ctx),
+	}
+
+	// Non-blocking audit log (fire and forget)
+	go func() {
+		_ = s.auditRepo.CreateAuditLog(context.Background(), log)
+	}()
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
+)
+
+// APITokenService manages personal API tokens and authenticates requests
+// made with them. A token carries a subset of its owner's permissions.
+type APITokenService interface {
+	// Self-service
+	ListTokens(ctx context.Context, userID string) ([]model.APITokenResponse, error)
+	CreateToken(ctx context.Context, userID string, req model.CreateAPITokenRequest) (*model.CreateAPITokenResponse, error)
+	RevokeToken(ctx context.Context, userID, tokenID string) error
+
+	// Authentication
+	AuthenticateAPIToken(ctx context.Context, rawToken string) (*model.AuthContext, error)
+}
+
+func NewAPITokenService(
+	repo repository.APITokenRepository,
+	authRepo repository.AuthRepository,
+	permissionRepo repository.PermissionRepository,
+	auditRepo repository.AuditLogRepository,
+	permCache *permcache.Cache,
+	tracer nr.Tracer,
+	log *core.Logger,
+) APITokenService {
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "api-tokens"})
+	}
+	return &apiTokenService{
+		repo:           repo,
+		authRepo:       authRepo,
+		permissionRepo: permissionRepo,
+		auditRepo:      auditRepo,
+		permCache:      permCache,
+		defaultTTLDays: 90,
+		touchInterval:  time.Minute,
+		tracer:         tracer,
+		log:            log,
+	}
+}
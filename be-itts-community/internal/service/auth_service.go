@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/daisyorscry/itts/core"
@@ -12,40 +13,87 @@ import (
 	"be-itts-community/internal/model"
 	"be-itts-community/internal/repository"
 	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/clientinfo"
 	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
+	"be-itts-community/pkg/validator"
 )
 
 type authService struct {
-	authRepo       repository.AuthRepository
-	permissionRepo repository.PermissionRepository
-	auditRepo      repository.AuditLogRepository
-	jwtManager     *auth.JWTManager
-	tracer         nr.Tracer
+	authRepo        repository.AuthRepository
+	permissionRepo  repository.PermissionRepository
+	auditRepo       repository.AuditLogRepository
+	resetRepo       repository.PasswordResetRepository
+	magicLinkRepo   repository.MagicLinkRepository
+	emailChangeRepo repository.EmailChangeRepository
+	outbox          EmailOutboxService
+	jwtManager      *auth.JWTManager
+	secretBox       *auth.SecretBox
+	loginProtection LoginProtection
+	passwordPolicy  auth.PasswordPolicy
+	permCache       *permcache.Cache
+	resetTokenTTL   time.Duration
+	magicLinkTTL    time.Duration
+	emailChangeTTL  time.Duration
+	mfaIssuer       string
+	tracer          nr.Tracer
+	log             *core.Logger
 }
 
-// NewAuthService creates a new auth service
+// NewAuthService creates a new auth service. permCache and log may be nil.
 func NewAuthService(
 	authRepo repository.AuthRepository,
 	permissionRepo repository.PermissionRepository,
 	auditRepo repository.AuditLogRepository,
+	resetRepo repository.PasswordResetRepository,
+	magicLinkRepo repository.MagicLinkRepository,
+	emailChangeRepo repository.EmailChangeRepository,
+	outbox EmailOutboxService,
 	jwtManager *auth.JWTManager,
+	secretBox *auth.SecretBox,
+	loginProtection LoginProtection,
+	passwordPolicy auth.PasswordPolicy,
+	permCache *permcache.Cache,
 	tracer nr.Tracer,
+	log *core.Logger,
 ) AuthService {
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "auth"})
+	}
 	return &authService{
-		authRepo:       authRepo,
-		permissionRepo: permissionRepo,
-		auditRepo:      auditRepo,
-		jwtManager:     jwtManager,
-		tracer:         tracer,
+		authRepo:        authRepo,
+		permissionRepo:  permissionRepo,
+		auditRepo:       auditRepo,
+		resetRepo:       resetRepo,
+		magicLinkRepo:   magicLinkRepo,
+		emailChangeRepo: emailChangeRepo,
+		outbox:          outbox,
+		jwtManager:      jwtManager,
+		secretBox:       secretBox,
+		loginProtection: loginProtection.withDefaults(),
+		passwordPolicy:  passwordPolicy.WithDefaults(),
+		permCache:       permCache,
+		resetTokenTTL:   time.Hour,
+		magicLinkTTL:    15 * time.Minute,
+		emailChangeTTL:  24 * time.Hour,
+		mfaIssuer:       "ITTS Community",
+		tracer:          tracer,
+		log:             log,
 	}
 }
 
-// Login authenticates a user and returns tokens
-func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, error) {
+// Login authenticates a user and returns tokens, or an MFA challenge when a
+// second factor is required
+func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, *model.MFAChallengeResponse, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "AuthService.Login")()
 	}
 
+	// Brute-force protection
+	if err := s.checkLoginAllowed(ctx, req.Email); err != nil {
+		return nil, nil, err
+	}
+
 	// Get user by email
 	user, err := s.authRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -55,9 +103,12 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 				"email":  req.Email,
 				"reason": "user not found",
 			})
-			return nil, core.Unauthorized("Invalid email or password")
+			if err := s.recordLoginFailure(ctx, req.Email, nil); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, core.Unauthorized("Invalid email or password")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Check if user is active
@@ -66,7 +117,7 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 			"email":  req.Email,
 			"reason": "user not active",
 		})
-		return nil, core.Forbidden("Account is inactive")
+		return nil, nil, core.Forbidden("Account is inactive")
 	}
 
 	// Check if user has password (not OAuth-only account)
@@ -75,7 +126,7 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 			"email":  req.Email,
 			"reason": "OAuth-only account",
 		})
-		return nil, core.BadRequest("This account uses OAuth login. Please use the OAuth provider to sign in.")
+		return nil, nil, core.BadRequest("This account uses OAuth login. Please use the OAuth provider to sign in.")
 	}
 
 	// Verify password
@@ -84,11 +135,40 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 			"email":  req.Email,
 			"reason": "invalid password",
 		})
-		return nil, core.Unauthorized("Invalid email or password")
+		if err := s.recordLoginFailure(ctx, req.Email, user); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, core.Unauthorized("Invalid email or password")
+	}
+	s.clearLoginFailures(ctx, req.Email)
+
+	// Second factor
+	challenge, err := s.mfaChallenge(ctx, user, "password")
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, challenge, nil
+	}
+
+	resp, err := s.issueLogin(ctx, user)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	// Audit log
+	s.auditLog(ctx, &user.ID, "user.login.success", nil, nil, map[string]interface{}{
+		"email": req.Email,
+	})
+
+	return resp, nil, nil
+}
+
+// issueLogin creates the access/refresh token pair for an authenticated user
+// and records the login
+func (s *authService) issueLogin(ctx context.Context, user *model.User) (*model.LoginResponse, error) {
 	// Get user permissions
-	_, permissions, err := s.authRepo.GetUserWithPermissions(ctx, user.ID)
+	_, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, user.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user permissions: %w", err)
 	}
@@ -103,45 +183,46 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 		roleNames[i] = role.Name
 	}
 
-	// Generate access token
-	accessToken, err := s.jwtManager.GenerateAccessToken(
-		user.ID,
-		user.Email,
-		user.IsSuperAdmin,
-		roleNames,
-		permissions,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
-	}
-
 	// Generate refresh token
 	refreshTokenStr, err := s.jwtManager.GenerateRefreshToken()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Save refresh token to database
+	// Save refresh token to database; this starts a new session (family)
+	now := time.Now()
 	refreshToken := &model.RefreshToken{
-		UserID:    user.ID,
-		TokenHash: s.jwtManager.HashRefreshToken(refreshTokenStr),
-		ExpiresAt: time.Now().Add(s.jwtManager.GetRefreshTokenDuration()),
+		UserID:     user.ID,
+		TokenHash:  s.jwtManager.HashRefreshToken(refreshTokenStr),
+		ExpiresAt:  now.Add(s.jwtManager.GetRefreshTokenDuration()),
+		IPAddress:  getIPFromContext(ctx),
+		UserAgent:  getUserAgentFromContext(ctx),
+		LastUsedAt: &now,
 	}
 	if err := s.authRepo.CreateRefreshToken(ctx, refreshToken); err != nil {
 		return nil, fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
+	// Generate access token
+	accessToken, err := s.jwtManager.GenerateAccessToken(
+		user.ID,
+		user.Email,
+		user.IsSuperAdmin,
+		roleNames,
+		permissions,
+		refreshToken.FamilyID,
+		user.TokenVersion,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
 	// Update last login
 	if err := s.authRepo.UpdateLastLogin(ctx, user.ID); err != nil {
 		// Non-critical error, just log it
-		fmt.Printf("failed to update last login: %v\n", err)
+		s.log.WithError(err).WithField("user_id", user.ID).Warn("failed to update last login")
 	}
 
-	// Audit log
-	s.auditLog(ctx, &user.ID, "user.login.success", nil, nil, map[string]interface{}{
-		"email": req.Email,
-	})
-
 	// Build response
 	user.Roles = roles
 	userResp := user.ToUserResponse()
@@ -156,7 +237,9 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*model
 	}, nil
 }
 
-// RefreshToken refreshes an access token using a refresh token
+// RefreshToken rotates a refresh token: the presented token is revoked and a
+// new one is issued in the same family. Presenting an already-rotated token
+// means it leaked, so the whole family is revoked.
 func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string) (*model.RefreshTokenResponse, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "AuthService.RefreshToken")()
@@ -165,8 +248,8 @@ func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string)
 	// Hash the token
 	tokenHash := s.jwtManager.HashRefreshToken(refreshTokenStr)
 
-	// Get token from database
-	token, err := s.authRepo.GetRefreshTokenByHash(ctx, tokenHash)
+	// Get token from database (revoked ones too, to detect reuse)
+	token, err := s.authRepo.GetRefreshTokenByHashAny(ctx, tokenHash)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, core.Unauthorized("Invalid or expired refresh token")
@@ -174,8 +257,15 @@ func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string)
 		return nil, fmt.Errorf("failed to get refresh token: %w", err)
 	}
 
+	if token.RevokedAt != nil {
+		return nil, s.handleRevokedRefreshToken(ctx, token)
+	}
+	if !token.ExpiresAt.After(time.Now()) {
+		return nil, core.Unauthorized("Invalid or expired refresh token")
+	}
+
 	// Get user with permissions
-	user, permissions, err := s.authRepo.GetUserWithPermissions(ctx, token.UserID)
+	user, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, token.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -202,6 +292,8 @@ func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string)
 		user.IsSuperAdmin,
 		roleNames,
 		permissions,
+		token.FamilyID,
+		user.TokenVersion,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
@@ -213,23 +305,35 @@ func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string)
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Revoke old refresh token and save new one in transaction
+	// Save new token and revoke the old one in transaction
 	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
-		// Revoke old token
-		if err := s.authRepo.RevokeRefreshToken(txCtx, tokenHash); err != nil {
+		now := time.Now()
+		newToken := &model.RefreshToken{
+			UserID:     user.ID,
+			TokenHash:  s.jwtManager.HashRefreshToken(newRefreshTokenStr),
+			ExpiresAt:  now.Add(s.jwtManager.GetRefreshTokenDuration()),
+			FamilyID:   token.FamilyID,
+			IPAddress:  getIPFromContext(ctx),
+			UserAgent:  getUserAgentFromContext(ctx),
+			LastUsedAt: &now,
+		}
+		if err := s.authRepo.CreateRefreshToken(txCtx, newToken); err != nil {
 			return err
 		}
 
-		// Save new token
-		newToken := &model.RefreshToken{
-			UserID:    user.ID,
-			TokenHash: s.jwtManager.HashRefreshToken(newRefreshTokenStr),
-			ExpiresAt: time.Now().Add(s.jwtManager.GetRefreshTokenDuration()),
-		}
-		return s.authRepo.CreateRefreshToken(txCtx, newToken)
+		return s.authRepo.RotateRefreshToken(txCtx, token.ID, newToken.ID)
 	})
 
 	if err != nil {
+		// Lost a race with another refresh, a logout or a revoke; the
+		// current state tells which
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			current, getErr := s.authRepo.GetRefreshTokenByHashAny(ctx, tokenHash)
+			if getErr != nil {
+				return nil, fmt.Errorf("failed to get refresh token: %w", getErr)
+			}
+			return nil, s.handleRevokedRefreshToken(ctx, current)
+		}
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
 
@@ -241,6 +345,33 @@ func (s *authService) RefreshToken(ctx context.Context, refreshTokenStr string)
 	}, nil
 }
 
+// handleRevokedRefreshToken rejects a revoked refresh token. Only a token
+// that was rotated (ReplacedByID set) counts as reuse: a successor exists, so
+// someone else holds the family. Tokens revoked by logout, a password change
+// or a session revoke are a plain 401, as a client retrying after logout is
+// not an attack.
+func (s *authService) handleRevokedRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	if token.ReplacedByID == nil {
+		return core.Unauthorized("Invalid or expired refresh token")
+	}
+	return s.handleRefreshTokenReuse(ctx, token)
+}
+
+// handleRefreshTokenReuse revokes the family of a replayed refresh token and
+// records the incident
+func (s *authService) handleRefreshTokenReuse(ctx context.Context, token *model.RefreshToken) error {
+	if err := s.authRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+
+	s.auditLog(ctx, &token.UserID, "auth.refresh.reuse_detected", strPtr("refresh_tokens"), &token.ID, map[string]interface{}{
+		"family_id":  token.FamilyID,
+		"revoked_at": token.RevokedAt,
+	})
+
+	return core.Unauthorized("Invalid or expired refresh token")
+}
+
 // Logout logs out a user by revoking their refresh token
 func (s *authService) Logout(ctx context.Context, refreshTokenStr string) error {
 	if s.tracer != nil {
@@ -256,8 +387,8 @@ func (s *authService) Logout(ctx context.Context, refreshTokenStr string) error
 		return nil
 	}
 
-	// Revoke token
-	if err := s.authRepo.RevokeRefreshToken(ctx, tokenHash); err != nil {
+	// Revoke the whole family so rotated copies cannot outlive the session
+	if err := s.authRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
 		return fmt.Errorf("failed to revoke token: %w", err)
 	}
 
@@ -273,7 +404,7 @@ func (s *authService) GetCurrentUser(ctx context.Context, userID string) (*model
 		defer s.tracer.StartSegment(ctx, "AuthService.GetCurrentUser")()
 	}
 
-	user, permissions, err := s.authRepo.GetUserWithPermissions(ctx, userID)
+	user, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, core.NotFound("user", userID)
@@ -283,16 +414,22 @@ func (s *authService) GetCurrentUser(ctx context.Context, userID string) (*model
 
 	resp := user.ToUserResponse()
 	resp.Permissions = permissions
+	resp.PendingEmail = s.pendingEmail(ctx, userID)
 
 	return &resp, nil
 }
 
-// UpdateProfile updates current user's profile
+// UpdateProfile updates current user's profile. A new email is not applied
+// directly: it is staged until confirmed from a link sent to the new address.
 func (s *authService) UpdateProfile(ctx context.Context, userID string, req model.UpdateProfileRequest) (*model.UserResponse, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "AuthService.UpdateProfile")()
 	}
 
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
 	// Get existing user
 	user, err := s.authRepo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -302,40 +439,34 @@ func (s *authService) UpdateProfile(ctx context.Context, userID string, req mode
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Update fields if provided
-	if req.Email != nil {
-		// Check email uniqueness
-		existing, err := s.authRepo.GetUserByEmail(ctx, *req.Email)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("failed to check email: %w", err)
-		}
-		if existing != nil && existing.ID != userID {
-			return nil, core.Conflict("Email already exists")
-		}
-		user.Email = *req.Email
+	// Email changes need the password and go through RequestEmailChange;
+	// an unchanged email is ignored
+	if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+		return nil, core.BadRequest("Use POST /auth/me/email to change your email").WithDetail("field", "email")
 	}
+
+	// Update fields if provided
 	if req.FullName != nil {
 		user.FullName = *req.FullName
-	}
 
-	// Update user
-	if err := s.authRepo.UpdateUser(ctx, user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		if err := s.authRepo.UpdateUser(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+
+		s.auditLog(ctx, &userID, "user.profile.updated", strPtr("users"), &userID, map[string]interface{}{
+			"fields_updated": getUpdatedFields(req),
+		})
 	}
 
 	// Get updated user with permissions
-	updatedUser, permissions, err := s.authRepo.GetUserWithPermissions(ctx, userID)
+	updatedUser, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated user: %w", err)
 	}
 
 	resp := updatedUser.ToUserResponse()
 	resp.Permissions = permissions
-
-	// Audit log
-	s.auditLog(ctx, &userID, "user.profile.updated", strPtr("users"), &userID, map[string]interface{}{
-		"fields_updated": getUpdatedFields(req),
-	})
+	resp.PendingEmail = s.pendingEmail(ctx, userID)
 
 	return &resp, nil
 }
@@ -346,6 +477,10 @@ func (s *authService) ChangePassword(ctx context.Context, userID string, req mod
 		defer s.tracer.StartSegment(ctx, "AuthService.ChangePassword")()
 	}
 
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
 	// Get user
 	user, err := s.authRepo.GetUserByID(ctx, userID)
 	if err != nil {
@@ -368,6 +503,10 @@ func (s *authService) ChangePassword(ctx context.Context, userID string, req mod
 		return core.BadRequest("Invalid old password")
 	}
 
+	if err := checkPasswordPolicy(s.log, s.passwordPolicy, "new_password", req.NewPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
@@ -381,8 +520,8 @@ func (s *authService) ChangePassword(ctx context.Context, userID string, req mod
 			return err
 		}
 
-		// Revoke all refresh tokens (force re-login)
-		return s.authRepo.RevokeAllUserRefreshTokens(txCtx, userID)
+		// Revoke all tokens (force re-login)
+		return s.signOutEverywhere(txCtx, userID)
 	})
 
 	if err != nil {
@@ -407,6 +546,10 @@ func (s *authService) ResetPassword(ctx context.Context, userID string, newPassw
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	if err := checkPasswordPolicy(s.log, s.passwordPolicy, "new_password", newPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
 	// Hash new password
 	hashedPassword, err := auth.HashPassword(newPassword)
 	if err != nil {
@@ -420,8 +563,8 @@ func (s *authService) ResetPassword(ctx context.Context, userID string, newPassw
 			return err
 		}
 
-		// Revoke all refresh tokens
-		return s.authRepo.RevokeAllUserRefreshTokens(txCtx, userID)
+		// Revoke all tokens
+		return s.signOutEverywhere(txCtx, userID)
 	})
 
 	if err != nil {
@@ -443,6 +586,10 @@ func (s *authService) CreateUser(ctx context.Context, req model.CreateUserReques
 		defer s.tracer.StartSegment(ctx, "AuthService.CreateUser")()
 	}
 
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
 	// Check if email already exists
 	existing, err := s.authRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -452,6 +599,10 @@ func (s *authService) CreateUser(ctx context.Context, req model.CreateUserReques
 		return nil, core.Conflict("Email already exists")
 	}
 
+	if err := checkPasswordPolicy(s.log, s.passwordPolicy, "password", req.Password, req.Email, req.FullName); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -603,6 +754,13 @@ func (s *authService) UpdateUser(ctx context.Context, userID string, req model.U
 			return err
 		}
 
+		// Claims embedded in issued access tokens are now stale
+		if req.Email != nil || req.IsActive != nil || req.IsSuperAdmin != nil || req.RoleIDs != nil {
+			if err := s.authRepo.BumpTokenVersion(txCtx, userID); err != nil {
+				return err
+			}
+		}
+
 		// Update roles if provided
 		if req.RoleIDs != nil {
 			// Remove all existing roles
@@ -640,6 +798,9 @@ func (s *authService) UpdateUser(ctx context.Context, userID string, req model.U
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	if req.RoleIDs != nil {
+		dropCachedPermissions(ctx, s.log, s.permCache, userID)
+	}
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -674,6 +835,7 @@ func (s *authService) DeleteUser(ctx context.Context, userID string) error {
 	if err := s.authRepo.DeleteUser(ctx, userID); err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
+	dropCachedPermissions(ctx, s.log, s.permCache, userID)
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -700,9 +862,16 @@ func (s *authService) AssignRolesToUser(ctx context.Context, userID string, req
 	}
 
 	// Assign roles
-	if err := s.authRepo.AssignRolesToUser(ctx, userID, req.RoleIDs, &grantedBy); err != nil {
+	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.authRepo.AssignRolesToUser(txCtx, userID, req.RoleIDs, &grantedBy); err != nil {
+			return err
+		}
+		return s.authRepo.BumpTokenVersion(txCtx, userID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to assign roles: %w", err)
 	}
+	dropCachedPermissions(ctx, s.log, s.permCache, userID)
 
 	// Audit log
 	s.auditLog(ctx, &grantedBy, "user.roles.assign", strPtr("users"), &userID, map[string]interface{}{
@@ -728,9 +897,16 @@ func (s *authService) RemoveRolesFromUser(ctx context.Context, userID string, ro
 	}
 
 	// Remove roles
-	if err := s.authRepo.RemoveRolesFromUser(ctx, userID, roleIDs); err != nil {
+	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.authRepo.RemoveRolesFromUser(txCtx, userID, roleIDs); err != nil {
+			return err
+		}
+		return s.authRepo.BumpTokenVersion(txCtx, userID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to remove roles: %w", err)
 	}
+	dropCachedPermissions(ctx, s.log, s.permCache, userID)
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -741,16 +917,27 @@ func (s *authService) RemoveRolesFromUser(ctx context.Context, userID string, ro
 	return nil
 }
 
+// signOutEverywhere revokes all refresh tokens of a user and invalidates
+// access tokens already issued
+func (s *authService) signOutEverywhere(ctx context.Context, userID string) error {
+	if err := s.authRepo.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.authRepo.BumpTokenVersion(ctx, userID)
+}
+
 // Helper: audit logging
 func (s *authService) auditLog(ctx context.Context, userID *string, action string, resourceType *string, resourceID *string, metadata map[string]interface{}) {
 	log := &model.AuditLog{
-		UserID:       userID,
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		Metadata:     metadata,
-		IPAddress:    getIPFromContext(ctx),
-		UserAgent:    getUserAgentFromContext(ctx),
+		UserID:         userID,
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Metadata:       metadata,
+		IPAddress:      getIPFromContext(ctx),
+		UserAgent:      getUserAgentFromContext(ctx),
+		APITokenID:     getAPITokenIDFromContext(ctx),
+		ImpersonatorID: getImpersonatorIDFromContext(ctx),
 	}
 
 	// Non-blocking audit log (fire and forget)
@@ -772,12 +959,30 @@ func getUserIDFromContext(ctx context.Context) *string {
 }
 
 func getIPFromContext(ctx context.Context) *string {
-	// TODO: implement IP extraction from context
+	if ip := clientinfo.FromContext(ctx).IP; ip != "" {
+		return &ip
+	}
 	return nil
 }
 
 func getUserAgentFromContext(ctx context.Context) *string {
-	// TODO: implement user agent extraction from context
+	if ua := clientinfo.FromContext(ctx).UserAgent; ua != "" {
+		return &ua
+	}
+	return nil
+}
+
+func getAPITokenIDFromContext(ctx context.Context) *string {
+	if id := auth.APITokenIDFromContext(ctx); id != "" {
+		return &id
+	}
+	return nil
+}
+
+func getImpersonatorIDFromContext(ctx context.Context) *string {
+	if id := auth.ImpersonatorIDFromContext(ctx); id != "" {
+		return &id
+	}
 	return nil
 }
 
@@ -786,7 +991,7 @@ func (s *authService) HandleOAuthCallback(
 	ctx context.Context,
 	provider, providerID, email, fullName string,
 	providerData map[string]interface{},
-) (*model.LoginResponse, error) {
+) (*model.LoginResponse, *model.MFAChallengeResponse, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "AuthService.HandleOAuthCallback")()
 	}
@@ -797,7 +1002,7 @@ func (s *authService) HandleOAuthCallback(
 	// Try to find existing OAuth account
 	existingUser, err := s.authRepo.GetUserByOAuth(ctx, provider, providerID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("failed to check existing OAuth account: %w", err)
+		return nil, nil, fmt.Errorf("failed to check existing OAuth account: %w", err)
 	}
 
 	if existingUser != nil {
@@ -806,42 +1011,19 @@ func (s *authService) HandleOAuthCallback(
 
 		// Update last login
 		if err := s.authRepo.UpdateLastLogin(ctx, user.ID); err != nil {
-			return nil, fmt.Errorf("failed to update last login: %w", err)
+			return nil, nil, fmt.Errorf("failed to update last login: %w", err)
 		}
 	} else {
 		// Check if user exists by email
 		existingUserByEmail, err := s.authRepo.GetUserByEmail(ctx, email)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("failed to check existing user by email: %w", err)
+			return nil, nil, fmt.Errorf("failed to check existing user by email: %w", err)
 		}
 
 		if existingUserByEmail != nil {
-			// User exists with this email, link OAuth account
-			user = existingUserByEmail
-
-			// Create OAuth account linkage
-			oauthAccount := &model.OAuthAccount{
-				UserID:       user.ID,
-				Provider:     provider,
-				ProviderID:   providerID,
-				ProviderData: providerData,
-			}
-
-			if err := s.authRepo.CreateOAuthAccount(ctx, oauthAccount); err != nil {
-				return nil, fmt.Errorf("failed to link OAuth account: %w", err)
-			}
-
-			// Update last login
-			if err := s.authRepo.UpdateLastLogin(ctx, user.ID); err != nil {
-				return nil, fmt.Errorf("failed to update last login: %w", err)
-			}
-
-			// Audit log
-			s.auditLog(ctx, &user.ID, "oauth.link", strPtr("oauth_accounts"), nil, map[string]interface{}{
-				"provider":    provider,
-				"provider_id": providerID,
-				"email":       email,
-			})
+			// User exists with this email: never link silently, the owner
+			// must sign in with an existing method and confirm
+			return nil, nil, s.oauthLinkRequired(ctx, existingUserByEmail, provider, providerID, providerData)
 		} else {
 			// New user - create account
 			isNewUser = true
@@ -890,7 +1072,7 @@ func (s *authService) HandleOAuthCallback(
 			})
 
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			// Audit log
@@ -910,58 +1092,21 @@ func (s *authService) HandleOAuthCallback(
 			"email":    email,
 			"reason":   "user not active",
 		})
-		return nil, core.Forbidden("Account is inactive")
+		return nil, nil, core.Forbidden("Account is inactive")
 	}
 
-	// Get user permissions
-	_, permissions, err := s.authRepo.GetUserWithPermissions(ctx, user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user permissions: %w", err)
-	}
-
-	// Get role names
-	roles, err := s.authRepo.GetUserRoles(ctx, user.ID)
+	// Second factor
+	challenge, err := s.mfaChallenge(ctx, user, "oauth:"+provider)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user roles: %w", err)
+		return nil, nil, err
 	}
-	roleNames := make([]string, len(roles))
-	for i, role := range roles {
-		roleNames[i] = role.Name
+	if challenge != nil {
+		return nil, challenge, nil
 	}
 
-	// Generate access token
-	accessToken, err := s.jwtManager.GenerateAccessToken(
-		user.ID,
-		user.Email,
-		user.IsSuperAdmin,
-		roleNames,
-		permissions,
-	)
+	response, err := s.issueLogin(ctx, user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
-	}
-
-	// Generate refresh token
-	refreshTokenStr, err := s.jwtManager.GenerateRefreshToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
-	}
-
-	// Store refresh token
-	refreshToken := &model.RefreshToken{
-		UserID:    user.ID,
-		TokenHash: s.jwtManager.HashRefreshToken(refreshTokenStr),
-		ExpiresAt: time.Now().Add(s.jwtManager.GetRefreshTokenDuration()),
-	}
-	if err := s.authRepo.CreateRefreshToken(ctx, refreshToken); err != nil {
-		return nil, fmt.Errorf("failed to store refresh token: %w", err)
-	}
-
-	// Update last login
-	if !isNewUser { // already updated for new users
-		if err := s.authRepo.UpdateLastLogin(ctx, user.ID); err != nil {
-			return nil, fmt.Errorf("failed to update last login: %w", err)
-		}
+		return nil, nil, err
 	}
 
 	// Audit log
@@ -971,47 +1116,13 @@ func (s *authService) HandleOAuthCallback(
 		"is_new":   isNewUser,
 	})
 
-	// Build response
-	response := &model.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenStr,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(s.jwtManager.GetAccessTokenDuration().Seconds()),
-		User: model.UserResponse{
-			ID:           user.ID,
-			Email:        user.Email,
-			FullName:     user.FullName,
-			IsActive:     user.IsActive,
-			IsSuperAdmin: user.IsSuperAdmin,
-			LastLoginAt:  user.LastLoginAt,
-			CreatedAt:    user.CreatedAt,
-			UpdatedAt:    user.UpdatedAt,
-			Roles:        make([]model.RoleResponse, len(roles)),
-			Permissions:  permissions,
-		},
-	}
-
-	// Map roles
-	for i, role := range roles {
-		response.User.Roles[i] = model.RoleResponse{
-			ID:          role.ID,
-			Name:        role.Name,
-			Description: role.Description,
-			IsSystem:    role.IsSystem,
-			CreatedAt:   role.CreatedAt,
-			UpdatedAt:   role.UpdatedAt,
-		}
-	}
-
-	return response, nil
+	return response, nil, nil
 }
 
-// getUpdatedFields returns list of updated fields from request
+// getUpdatedFields returns list of updated fields from request. Email is
+// audited separately since it only changes once confirmed.
 func getUpdatedFields(req model.UpdateProfileRequest) []string {
 	fields := []string{}
-	if req.Email != nil {
-		fields = append(fields, "email")
-	}
 	if req.FullName != nil {
 		fields = append(fields, "full_name")
 	}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/mailer"
+	"be-itts-community/pkg/validator"
+)
+
+// RequestEmailChange stages a new email for the user once they re-enter
+// their password. A confirmation link goes to the new address and a notice
+// with a cancel link to the current one; only the most recent request stays
+// valid.
+func (s *authService) RequestEmailChange(ctx context.Context, userID string, req model.ChangeEmailRequest, confirmURL, cancelURL string) (*model.UserResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.RequestEmailChange")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+	if s.outbox == nil || confirmURL == "" || cancelURL == "" {
+		return nil, core.ServiceUnavailable("Email change is not configured")
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// A stolen session alone must not be enough to move the account
+	if user.PasswordHash == nil {
+		return nil, core.BadRequest("Set a password with password reset before changing your email")
+	}
+	if err := auth.CheckPassword(*user.PasswordHash, req.CurrentPassword); err != nil {
+		s.auditLog(ctx, &userID, "user.email_change.failed", strPtr("users"), &userID, map[string]interface{}{
+			"new_email": req.NewEmail,
+			"reason":    "invalid password",
+		})
+		return nil, core.BadRequest("Invalid current password")
+	}
+
+	if strings.EqualFold(req.NewEmail, user.Email) {
+		return nil, core.BadRequest("New email is the same as the current one")
+	}
+	if err := s.stageEmailChange(ctx, user, req.NewEmail, confirmURL, cancelURL); err != nil {
+		return nil, err
+	}
+
+	updatedUser, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get updated user: %w", err)
+	}
+	resp := updatedUser.ToUserResponse()
+	resp.Permissions = permissions
+	resp.PendingEmail = s.pendingEmail(ctx, userID)
+
+	return &resp, nil
+}
+
+// stageEmailChange records the change and queues both emails
+func (s *authService) stageEmailChange(ctx context.Context, user *model.User, newEmail, confirmURL, cancelURL string) error {
+	existing, err := s.authRepo.GetUserByEmail(ctx, newEmail)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	if existing != nil {
+		return core.Conflict("Email already exists")
+	}
+
+	rawToken, tokenHash, err := generateToken()
+	if err != nil {
+		return core.InternalServerError("failed to generate confirmation link").WithError(err)
+	}
+	rawCancelToken, cancelTokenHash, err := generateToken()
+	if err != nil {
+		return core.InternalServerError("failed to generate cancel link").WithError(err)
+	}
+
+	now := time.Now()
+	expiresIn := fmt.Sprintf("%d hours", int(s.emailChangeTTL.Hours()))
+	err = s.emailChangeRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.emailChangeRepo.InvalidateForUser(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		change := &model.EmailChange{
+			UserID:    user.ID,
+			OldEmail:  user.Email,
+			NewEmail:  newEmail,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(s.emailChangeTTL),
+
+			CancelTokenHash: &cancelTokenHash,
+		}
+		if err := s.emailChangeRepo.Create(txCtx, change); err != nil {
+			return err
+		}
+
+		body, err := mailer.RenderEmailChangeConfirmEmail(user.FullName, newEmail, fmt.Sprintf("%s?token=%s", confirmURL, rawToken), expiresIn)
+		if err != nil {
+			return fmt.Errorf("failed to render email change confirmation: %w", err)
+		}
+		if err := s.outbox.Enqueue(txCtx, newEmail, "Confirm Your New Email - ITTS Community", body); err != nil {
+			return err
+		}
+
+		body, err = mailer.RenderEmailChangeNoticeEmail(user.FullName, newEmail, fmt.Sprintf("%s?token=%s", cancelURL, rawCancelToken), expiresIn)
+		if err != nil {
+			return fmt.Errorf("failed to render email change notice: %w", err)
+		}
+		return s.outbox.Enqueue(txCtx, user.Email, "Email Change Requested - ITTS Community", body)
+	})
+	if err != nil {
+		return core.InternalServerError("failed to request email change").WithError(err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.email_change.requested", strPtr("users"), &user.ID, map[string]interface{}{
+		"old_email":  user.Email,
+		"new_email":  newEmail,
+		"expires_at": now.Add(s.emailChangeTTL),
+	})
+
+	return nil
+}
+
+// ConfirmEmailChange applies a staged email change. Access tokens carrying
+// the old email are invalidated; refresh tokens keep working.
+func (s *authService) ConfirmEmailChange(ctx context.Context, req model.ConfirmEmailChangeRequest) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ConfirmEmailChange")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
+	sum := sha256.Sum256([]byte(req.Token))
+	change, err := s.emailChangeRepo.FindValidByHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.Unauthorized("Invalid or expired confirmation link")
+		}
+		return fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		// Single use; a concurrent second use loses here
+		if err := s.emailChangeRepo.MarkConfirmed(txCtx, change.ID, time.Now()); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.Unauthorized("Invalid or expired confirmation link")
+			}
+			return fmt.Errorf("failed to confirm email change: %w", err)
+		}
+
+		user, err := s.authRepo.LockUser(txCtx, change.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.NotFound("user", change.UserID)
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		// The email was changed another way since the request
+		if !strings.EqualFold(user.Email, change.OldEmail) {
+			return core.Conflict("Email has changed since this request was made")
+		}
+
+		existing, err := s.authRepo.GetUserByEmail(txCtx, change.NewEmail)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check email: %w", err)
+		}
+		if existing != nil && existing.ID != user.ID {
+			return core.Conflict("Email already exists")
+		}
+
+		user.Email = change.NewEmail
+		if err := s.authRepo.UpdateUser(txCtx, user); err != nil {
+			return fmt.Errorf("failed to update email: %w", err)
+		}
+		return s.authRepo.BumpTokenVersion(txCtx, user.ID)
+	})
+	if err != nil {
+		s.auditLog(ctx, &change.UserID, "user.email_change.failed", strPtr("users"), &change.UserID, map[string]interface{}{
+			"old_email": change.OldEmail,
+			"new_email": change.NewEmail,
+			"reason":    err.Error(),
+		})
+		return err
+	}
+
+	s.auditLog(ctx, &change.UserID, "user.email_change.confirmed", strPtr("users"), &change.UserID, map[string]interface{}{
+		"old_email": change.OldEmail,
+		"new_email": change.NewEmail,
+	})
+
+	return nil
+}
+
+// CancelEmailChange withdraws a pending email change from the link sent to
+// the current address
+func (s *authService) CancelEmailChange(ctx context.Context, req model.CancelEmailChangeRequest) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.CancelEmailChange")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
+	sum := sha256.Sum256([]byte(req.Token))
+	change, err := s.emailChangeRepo.FindCancellableByHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.Unauthorized("Invalid or expired cancel link")
+		}
+		return fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	if err := s.emailChangeRepo.MarkCancelled(ctx, change.ID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.Conflict("Email change was already confirmed or cancelled")
+		}
+		return fmt.Errorf("failed to cancel email change: %w", err)
+	}
+
+	s.auditLog(ctx, &change.UserID, "user.email_change.cancelled", strPtr("users"), &change.UserID, map[string]interface{}{
+		"old_email": change.OldEmail,
+		"new_email": change.NewEmail,
+	})
+
+	return nil
+}
+
+// pendingEmail returns the email awaiting confirmation, if any
+func (s *authService) pendingEmail(ctx context.Context, userID string) *string {
+	change, err := s.emailChangeRepo.FindPendingByUser(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.log.WithError(err).WithField("user_id", userID).Warn("failed to get pending email change")
+		}
+		return nil
+	}
+	return &change.NewEmail
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+)
+
+// Reasons of a PermissionExplanation
+const (
+	explainInactive   = "inactive"
+	explainSuperAdmin = "super_admin"
+	explainGranted    = "granted"
+	explainDenied     = "denied"
+	explainNoGrant    = "no_grant"
+)
+
+// ExplainPermission tells whether RequirePermission lets userID through for
+// permission and lists every role path that grants or denies it. Paths from
+// expired assignments are listed as inactive so admins can see a grant that
+// lapsed. A deactivated user is never allowed, as authentication rejects them
+// before any permission check. The decision is made on the current roles,
+// which tokens issued before a change may not reflect yet.
+func (s *authService) ExplainPermission(ctx context.Context, userID, permission string) (*model.PermissionExplanation, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ExplainPermission")()
+	}
+
+	permission = strings.TrimSpace(permission)
+	if _, _, ok := model.SplitPermission(permission); !ok || strings.Contains(permission, model.PermissionScopeSeparator) {
+		return nil, core.BadRequest("permission must be in the form resource:action").WithDetail("permission", permission)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	assignments, err := s.permissionRepo.GetUserRoleAssignments(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+
+	// Role chains per assignment: the assigned role, then its parents
+	chains := make([][]model.Role, len(assignments))
+	ancestors := make(map[string][]model.Role)
+	roleIDs := make([]string, 0, len(assignments))
+	seen := make(map[string]bool)
+	for i, assignment := range assignments {
+		parents, ok := ancestors[assignment.RoleID]
+		if !ok {
+			parents, err = s.permissionRepo.GetRoleAncestors(ctx, assignment.RoleID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent roles: %w", err)
+			}
+			ancestors[assignment.RoleID] = parents
+		}
+		chains[i] = append([]model.Role{assignment.Role}, parents...)
+		for _, role := range chains[i] {
+			if !seen[role.ID] {
+				seen[role.ID] = true
+				roleIDs = append(roleIDs, role.ID)
+			}
+		}
+	}
+
+	grants, err := s.permissionRepo.GetRoleGrantsForPermissions(ctx, roleIDs, model.PermissionCandidates(permission))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
+	byRole := make(map[string][]model.RolePermission)
+	for _, grant := range grants {
+		byRole[grant.RoleID] = append(byRole[grant.RoleID], grant)
+	}
+
+	explanation := &model.PermissionExplanation{
+		UserID:     userID,
+		Permission: permission,
+		SuperAdmin: user.IsSuperAdmin,
+		Paths:      []model.PermissionPath{},
+	}
+
+	// The same entries a fresh token would carry, for the decision below
+	var active []string
+	now := time.Now()
+	for i, assignment := range assignments {
+		expired := assignment.ExpiresAt != nil && !assignment.ExpiresAt.After(now)
+		via := make([]string, 0, len(chains[i]))
+		for depth, role := range chains[i] {
+			via = append(via, role.Name)
+			for _, grant := range byRole[role.ID] {
+				entry := model.ScopedPermission(grant.Permission.Name, grant.Scope)
+				if grant.Effect == model.PermissionDeny {
+					entry = model.DeniedPermission(grant.Permission.Name)
+				}
+				explanation.Paths = append(explanation.Paths, model.PermissionPath{
+					Via:       append([]string(nil), via...),
+					Role:      role.Name,
+					RoleID:    role.ID,
+					Grant:     entry,
+					Effect:    grant.Effect,
+					Scope:     grant.Scope,
+					Inherited: depth > 0,
+					Active:    !expired,
+					ExpiresAt: assignment.ExpiresAt,
+				})
+				if !expired {
+					active = append(active, entry)
+				}
+			}
+		}
+	}
+
+	authCtx := model.AuthContext{UserID: userID, IsSuperAdmin: user.IsSuperAdmin, Permissions: active}
+	explanation.Allowed = user.IsActive && authCtx.HasPermission(permission)
+	switch {
+	case !user.IsActive:
+		explanation.Reason = explainInactive
+	case user.IsSuperAdmin:
+		explanation.Reason = explainSuperAdmin
+	case model.PermissionDenied(active, permission):
+		explanation.Reason = explainDenied
+	case explanation.Allowed:
+		explanation.Reason = explainGranted
+	default:
+		explanation.Reason = explainNoGrant
+	}
+
+	return explanation, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+)
+
+// OAuthLinkRequiredCode is the error code returned by HandleOAuthCallback when
+// the provider email belongs to an existing user. The error carries a
+// link_token the user confirms after signing in with an existing method.
+const OAuthLinkRequiredCode = "OAUTH_LINK_REQUIRED"
+
+// ListIdentities lists the current user's login methods
+func (s *authService) ListIdentities(ctx context.Context, userID string) (*model.IdentitiesResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ListIdentities")()
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	accounts, err := s.authRepo.ListUserOAuthAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", err)
+	}
+
+	resp := &model.IdentitiesResponse{
+		HasPassword: user.PasswordHash != nil,
+		Identities:  make([]model.IdentityResponse, 0, len(accounts)),
+	}
+	for i := range accounts {
+		resp.Identities = append(resp.Identities, accounts[i].ToIdentityResponse())
+	}
+	return resp, nil
+}
+
+// StartIdentityLink returns a link token for a provider account signed in to
+// from a link flow. Nothing is linked until the signed-in user confirms the
+// token, so a flow started from another session cannot attach the provider
+// account to the wrong user.
+func (s *authService) StartIdentityLink(ctx context.Context, userID, provider, providerID string, providerData map[string]interface{}) (string, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.StartIdentityLink")()
+	}
+
+	return s.pendingOAuthLink(ctx, userID, provider, providerID, "explicit", providerData)
+}
+
+// ConfirmIdentityLink links the provider account from a pending link token
+// to the current user. Only the user the email matched may confirm.
+func (s *authService) ConfirmIdentityLink(ctx context.Context, userID string, req model.ConfirmIdentityLinkRequest) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ConfirmIdentityLink")()
+	}
+
+	claims, err := s.jwtManager.VerifyOAuthLinkToken(req.LinkToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrExpiredToken) {
+			return core.Unauthorized("Link request has expired, please sign in with the provider again")
+		}
+		return core.Unauthorized("Invalid link token")
+	}
+	if claims.Subject != userID {
+		return core.Forbidden("This link request belongs to another account")
+	}
+
+	method := claims.Method
+	if method == "" {
+		method = "email_confirmed"
+	}
+	return s.linkOAuthAccount(ctx, userID, claims.Provider, claims.ProviderID, claims.ProviderData, method)
+}
+
+// UnlinkIdentity removes a linked provider account. A user without a
+// password must keep at least one provider to be able to sign in.
+func (s *authService) UnlinkIdentity(ctx context.Context, userID, identityID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.UnlinkIdentity")()
+	}
+
+	var provider string
+	err := s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		user, err := s.authRepo.LockUser(txCtx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.NotFound("user", userID)
+			}
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		accounts, err := s.authRepo.ListUserOAuthAccounts(txCtx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to list identities: %w", err)
+		}
+
+		found := false
+		for _, a := range accounts {
+			if a.ID == identityID {
+				found = true
+				provider = a.Provider
+			}
+		}
+		if !found {
+			return core.NotFound("identity", identityID)
+		}
+
+		if user.PasswordHash == nil && len(accounts) <= 1 {
+			return core.Conflict("Cannot remove your last login method; set a password first")
+		}
+
+		if err := s.authRepo.DeleteOAuthAccount(txCtx, userID, identityID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.NotFound("identity", identityID)
+			}
+			return fmt.Errorf("failed to unlink identity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditLog(ctx, &userID, "oauth.unlink", strPtr("oauth_accounts"), &identityID, map[string]interface{}{
+		"provider": provider,
+	})
+
+	return nil
+}
+
+// linkOAuthAccount attaches a provider account to userID. Linking an
+// account that already belongs to the user only refreshes its data.
+func (s *authService) linkOAuthAccount(ctx context.Context, userID, provider, providerID string, providerData map[string]interface{}, method string) error {
+	existing, err := s.authRepo.GetOAuthAccount(ctx, provider, providerID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check existing OAuth account: %w", err)
+	}
+
+	if existing != nil {
+		if existing.UserID != userID {
+			return core.Conflict("This provider account is already linked to another user")
+		}
+		existing.ProviderData = providerData
+		if err := s.authRepo.UpdateOAuthAccount(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update OAuth account: %w", err)
+		}
+		return nil
+	}
+
+	account := &model.OAuthAccount{
+		UserID:       userID,
+		Provider:     provider,
+		ProviderID:   providerID,
+		ProviderData: providerData,
+	}
+	if err := s.authRepo.CreateOAuthAccount(ctx, account); err != nil {
+		return fmt.Errorf("failed to link OAuth account: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "oauth.link", strPtr("oauth_accounts"), &account.ID, map[string]interface{}{
+		"provider":    provider,
+		"provider_id": providerID,
+		"method":      method,
+	})
+
+	return nil
+}
+
+// oauthLinkRequired asks the owner of an existing account to confirm linking
+// a provider account that matched their email
+func (s *authService) oauthLinkRequired(ctx context.Context, user *model.User, provider, providerID string, providerData map[string]interface{}) error {
+	linkToken, err := s.pendingOAuthLink(ctx, user.ID, provider, providerID, "email_confirmed", providerData)
+	if err != nil {
+		return err
+	}
+
+	return core.NewAppError(http.StatusConflict, OAuthLinkRequiredCode, "An account with this email already exists; sign in to link this provider").
+		WithDetail("link_token", linkToken).
+		WithDetail("provider", provider).
+		WithDetail("expires_in", int64(auth.OAuthLinkDuration.Seconds()))
+}
+
+// pendingOAuthLink issues a link token for userID to confirm through
+// ConfirmIdentityLink
+func (s *authService) pendingOAuthLink(ctx context.Context, userID, provider, providerID, method string, providerData map[string]interface{}) (string, error) {
+	linkToken, err := s.jwtManager.GenerateOAuthLinkToken(userID, provider, providerID, method, providerData)
+	if err != nil {
+		return "", core.InternalServerError("failed to generate link token").WithError(err)
+	}
+
+	s.auditLog(ctx, &userID, "oauth.link.pending", strPtr("oauth_accounts"), nil, map[string]interface{}{
+		"provider":    provider,
+		"provider_id": providerID,
+		"method":      method,
+	})
+
+	return linkToken, nil
+}
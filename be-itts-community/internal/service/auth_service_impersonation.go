@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/validator"
+)
+
+// Impersonate issues a short-lived access token that lets actor act as the
+// target user. The token carries both identities; requests made with it are
+// audited under the target with the actor as impersonator.
+func (s *authService) Impersonate(ctx context.Context, actor *model.AuthContext, targetID string, req model.ImpersonateRequest) (*model.ImpersonationResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.Impersonate")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+	if actor.ImpersonatorID != "" || actor.APITokenID != "" {
+		return nil, core.Forbidden("Impersonation must be started from your own session")
+	}
+	if actor.UserID == targetID {
+		return nil, core.BadRequest("You cannot impersonate yourself")
+	}
+
+	actorUser, actorPermissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get actor: %w", err)
+	}
+
+	target, permissions, err := userWithPermissions(ctx, s.authRepo, s.permissionRepo, s.permCache, targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", targetID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	deny := func(reason string) error {
+		s.auditLog(ctx, &actor.UserID, "user.impersonation.denied", strPtr("users"), &targetID, map[string]interface{}{
+			"target_email": target.Email,
+			"reason":       reason,
+		})
+		return core.Forbidden(reason)
+	}
+	if target.IsSuperAdmin {
+		return nil, deny("Super admins cannot be impersonated")
+	}
+	if !target.IsActive {
+		return nil, deny("Inactive users cannot be impersonated")
+	}
+	// Acting as someone who can do more would be an escalation
+	if !actorUser.IsSuperAdmin && len(model.PermissionsBeyond(permissions, actorPermissions)) > 0 {
+		return nil, deny("You cannot impersonate a user with permissions you do not hold")
+	}
+
+	roles, err := s.authRepo.GetUserRoles(ctx, target.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	roleNames := make([]string, len(roles))
+	for i, role := range roles {
+		roleNames[i] = role.Name
+	}
+
+	accessToken, err := s.jwtManager.GenerateImpersonationToken(
+		target.ID,
+		target.Email,
+		roleNames,
+		permissions,
+		target.TokenVersion,
+		auth.Actor{
+			UserID:       actorUser.ID,
+			Email:        actorUser.Email,
+			TokenVersion: actorUser.TokenVersion,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+
+	s.auditLog(ctx, &actor.UserID, "user.impersonation.started", strPtr("users"), &target.ID, map[string]interface{}{
+		"target_email": target.Email,
+		"reason":       req.Reason,
+		"expires_at":   time.Now().Add(auth.ImpersonationDuration),
+	})
+
+	target.Roles = roles
+	userResp := target.ToUserResponse()
+	userResp.Permissions = permissions
+
+	return &model.ImpersonationResponse{
+		AccessToken:    accessToken,
+		TokenType:      "Bearer",
+		ExpiresIn:      int64(auth.ImpersonationDuration.Seconds()),
+		User:           userResp,
+		ImpersonatorID: actorUser.ID,
+	}, nil
+}
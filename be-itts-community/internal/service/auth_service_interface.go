@@ -9,20 +9,53 @@ import (
 // AuthService handles authentication operations
 type AuthService interface {
 	// Authentication
-	Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, error)
+	Login(ctx context.Context, req model.LoginRequest) (*model.LoginResponse, *model.MFAChallengeResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*model.RefreshTokenResponse, error)
 	Logout(ctx context.Context, refreshToken string) error
 	GetCurrentUser(ctx context.Context, userID string) (*model.UserResponse, error)
 
+	// Passwordless Authentication
+	RequestMagicLink(ctx context.Context, req model.MagicLinkRequest, loginURL string) error
+	ConsumeMagicLink(ctx context.Context, req model.MagicLinkConsumeRequest) (*model.LoginResponse, *model.MFAChallengeResponse, error)
+
 	// OAuth Authentication
-	HandleOAuthCallback(ctx context.Context, provider, providerID, email, fullName string, providerData map[string]interface{}) (*model.LoginResponse, error)
+	HandleOAuthCallback(ctx context.Context, provider, providerID, email, fullName string, providerData map[string]interface{}) (*model.LoginResponse, *model.MFAChallengeResponse, error)
+
+	// Linked Identities
+	ListIdentities(ctx context.Context, userID string) (*model.IdentitiesResponse, error)
+	StartIdentityLink(ctx context.Context, userID, provider, providerID string, providerData map[string]interface{}) (string, error)
+	ConfirmIdentityLink(ctx context.Context, userID string, req model.ConfirmIdentityLinkRequest) error
+	UnlinkIdentity(ctx context.Context, userID, identityID string) error
+
+	// Multi-factor Authentication
+	VerifyMFA(ctx context.Context, req model.MFAVerifyRequest) (*model.LoginResponse, error)
+	BeginMFASetup(ctx context.Context, req model.MFASetupRequest) (*model.MFAEnrollResponse, error)
+	CompleteMFASetup(ctx context.Context, req model.MFASetupConfirmRequest) (*model.MFASetupConfirmResponse, error)
+	EnrollMFA(ctx context.Context, userID string) (*model.MFAEnrollResponse, error)
+	ActivateMFA(ctx context.Context, userID string, req model.MFAActivateRequest) (*model.MFARecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, userID string, req model.MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID string, req model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error)
+	AdminResetMFA(ctx context.Context, userID string) error
+
+	// Session Management
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]model.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error
+	AdminListUserSessions(ctx context.Context, userID string) ([]model.SessionResponse, error)
+	AdminRevokeUserSession(ctx context.Context, userID, sessionID string) error
+	AdminRevokeAllUserSessions(ctx context.Context, userID string) error
 
 	// Profile Management
 	UpdateProfile(ctx context.Context, userID string, req model.UpdateProfileRequest) (*model.UserResponse, error)
+	RequestEmailChange(ctx context.Context, userID string, req model.ChangeEmailRequest, confirmURL, cancelURL string) (*model.UserResponse, error)
+	ConfirmEmailChange(ctx context.Context, req model.ConfirmEmailChangeRequest) error
+	CancelEmailChange(ctx context.Context, req model.CancelEmailChangeRequest) error
 
 	// Password Management
 	ChangePassword(ctx context.Context, userID string, req model.ChangePasswordRequest) error
 	ResetPassword(ctx context.Context, userID string, newPassword string) error
+	ForgotPassword(ctx context.Context, req model.ForgotPasswordRequest, resetURL string) error
+	ResetPasswordWithToken(ctx context.Context, req model.ResetPasswordWithTokenRequest) error
 
 	// User Management (Admin)
 	CreateUser(ctx context.Context, req model.CreateUserRequest, createdBy string) (*model.UserResponse, error)
@@ -31,6 +64,15 @@ type AuthService interface {
 	UpdateUser(ctx context.Context, userID string, req model.UpdateUserRequest) (*model.UserResponse, error)
 	DeleteUser(ctx context.Context, userID string) error
 
+	// Login Protection (Admin)
+	UnlockUser(ctx context.Context, userID string) error
+
+	// Permission Diagnostics (Admin)
+	ExplainPermission(ctx context.Context, userID, permission string) (*model.PermissionExplanation, error)
+
+	// Impersonation (Admin)
+	Impersonate(ctx context.Context, actor *model.AuthContext, targetID string, req model.ImpersonateRequest) (*model.ImpersonationResponse, error)
+
 	// Role Assignment
 	AssignRolesToUser(ctx context.Context, userID string, req model.AssignRoleRequest, grantedBy string) error
 	RemoveRolesFromUser(ctx context.Context, userID string, roleIDs []string) error
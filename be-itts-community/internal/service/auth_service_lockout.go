@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/attempt"
+	"be-itts-community/pkg/clientinfo"
+	"be-itts-community/pkg/mailer"
+)
+
+// LoginProtection configures brute-force protection for password login.
+// Zero values fall back to the defaults below.
+type LoginProtection struct {
+	Store              attempt.Store // in-memory if nil
+	MaxAccountFailures int           // failures per account before lockout (default 5)
+	MaxIPFailures      int           // failures per client IP before lockout (default 20)
+	Window             time.Duration // period in which failures are counted (default 15m)
+	LockoutDuration    time.Duration // how long a lockout lasts (default 15m)
+	FreeFailures       int           // failures allowed before responses are delayed (default 2)
+	BaseDelay          time.Duration // first delay, doubled per further failure (default 500ms)
+	MaxDelay           time.Duration // delay cap (default 8s)
+	ResetPasswordURL   string        // linked from the lockout email
+	MaxMagicLinks      int           // magic link emails per address per Window (default 3)
+}
+
+func (p LoginProtection) withDefaults() LoginProtection {
+	if p.Store == nil {
+		p.Store = attempt.NewMemoryStore()
+	}
+	if p.MaxAccountFailures <= 0 {
+		p.MaxAccountFailures = 5
+	}
+	if p.MaxIPFailures <= 0 {
+		p.MaxIPFailures = 20
+	}
+	if p.Window <= 0 {
+		p.Window = 15 * time.Minute
+	}
+	if p.LockoutDuration <= 0 {
+		p.LockoutDuration = 15 * time.Minute
+	}
+	if p.FreeFailures <= 0 {
+		p.FreeFailures = 2
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 8 * time.Second
+	}
+	if p.MaxMagicLinks <= 0 {
+		p.MaxMagicLinks = 3
+	}
+	return p
+}
+
+// Keys are derived from the submitted email rather than the user ID so
+// unknown emails are throttled the same way and do not reveal existence
+func loginAccountKey(email string) string {
+	return "login:account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginIPKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// loginUnlockedKey marks an account an admin unlocked; while set, IP locks
+// do not apply to it so the unlock takes effect even from a locked network
+func loginUnlockedKey(email string) string {
+	return "login:unlocked:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func lockedError(retryAfter time.Duration) *core.AppError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return core.NewAppError(http.StatusLocked, "LOCKED", "Too many failed login attempts, please try again later").
+		WithDetail("retry_after_seconds", seconds)
+}
+
+// checkLoginAllowed rejects attempts against a locked account or from a
+// locked IP, and slows down repeated failures
+func (s *authService) checkLoginAllowed(ctx context.Context, email string) error {
+	p := s.loginProtection
+	ip := clientinfo.FromContext(ctx).IP
+
+	keys := []string{loginAccountKey(email)}
+	if ip != "" {
+		unlocked, err := p.Store.LockedFor(ctx, loginUnlockedKey(email))
+		if err != nil {
+			s.log.WithError(err).Warn("failed to check admin unlock")
+		}
+		if unlocked == 0 {
+			keys = append(keys, loginIPKey(ip))
+		}
+	}
+	for _, key := range keys {
+		left, err := p.Store.LockedFor(ctx, key)
+		if err != nil {
+			// Fail open: an unavailable store must not block all logins
+			s.log.WithError(err).WithField("key", key).Error("failed to check login lock")
+			continue
+		}
+		if left > 0 {
+			s.auditLog(ctx, nil, "user.login.blocked", nil, nil, map[string]interface{}{
+				"email":       email,
+				"lock":        key,
+				"retry_after": int(math.Ceil(left.Seconds())),
+			})
+			return lockedError(left)
+		}
+	}
+
+	failures, err := p.Store.Count(ctx, loginAccountKey(email))
+	if err != nil {
+		s.log.WithError(err).Error("failed to get login failures")
+		return nil
+	}
+	if over := int(failures) - p.FreeFailures; over > 0 {
+		delay := p.BaseDelay << (over - 1)
+		if delay <= 0 || delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed attempt for the account and client IP.
+// It returns a LOCKED error when this attempt triggers a lockout; user is nil
+// when the email does not belong to an account.
+func (s *authService) recordLoginFailure(ctx context.Context, email string, user *model.User) error {
+	p := s.loginProtection
+	ip := clientinfo.FromContext(ctx).IP
+
+	var lockErr error
+	if ip != "" {
+		n, err := p.Store.Incr(ctx, loginIPKey(ip), p.Window)
+		if err != nil {
+			s.log.WithError(err).WithField("ip_address", ip).Error("failed to record login failure")
+		} else if n >= int64(p.MaxIPFailures) {
+			if err := p.Store.Lock(ctx, loginIPKey(ip), p.LockoutDuration); err != nil {
+				s.log.WithError(err).WithField("ip_address", ip).Error("failed to lock ip")
+			}
+			s.auditLog(ctx, nil, "auth.ip.locked", nil, nil, map[string]interface{}{
+				"ip_address": ip,
+				"failures":   n,
+				"duration":   p.LockoutDuration.String(),
+			})
+			lockErr = lockedError(p.LockoutDuration)
+		}
+	}
+
+	key := loginAccountKey(email)
+	n, err := p.Store.Incr(ctx, key, p.Window)
+	if err != nil {
+		s.log.WithError(err).Error("failed to record login failure")
+		return lockErr
+	}
+	if n < int64(p.MaxAccountFailures) {
+		return lockErr
+	}
+
+	if err := p.Store.Lock(ctx, key, p.LockoutDuration); err != nil {
+		s.log.WithError(err).Error("failed to lock account")
+	}
+
+	var userID *string
+	if user != nil {
+		userID = &user.ID
+	}
+	s.auditLog(ctx, userID, "user.login.locked", strPtr("users"), userID, map[string]interface{}{
+		"email":    email,
+		"failures": n,
+		"duration": p.LockoutDuration.String(),
+	})
+
+	if user != nil {
+		s.sendLockoutEmail(ctx, user)
+	}
+
+	return lockedError(p.LockoutDuration)
+}
+
+// clearLoginFailures resets the account counter after a successful login
+func (s *authService) clearLoginFailures(ctx context.Context, email string) {
+	if err := s.loginProtection.Store.Reset(ctx, loginAccountKey(email)); err != nil {
+		s.log.WithError(err).Warn("failed to reset login failures")
+	}
+}
+
+func (s *authService) sendLockoutEmail(ctx context.Context, user *model.User) {
+	if s.outbox == nil {
+		return
+	}
+	body, err := mailer.RenderAccountLockedEmail(user.FullName, s.loginProtection.LockoutDuration.String(), s.loginProtection.ResetPasswordURL)
+	if err != nil {
+		s.log.WithError(err).WithField("user_id", user.ID).Error("failed to render lockout email")
+		return
+	}
+	if err := s.outbox.Enqueue(ctx, user.Email, "Account Temporarily Locked - ITTS Community", body); err != nil {
+		s.log.WithError(err).WithField("user_id", user.ID).Error("failed to queue lockout email")
+	}
+}
+
+// UnlockUser lifts a login lockout before it expires (admin). The failing
+// client IP is not known here, so instead of clearing its lock the account is
+// exempted from IP locks for one lockout period.
+func (s *authService) UnlockUser(ctx context.Context, userID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.UnlockUser")()
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("user", userID)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.loginProtection.Store.Reset(ctx, loginAccountKey(user.Email)); err != nil {
+		return core.InternalServerError("failed to unlock user").WithError(err)
+	}
+	if err := s.loginProtection.Store.Lock(ctx, loginUnlockedKey(user.Email), s.loginProtection.LockoutDuration); err != nil {
+		return core.InternalServerError("failed to unlock user").WithError(err)
+	}
+
+	adminID := getUserIDFromContext(ctx)
+	s.auditLog(ctx, adminID, "user.login.unlocked", strPtr("users"), &userID, map[string]interface{}{
+		"target_user_id": userID,
+		"email":          user.Email,
+	})
+
+	return nil
+}
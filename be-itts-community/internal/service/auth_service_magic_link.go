@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/mailer"
+	"be-itts-community/pkg/validator"
+)
+
+// magicLinkEmailKey counts link requests per submitted email, whether or not
+// it belongs to an account
+func magicLinkEmailKey(email string) string {
+	return "magic_link:email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// RequestMagicLink emails a single-use login link. Like ForgotPassword it
+// succeeds silently for unknown or inactive emails.
+func (s *authService) RequestMagicLink(ctx context.Context, req model.MagicLinkRequest, loginURL string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.RequestMagicLink")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
+	// Throttle before the lookup so the limit does not reveal accounts
+	p := s.loginProtection
+	n, err := p.Store.Incr(ctx, magicLinkEmailKey(req.Email), p.Window)
+	if err != nil {
+		// Fail open: an unavailable store must not block all logins
+		s.log.WithError(err).Warn("failed to count magic link requests")
+	} else if n > int64(p.MaxMagicLinks) {
+		s.auditLog(ctx, nil, "user.magic_link.throttled", nil, nil, map[string]interface{}{
+			"email":    req.Email,
+			"requests": n,
+		})
+		return core.NewAppError(http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Too many login link requests, please try again later").
+			WithDetail("retry_after_seconds", int(p.Window.Seconds()))
+	}
+
+	user, err := s.authRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.auditLog(ctx, nil, "user.magic_link.requested", nil, nil, map[string]interface{}{
+				"email":  req.Email,
+				"result": "ignored",
+				"reason": "user not found",
+			})
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		s.auditLog(ctx, &user.ID, "user.magic_link.requested", strPtr("users"), &user.ID, map[string]interface{}{
+			"email":  req.Email,
+			"result": "ignored",
+			"reason": "user not active",
+		})
+		return nil
+	}
+
+	rawToken, tokenHash, err := generateToken()
+	if err != nil {
+		return core.InternalServerError("failed to generate login link").WithError(err)
+	}
+
+	now := time.Now()
+	err = s.magicLinkRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		// Only the most recent link stays valid
+		if err := s.magicLinkRepo.InvalidateForUser(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		link := &model.MagicLink{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(s.magicLinkTTL),
+		}
+		if err := s.magicLinkRepo.Create(txCtx, link); err != nil {
+			return err
+		}
+
+		if s.outbox != nil && loginURL != "" {
+			body, err := mailer.RenderMagicLinkEmail(user.FullName, fmt.Sprintf("%s?token=%s", loginURL, rawToken), fmt.Sprintf("%d minutes", int(s.magicLinkTTL.Minutes())))
+			if err != nil {
+				return fmt.Errorf("failed to render magic link email: %w", err)
+			}
+			if err := s.outbox.Enqueue(txCtx, user.Email, "Your Login Link - ITTS Community", body); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return core.InternalServerError("failed to issue login link").WithError(err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.magic_link.requested", strPtr("users"), &user.ID, map[string]interface{}{
+		"email":      user.Email,
+		"result":     "issued",
+		"expires_at": now.Add(s.magicLinkTTL),
+	})
+
+	return nil
+}
+
+// ConsumeMagicLink logs in with a magic link token. The response is the same
+// as Login, including an MFA challenge when a second factor is required.
+func (s *authService) ConsumeMagicLink(ctx context.Context, req model.MagicLinkConsumeRequest) (*model.LoginResponse, *model.MFAChallengeResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ConsumeMagicLink")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, nil, core.ValidationError(err)
+	}
+
+	sum := sha256.Sum256([]byte(req.Token))
+	link, err := s.magicLinkRepo.FindValidByHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, core.Unauthorized("Invalid or expired login link")
+		}
+		return nil, nil, fmt.Errorf("failed to get login link: %w", err)
+	}
+
+	// Single use; a concurrent second use loses here
+	if err := s.magicLinkRepo.MarkUsed(ctx, link.ID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, core.Unauthorized("Invalid or expired login link")
+		}
+		return nil, nil, fmt.Errorf("failed to consume login link: %w", err)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive {
+		s.auditLog(ctx, &user.ID, "user.login.failed", nil, nil, map[string]interface{}{
+			"email":  user.Email,
+			"method": "magic_link",
+			"reason": "account inactive",
+		})
+		return nil, nil, core.Forbidden("Account is inactive")
+	}
+	s.clearLoginFailures(ctx, user.Email)
+
+	// Second factor
+	challenge, err := s.mfaChallenge(ctx, user, "magic_link")
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge != nil {
+		return nil, challenge, nil
+	}
+
+	resp, err := s.issueLogin(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.auditLog(ctx, &user.ID, "user.login.success", nil, nil, map[string]interface{}{
+		"email":  user.Email,
+		"method": "magic_link",
+	})
+
+	return resp, nil, nil
+}
@@ -0,0 +1,573 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/validator"
+)
+
+const mfaRecoveryCodeCount = 10
+
+// maxMFAChallengeFailures is how many wrong codes one challenge token takes
+// before it is burned and the user has to pass the first factor again
+const maxMFAChallengeFailures = 5
+
+// mfaChallenge decides whether a user who passed the first factor needs a
+// second one. It returns nil when tokens can be issued right away.
+func (s *authService) mfaChallenge(ctx context.Context, user *model.User, method string) (*model.MFAChallengeResponse, error) {
+	purpose := ""
+	if user.MFAEnabled {
+		purpose = auth.MFAPurposeVerify
+	} else {
+		required, err := s.userRequiresMFA(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if required {
+			purpose = auth.MFAPurposeEnroll
+		}
+	}
+	if purpose == "" {
+		return nil, nil
+	}
+
+	token, err := s.jwtManager.GenerateMFAChallengeToken(user.ID, purpose, method)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa challenge: %w", err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.login.mfa_challenge", nil, nil, map[string]interface{}{
+		"email":   user.Email,
+		"method":  method,
+		"purpose": purpose,
+	})
+
+	return &model.MFAChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: purpose == auth.MFAPurposeEnroll,
+		MFAToken:           token,
+		ExpiresIn:          int64(auth.MFAChallengeDuration.Seconds()),
+	}, nil
+}
+
+// userRequiresMFA reports whether policy forces MFA on the user: super admins
+// and members of any role flagged requires_mfa.
+func (s *authService) userRequiresMFA(ctx context.Context, user *model.User) (bool, error) {
+	if user.IsSuperAdmin {
+		return true, nil
+	}
+	roles, err := s.authRepo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	for _, role := range roles {
+		if role.RequiresMFA {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// VerifyMFA completes a two-step login
+func (s *authService) VerifyMFA(ctx context.Context, req model.MFAVerifyRequest) (*model.LoginResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.VerifyMFA")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, claims, err := s.userFromMFAToken(ctx, req.MFAToken, auth.MFAPurposeVerify)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, core.Unauthorized("Invalid or expired MFA token")
+	}
+
+	factor, err := s.checkSecondFactor(ctx, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		s.auditLog(ctx, &user.ID, "user.login.failed", nil, nil, map[string]interface{}{
+			"email":  user.Email,
+			"method": claims.Method,
+			"reason": "invalid mfa code",
+		})
+		if ferr := s.recordMFAChallengeFailure(ctx, claims); ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if err := s.closeMFAChallenge(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLog(ctx, &user.ID, "user.login.success", nil, nil, map[string]interface{}{
+		"email":      user.Email,
+		"method":     claims.Method,
+		"mfa_factor": factor,
+	})
+
+	return resp, nil
+}
+
+// BeginMFASetup starts enrollment for a user whose login is blocked by MFA policy
+func (s *authService) BeginMFASetup(ctx context.Context, req model.MFASetupRequest) (*model.MFAEnrollResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.BeginMFASetup")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, _, err := s.userFromMFAToken(ctx, req.MFAToken, auth.MFAPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.enrollMFA(ctx, user)
+}
+
+// CompleteMFASetup activates MFA for a user blocked by policy and logs them in
+func (s *authService) CompleteMFASetup(ctx context.Context, req model.MFASetupConfirmRequest) (*model.MFASetupConfirmResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.CompleteMFASetup")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, claims, err := s.userFromMFAToken(ctx, req.MFAToken, auth.MFAPurposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.activateMFA(ctx, user, req.Code)
+	if err != nil {
+		if _, ok := core.IsAppError(err); ok {
+			if ferr := s.recordMFAChallengeFailure(ctx, claims); ferr != nil {
+				return nil, ferr
+			}
+		}
+		return nil, err
+	}
+	if err := s.closeMFAChallenge(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.issueLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditLog(ctx, &user.ID, "user.login.success", nil, nil, map[string]interface{}{
+		"email":      user.Email,
+		"method":     claims.Method,
+		"mfa_factor": "totp",
+	})
+
+	return &model.MFASetupConfirmResponse{
+		RecoveryCodes: codes,
+		Login:         *resp,
+	}, nil
+}
+
+// EnrollMFA generates a new TOTP secret for the current user
+func (s *authService) EnrollMFA(ctx context.Context, userID string) (*model.MFAEnrollResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.EnrollMFA")()
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return s.enrollMFA(ctx, user)
+}
+
+// ActivateMFA confirms enrollment with a code and returns recovery codes
+func (s *authService) ActivateMFA(ctx context.Context, userID string, req model.MFAActivateRequest) (*model.MFARecoveryCodesResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ActivateMFA")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	codes, err := s.activateMFA(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableMFA turns MFA off for the current user unless policy requires it
+func (s *authService) DisableMFA(ctx context.Context, userID string, req model.MFACodeRequest) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.DisableMFA")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("user", userID)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.MFAEnabled {
+		return core.BadRequest("MFA is not enabled")
+	}
+
+	required, err := s.userRequiresMFA(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return core.Forbidden("MFA is required for your role and cannot be disabled")
+	}
+
+	if _, err := s.checkSecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		s.auditLog(ctx, &userID, "user.mfa.disable.failed", strPtr("users"), &userID, map[string]interface{}{
+			"reason": "invalid mfa code",
+		})
+		return err
+	}
+
+	if err := s.clearMFA(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditLog(ctx, &userID, "user.mfa.disabled", strPtr("users"), &userID, nil)
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the current user
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID string, req model.MFACodeRequest) (*model.MFARecoveryCodesResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.RegenerateRecoveryCodes")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.MFAEnabled {
+		return nil, core.BadRequest("MFA is not enabled")
+	}
+
+	if _, err := s.checkSecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate recovery codes").WithError(err)
+	}
+	if err := s.authRepo.ReplaceMFARecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "user.mfa.recovery_codes.regenerated", strPtr("users"), &userID, nil)
+
+	return &model.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// AdminResetMFA clears MFA for a user who lost their authenticator. The user
+// is signed out and, if policy requires MFA, must enroll again on next login.
+func (s *authService) AdminResetMFA(ctx context.Context, userID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.AdminResetMFA")()
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("user", userID)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.clearMFA(ctx, user); err != nil {
+		return err
+	}
+
+	adminID := getUserIDFromContext(ctx)
+	s.auditLog(ctx, adminID, "user.mfa.reset", strPtr("users"), &userID, map[string]interface{}{
+		"target_user_id": userID,
+	})
+
+	return nil
+}
+
+// userFromMFAToken validates a challenge token for the given purpose and
+// loads its (active) user. Used and burned challenges are rejected.
+func (s *authService) userFromMFAToken(ctx context.Context, token, purpose string) (*model.User, *auth.MFAClaims, error) {
+	claims, err := s.jwtManager.VerifyMFAChallengeToken(token)
+	if err != nil || claims.Purpose != purpose {
+		return nil, nil, core.Unauthorized("Invalid or expired MFA token")
+	}
+	closed, err := s.loginProtection.Store.LockedFor(ctx, mfaChallengeKey(claims))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check mfa challenge: %w", err)
+	}
+	if closed > 0 {
+		return nil, nil, core.Unauthorized("Invalid or expired MFA token")
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, core.Unauthorized("Invalid or expired MFA token")
+		}
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive {
+		return nil, nil, core.Forbidden("Account is inactive")
+	}
+	return user, claims, nil
+}
+
+func (s *authService) enrollMFA(ctx context.Context, user *model.User) (*model.MFAEnrollResponse, error) {
+	if user.MFAEnabled {
+		return nil, core.Conflict("MFA is already enabled")
+	}
+
+	if s.secretBox == nil {
+		return nil, core.InternalServerError("MFA is not configured")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate MFA secret").WithError(err)
+	}
+	sealed, err := s.secretBox.Seal(secret)
+	if err != nil {
+		return nil, core.InternalServerError("failed to encrypt MFA secret").WithError(err)
+	}
+
+	// Pending until activated; a new enrollment replaces an unconfirmed one
+	user.MFASecret = &sealed
+	user.MFALastStep = nil
+	if err := s.authRepo.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to save MFA secret: %w", err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.mfa.enrollment_started", strPtr("users"), &user.ID, nil)
+
+	return &model.MFAEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *authService) activateMFA(ctx context.Context, user *model.User, code string) ([]string, error) {
+	if user.MFAEnabled {
+		return nil, core.Conflict("MFA is already enabled")
+	}
+	if user.MFASecret == nil {
+		return nil, core.BadRequest("MFA enrollment has not been started")
+	}
+
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		s.auditLog(ctx, &user.ID, "user.mfa.activation.failed", strPtr("users"), &user.ID, map[string]interface{}{
+			"reason": "invalid mfa code",
+		})
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate recovery codes").WithError(err)
+	}
+
+	now := time.Now()
+	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		fresh, err := s.authRepo.GetUserByID(txCtx, user.ID)
+		if err != nil {
+			return err
+		}
+		fresh.MFAEnabled = true
+		fresh.MFAEnabledAt = &now
+		if err := s.authRepo.UpdateUser(txCtx, fresh); err != nil {
+			return err
+		}
+		*user = *fresh
+		return s.authRepo.ReplaceMFARecoveryCodes(txCtx, user.ID, hashes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable MFA: %w", err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.mfa.enabled", strPtr("users"), &user.ID, nil)
+
+	return codes, nil
+}
+
+func (s *authService) clearMFA(ctx context.Context, user *model.User) error {
+	err := s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		user.MFAEnabled = false
+		user.MFASecret = nil
+		user.MFAEnabledAt = nil
+		user.MFALastStep = nil
+		if err := s.authRepo.UpdateUser(txCtx, user); err != nil {
+			return err
+		}
+		if err := s.authRepo.DeleteMFARecoveryCodes(txCtx, user.ID); err != nil {
+			return err
+		}
+		// Sessions established with the old factor are no longer trusted
+		return s.signOutEverywhere(txCtx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear MFA: %w", err)
+	}
+	return nil
+}
+
+func mfaChallengeKey(claims *auth.MFAClaims) string {
+	return "mfa_challenge:" + claims.ID
+}
+
+// recordMFAChallengeFailure counts a wrong code against the challenge and
+// burns it after maxMFAChallengeFailures, which bounds guessing of the
+// 6-digit code to a few tries per first-factor login. It fails closed: when
+// the failure cannot be counted the attempt errors out.
+func (s *authService) recordMFAChallengeFailure(ctx context.Context, claims *auth.MFAClaims) error {
+	key := mfaChallengeKey(claims)
+	n, err := s.loginProtection.Store.Incr(ctx, key, auth.MFAChallengeDuration)
+	if err != nil {
+		return fmt.Errorf("failed to count mfa challenge failure: %w", err)
+	}
+	if n >= maxMFAChallengeFailures {
+		if err := s.loginProtection.Store.Lock(ctx, key, auth.MFAChallengeDuration); err != nil {
+			return fmt.Errorf("failed to burn mfa challenge: %w", err)
+		}
+	}
+	return nil
+}
+
+// closeMFAChallenge makes a challenge single-use once its code was accepted
+func (s *authService) closeMFAChallenge(ctx context.Context, claims *auth.MFAClaims) error {
+	if err := s.loginProtection.Store.Lock(ctx, mfaChallengeKey(claims), auth.MFAChallengeDuration); err != nil {
+		return fmt.Errorf("failed to close mfa challenge: %w", err)
+	}
+	return nil
+}
+
+// checkSecondFactor accepts either a TOTP code or a recovery code and returns
+// which factor was used
+func (s *authService) checkSecondFactor(ctx context.Context, user *model.User, code, recoveryCode string) (string, error) {
+	switch {
+	case code != "":
+		if err := s.checkTOTP(ctx, user, code); err != nil {
+			return "", err
+		}
+		return "totp", nil
+	case recoveryCode != "":
+		err := s.authRepo.UseMFARecoveryCode(ctx, user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", core.Unauthorized("Invalid MFA code")
+			}
+			return "", fmt.Errorf("failed to use recovery code: %w", err)
+		}
+		s.auditLog(ctx, &user.ID, "user.mfa.recovery_code.used", strPtr("users"), &user.ID, nil)
+		return "recovery_code", nil
+	default:
+		return "", core.BadRequest("code or recovery_code is required")
+	}
+}
+
+// checkTOTP validates a TOTP code and burns its time step so it cannot be replayed
+func (s *authService) checkTOTP(ctx context.Context, user *model.User, code string) error {
+	if user.MFASecret == nil {
+		return core.BadRequest("MFA is not set up")
+	}
+	if s.secretBox == nil {
+		return core.InternalServerError("MFA is not configured")
+	}
+	secret, err := s.secretBox.Open(*user.MFASecret)
+	if err != nil {
+		return core.InternalServerError("failed to decrypt MFA secret").WithError(err)
+	}
+
+	ok, step := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return core.Unauthorized("Invalid MFA code")
+	}
+	if err := s.authRepo.ConsumeMFAStep(ctx, user.ID, step); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.Unauthorized("MFA code already used")
+		}
+		return fmt.Errorf("failed to record MFA step: %w", err)
+	}
+	user.MFALastStep = &step
+	return nil
+}
+
+// generateRecoveryCodes returns display codes (xxxxx-xxxxx) and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, mfaRecoveryCodeCount)
+	hashes := make([]string, mfaRecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalises user input (case, dashes, spaces) before hashing
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/mailer"
+	"be-itts-community/pkg/validator"
+)
+
+// ForgotPassword issues a password reset token and emails the reset link.
+// It never reveals whether the email belongs to an account. Without an
+// outbox or reset URL no token is issued and the request fails for everyone
+// alike.
+func (s *authService) ForgotPassword(ctx context.Context, req model.ForgotPasswordRequest, resetURL string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ForgotPassword")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+	if s.outbox == nil || resetURL == "" {
+		return core.ServiceUnavailable("password reset is not available")
+	}
+
+	user, err := s.authRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.auditLog(ctx, nil, "user.password_reset.requested", nil, nil, map[string]interface{}{
+				"email":  req.Email,
+				"result": "ignored",
+				"reason": "user not found",
+			})
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		s.auditLog(ctx, &user.ID, "user.password_reset.requested", strPtr("users"), &user.ID, map[string]interface{}{
+			"email":  req.Email,
+			"result": "ignored",
+			"reason": "user not active",
+		})
+		return nil
+	}
+
+	rawToken, tokenHash, err := generateToken()
+	if err != nil {
+		return core.InternalServerError("failed to generate reset token").WithError(err)
+	}
+
+	now := time.Now()
+	err = s.resetRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		// Only the most recent link stays valid
+		if err := s.resetRepo.InvalidateForUser(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		reset := &model.PasswordReset{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(s.resetTokenTTL),
+		}
+		if err := s.resetRepo.Create(txCtx, reset); err != nil {
+			return err
+		}
+
+		link := fmt.Sprintf("%s?token=%s", resetURL, rawToken)
+		body, err := mailer.RenderPasswordResetEmail(user.FullName, link)
+		if err != nil {
+			return fmt.Errorf("failed to render password reset email: %w", err)
+		}
+		return s.outbox.Enqueue(txCtx, user.Email, "Reset Your Password - ITTS Community", body)
+	})
+	if err != nil {
+		return core.InternalServerError("failed to issue password reset").WithError(err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.password_reset.requested", strPtr("users"), &user.ID, map[string]interface{}{
+		"email":      user.Email,
+		"result":     "issued",
+		"expires_at": now.Add(s.resetTokenTTL),
+	})
+
+	return nil
+}
+
+// ResetPasswordWithToken consumes a reset token, sets the new password and
+// signs the user out everywhere.
+func (s *authService) ResetPasswordWithToken(ctx context.Context, req model.ResetPasswordWithTokenRequest) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ResetPasswordWithToken")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+
+	sum := sha256.Sum256([]byte(req.Token))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	reset, err := s.resetRepo.FindValidByHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.auditLog(ctx, nil, "user.password_reset.failed", nil, nil, map[string]interface{}{
+				"reason": "invalid or expired token",
+			})
+			return core.BadRequest("invalid or expired token")
+		}
+		return fmt.Errorf("failed to get reset token: %w", err)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.BadRequest("invalid or expired token")
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsActive {
+		s.auditLog(ctx, &user.ID, "user.password_reset.failed", strPtr("users"), &user.ID, map[string]interface{}{
+			"reason": "user not active",
+		})
+		return core.Forbidden("Account is inactive")
+	}
+
+	if err := checkPasswordPolicy(s.log, s.passwordPolicy, "new_password", req.NewPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	err = s.authRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.resetRepo.MarkUsed(txCtx, reset.ID, now); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.BadRequest("invalid or expired token")
+			}
+			return err
+		}
+
+		user.PasswordHash = &hashedPassword
+		if err := s.authRepo.UpdateUser(txCtx, user); err != nil {
+			return err
+		}
+
+		if err := s.resetRepo.InvalidateForUser(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		// Revoke all tokens (force re-login)
+		return s.signOutEverywhere(txCtx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	s.auditLog(ctx, &user.ID, "user.password_reset.completed", strPtr("users"), &user.ID, nil)
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+)
+
+// ListSessions lists the active sessions of the current user
+func (s *authService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]model.SessionResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.ListSessions")()
+	}
+
+	return s.listSessions(ctx, userID, currentSessionID)
+}
+
+// RevokeSession signs the current user out of one of their sessions
+func (s *authService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.RevokeSession")()
+	}
+
+	if err := s.authRepo.RevokeUserSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("session", sessionID)
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "user.session.revoked", strPtr("sessions"), &sessionID, nil)
+
+	return nil
+}
+
+// RevokeOtherSessions signs the current user out everywhere except the
+// session making the request
+func (s *authService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.RevokeOtherSessions")()
+	}
+
+	// Tokens issued before sessions were tracked carry no session ID
+	if currentSessionID == "" {
+		return core.BadRequest("Current session is unknown, please sign in again")
+	}
+
+	if err := s.authRepo.RevokeOtherUserSessions(ctx, userID, currentSessionID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.auditLog(ctx, &userID, "user.session.revoked_others", strPtr("sessions"), &currentSessionID, nil)
+
+	return nil
+}
+
+// AdminListUserSessions lists the active sessions of any user
+func (s *authService) AdminListUserSessions(ctx context.Context, userID string) ([]model.SessionResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.AdminListUserSessions")()
+	}
+
+	if _, err := s.authRepo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("user", userID)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.listSessions(ctx, userID, "")
+}
+
+// AdminRevokeUserSession revokes one session of any user
+func (s *authService) AdminRevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.AdminRevokeUserSession")()
+	}
+
+	if err := s.authRepo.RevokeUserSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("session", sessionID)
+		}
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	adminID := getUserIDFromContext(ctx)
+	s.auditLog(ctx, adminID, "user.session.revoked", strPtr("sessions"), &sessionID, map[string]interface{}{
+		"target_user_id": userID,
+	})
+
+	return nil
+}
+
+// AdminRevokeAllUserSessions signs a user out everywhere
+func (s *authService) AdminRevokeAllUserSessions(ctx context.Context, userID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "AuthService.AdminRevokeAllUserSessions")()
+	}
+
+	if _, err := s.authRepo.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("user", userID)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.signOutEverywhere(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	adminID := getUserIDFromContext(ctx)
+	s.auditLog(ctx, adminID, "user.session.revoked_all", strPtr("users"), &userID, map[string]interface{}{
+		"target_user_id": userID,
+	})
+
+	return nil
+}
+
+func (s *authService) listSessions(ctx context.Context, userID, currentSessionID string) ([]model.SessionResponse, error) {
+	sessions, err := s.authRepo.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	resp := make([]model.SessionResponse, len(sessions))
+	for i := range sessions {
+		resp[i] = sessions[i].ToSessionResponse(currentSessionID)
+	}
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+)
+
+// scopedActions lists, per resource, the actions whose services check
+// instance scope through authorize. A scoped grant on anything else would
+// act as a global grant at the route gate, so assigning one is rejected.
+var scopedActions = map[string][]string{
+	"events":  {"create", "update", "delete"},
+	"mentors": {"create", "update", "delete", "activate"},
+}
+
+// scopablePermission reports whether a grant for name may carry a scope
+func scopablePermission(name string) bool {
+	resource, action, ok := model.SplitPermission(name)
+	if !ok {
+		return false
+	}
+	for _, a := range scopedActions[resource] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize checks that the caller may perform permission on the resource
+// instance described by attrs. Calls without an auth context (jobs,
+// internal callers) are not restricted.
+func authorize(ctx context.Context, permission string, attrs model.ResourceAttrs) error {
+	ac := model.AuthContextFromContext(ctx)
+	if ac == nil || ac.Can(permission, attrs) {
+		return nil
+	}
+	return core.Forbidden("Insufficient permissions for this resource").WithDetail("permission", permission)
+}
+
+func eventAttrs(ev *model.Event) model.ResourceAttrs {
+	attrs := model.ResourceAttrs{CreatedBy: ev.CreatedBy}
+	if ev.Program != nil {
+		attrs.Programs = []model.ProgramEnum{*ev.Program}
+	}
+	return attrs
+}
+
+func mentorAttrs(m *model.Mentor) model.ResourceAttrs {
+	return model.ResourceAttrs{CreatedBy: m.CreatedBy, Programs: m.Programs}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/observability/nr"
+)
+
+type emailOutboxService struct {
+	repo        repository.EmailOutboxRepository
+	auditRepo   repository.AuditLogRepository
+	mailer      Mailer
+	maxAttempts int
+	batchSize   int
+	lease       time.Duration
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	tracer      nr.Tracer
+	log         *core.Logger
+}
+
+func (s *emailOutboxService) Enqueue(ctx context.Context, to, subject, htmlBody string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "EmailOutboxService.Enqueue")()
+	}
+
+	msg := model.EmailOutbox{
+		Recipient:     strings.TrimSpace(to),
+		Subject:       subject,
+		Body:          htmlBody,
+		Status:        model.EmailOutboxPending,
+		MaxAttempts:   s.maxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.repo.Enqueue(ctx, &msg); err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// ProcessDue claims one batch of due messages and attempts delivery.
+// It returns the number of messages sent successfully.
+func (s *emailOutboxService) ProcessDue(ctx context.Context) (int, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "EmailOutboxService.ProcessDue")()
+	}
+
+	// Without a transport, leave messages pending so they are delivered once
+	// SMTP is configured instead of burning through their attempts.
+	if s.mailer == nil {
+		return 0, nil
+	}
+
+	msgs, err := s.repo.ClaimDue(ctx, s.batchSize, s.lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	sent := 0
+	for _, msg := range msgs {
+		if err := s.mailer.Send(msg.Recipient, msg.Subject, msg.Body); err != nil {
+			s.handleFailure(ctx, msg, err)
+			continue
+		}
+		if err := s.repo.MarkSent(ctx, msg.ID, time.Now()); err != nil {
+			s.log.WithError(err).WithField("outbox_id", msg.ID).Error("failed to mark email as sent")
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *emailOutboxService) handleFailure(ctx context.Context, msg model.EmailOutbox, sendErr error) {
+	status := model.EmailOutboxPending
+	next := time.Now().Add(s.backoff(msg.Attempts))
+	if msg.Attempts >= msg.MaxAttempts {
+		status = model.EmailOutboxDead
+	}
+
+	if err := s.repo.MarkFailed(ctx, msg.ID, sendErr.Error(), status, next); err != nil {
+		s.log.WithError(err).WithField("outbox_id", msg.ID).Error("failed to record email failure")
+		return
+	}
+
+	l := s.log.WithError(sendErr).WithFields(map[string]interface{}{
+		"outbox_id": msg.ID,
+		"attempts":  msg.Attempts,
+	})
+	if status == model.EmailOutboxDead {
+		l.Error("email moved to dead letter after max attempts")
+	} else {
+		l.Warn("email delivery failed; will retry")
+	}
+}
+
+// backoff returns baseBackoff * 2^(attempts-1), capped at maxBackoff.
+func (s *emailOutboxService) backoff(attempts int) time.Duration {
+	d := s.baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return d
+}
+
+// Run polls the outbox until ctx is cancelled. A full batch is followed
+// immediately by another pass so a backlog drains without waiting a tick.
+func (s *emailOutboxService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.ProcessDue(ctx)
+			if err != nil {
+				s.log.WithError(err).Error("email outbox pass failed")
+				break
+			}
+			if n < s.batchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *emailOutboxService) AdminList(ctx context.Context, p repository.ListParams) (model.EmailOutboxListResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "EmailOutboxService.AdminList")()
+	}
+	result, err := s.repo.List(ctx, p)
+	if err != nil {
+		return model.EmailOutboxListResponse{}, core.InternalServerError("failed to list email outbox").WithError(err)
+	}
+	data := make([]model.EmailOutboxResponse, 0, len(result.Data))
+	for _, m := range result.Data {
+		data = append(data, model.EmailOutboxToResponse(m))
+	}
+	return model.EmailOutboxListResponse{
+		Data:       data,
+		Total:      result.Total,
+		Page:       result.Page,
+		PageSize:   result.PageSize,
+		TotalPages: result.TotalPages,
+	}, nil
+}
+
+func (s *emailOutboxService) AdminGet(ctx context.Context, id string) (model.EmailOutboxResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "EmailOutboxService.AdminGet")()
+	}
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.EmailOutboxResponse{}, core.NotFound("email", id)
+		}
+		return model.EmailOutboxResponse{}, core.InternalServerError("failed to fetch email").WithError(err)
+	}
+	return model.EmailOutboxToResponse(*m), nil
+}
+
+func (s *emailOutboxService) AdminResend(ctx context.Context, id string) (model.EmailOutboxResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "EmailOutboxService.AdminResend")()
+	}
+
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.EmailOutboxResponse{}, core.NotFound("email", id)
+		}
+		return model.EmailOutboxResponse{}, core.InternalServerError("failed to fetch email").WithError(err)
+	}
+	switch {
+	case m.Status == model.EmailOutboxSent:
+		return model.EmailOutboxResponse{}, core.Conflict("email has already been sent")
+	case m.Status == model.EmailOutboxSending && m.NextAttemptAt.After(time.Now()):
+		return model.EmailOutboxResponse{}, core.Conflict("email is currently being sent")
+	case m.Status == model.EmailOutboxPending && m.LastError == nil:
+		return model.EmailOutboxResponse{}, core.Conflict("email has not failed yet")
+	}
+
+	// The repository re-checks the state so a worker finishing meanwhile wins
+	if err := s.repo.Requeue(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.EmailOutboxResponse{}, core.Conflict("email is no longer in a failed state")
+		}
+		return model.EmailOutboxResponse{}, core.InternalServerError("failed to requeue email").WithError(err)
+	}
+
+	s.auditLog(ctx, getUserIDFromContext(ctx), "email_outbox.resend", strPtr("email_outbox"), &id, map[string]interface{}{
+		"recipient":       m.Recipient,
+		"previous_status": m.Status,
+		"attempts":        m.Attempts,
+	})
+
+	return s.AdminGet(ctx, id)
+}
+
+func (s *emailOutboxService) auditLog(ctx context.Context, userID *string, action string, resourceType *string, resourceID *string, metadata map[string]interface{}) {
+	if s.auditRepo == nil {
+		return
+	}
+	log := &model.AuditLog{
+		UserID:         userID,
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Metadata:       metadata,
+		IPAddress:      getIPFromContext(ctx),
+		UserAgent:      getUserAgentFromContext(ctx),
+		APITokenID:     getAPITokenIDFromContext(ctx),
+		ImpersonatorID: getImpersonatorIDFromContext(ctx),
+	}
+
+	// Non-blocking audit log
+	go func() {
+		_ = s.auditRepo.CreateAuditLog(context.Background(), log)
+	}()
+}
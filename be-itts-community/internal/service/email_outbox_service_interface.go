@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/observability/nr"
+)
+
+// EmailOutboxService queues outbound email in Postgres and delivers it
+// through the configured Mailer with retries and dead-lettering.
+type EmailOutboxService interface {
+	// Enqueue stores a message for delivery. When ctx carries a transaction
+	// the message is committed (or rolled back) together with it.
+	Enqueue(ctx context.Context, to, subject, htmlBody string) error
+
+	// Worker
+	ProcessDue(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+
+	// Admin
+	AdminList(ctx context.Context, p repository.ListParams) (model.EmailOutboxListResponse, error)
+	AdminGet(ctx context.Context, id string) (model.EmailOutboxResponse, error)
+	AdminResend(ctx context.Context, id string) (model.EmailOutboxResponse, error)
+}
+
+func NewEmailOutboxService(
+	repo repository.EmailOutboxRepository,
+	auditRepo repository.AuditLogRepository,
+	mailer Mailer,
+	maxAttempts int,
+	tracer nr.Tracer,
+	log *core.Logger,
+) EmailOutboxService {
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "email-outbox"})
+	}
+	return &emailOutboxService{
+		repo:        repo,
+		auditRepo:   auditRepo,
+		mailer:      mailer,
+		maxAttempts: maxAttempts,
+		batchSize:   20,
+		lease:       2 * time.Minute,
+		baseBackoff: 30 * time.Second,
+		maxBackoff:  time.Hour,
+		tracer:      tracer,
+		log:         log,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"be-itts-community/internal/model"
+	"be-itts-community/internal/rbac"
 	"be-itts-community/internal/repository"
 	"be-itts-community/pkg/lock"
 	"be-itts-community/pkg/observability/nr"
@@ -35,6 +36,10 @@ func (s *eventService) Create(ctx context.Context, req model.CreateEventRequest)
 	}
 
 	ev := req.ToModel()
+	ev.CreatedBy = getUserIDFromContext(ctx)
+	if err := authorize(ctx, rbac.EventsCreate, eventAttrs(&ev)); err != nil {
+		return model.EventResponse{}, err
+	}
 
 	if err := s.locker.WithLock(ctx, "lock:events:create", 10*time.Second, func(ctx context.Context) error {
 		return s.runTransaction(ctx, func(txCtx context.Context) error {
@@ -89,6 +94,9 @@ func (s *eventService) Update(ctx context.Context, id string, req model.UpdateEv
 		}
 		return model.EventResponse{}, core.InternalServerError("failed to fetch event").WithError(err)
 	}
+	if err := authorize(ctx, rbac.EventsUpdate, eventAttrs(ev)); err != nil {
+		return model.EventResponse{}, err
+	}
 
 	if req.Slug != nil {
 		ev.Slug = req.Slug
@@ -125,6 +133,13 @@ func (s *eventService) Update(ctx context.Context, id string, req model.UpdateEv
 		return model.EventResponse{}, core.BadRequest("ends_at must be after starts_at")
 	}
 
+	// A program-scoped editor must not move the event out of their program
+	if req.Program != nil {
+		if err := authorize(ctx, rbac.EventsUpdate, eventAttrs(ev)); err != nil {
+			return model.EventResponse{}, err
+		}
+	}
+
 	if err := s.locker.WithLock(ctx, "lock:events:"+id, 10*time.Second, func(ctx context.Context) error {
 		return s.runTransaction(ctx, func(txCtx context.Context) error {
 			return s.repo.UpdateEvent(txCtx, ev)
@@ -144,6 +159,18 @@ func (s *eventService) Delete(ctx context.Context, id string) error {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "EventService.Delete")()
 	}
+
+	ev, err := s.repo.GetEventByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("event", id)
+		}
+		return core.InternalServerError("failed to fetch event").WithError(err)
+	}
+	if err := authorize(ctx, rbac.EventsDelete, eventAttrs(ev)); err != nil {
+		return err
+	}
+
 	return s.locker.WithLock(ctx, "lock:events:"+id, 10*time.Second, func(ctx context.Context) error {
 		return s.runTransaction(ctx, func(txCtx context.Context) error {
 			if err := s.repo.DeleteEvent(txCtx, id); err != nil {
@@ -178,6 +205,9 @@ func (s *eventService) SetStatus(ctx context.Context, req model.SetEventStatusRe
 		}
 		return model.EventResponse{}, core.InternalServerError("failed to fetch event").WithError(err)
 	}
+	if err := authorize(ctx, rbac.EventsUpdate, eventAttrs(ev)); err != nil {
+		return model.EventResponse{}, err
+	}
 
 	ev.Status = req.Status
 
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+	"gorm.io/gorm"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/mailer"
+	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/validator"
+)
+
+type invitationService struct {
+	repo           repository.InvitationRepository
+	authRepo       repository.AuthRepository
+	permissionRepo repository.PermissionRepository
+	auditRepo      repository.AuditLogRepository
+	outbox         EmailOutboxService
+	acceptURL      string
+	passwordPolicy auth.PasswordPolicy
+	defaultTTL     time.Duration
+	tracer         nr.Tracer
+	log            *core.Logger
+}
+
+// Invite creates an invitation and emails the accept link. An expired
+// invitation for the same email is replaced.
+func (s *invitationService) Invite(ctx context.Context, req model.CreateInvitationRequest, invitedBy string) (*model.InvitationResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.Invite")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+	if err := s.checkCanSend(); err != nil {
+		return nil, err
+	}
+	email := strings.TrimSpace(req.Email)
+
+	existingUser, err := s.authRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing user: %w", err)
+	}
+	if existingUser != nil {
+		return nil, core.Conflict("A user with this email already exists")
+	}
+
+	if err := s.validateRoles(ctx, req.RoleIDs); err != nil {
+		return nil, err
+	}
+
+	rawToken, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate invitation token").WithError(err)
+	}
+
+	ttl := s.defaultTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	inv := &model.UserInvitation{
+		Email:      email,
+		RoleIDs:    req.RoleIDs,
+		TokenHash:  tokenHash,
+		Status:     model.InvitationPending,
+		ExpiresAt:  time.Now().Add(ttl),
+		InvitedBy:  &invitedBy,
+		SendCount:  1,
+		LastSentAt: time.Now(),
+	}
+	if inv.RoleIDs == nil {
+		inv.RoleIDs = []string{}
+	}
+	if name := strings.TrimSpace(req.FullName); name != "" {
+		inv.FullName = &name
+	}
+
+	err = s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		open, err := s.repo.FindPendingByEmail(txCtx, email)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if open != nil {
+			if time.Now().Before(open.ExpiresAt) {
+				return core.Conflict("This email already has a pending invitation; resend it instead").
+					WithDetail("invitation_id", open.ID)
+			}
+			if err := s.repo.Revoke(txCtx, open.ID, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.Create(txCtx, inv); err != nil {
+			return err
+		}
+		return s.sendInvitation(txCtx, inv, rawToken, invitedBy)
+	})
+	if err != nil {
+		if _, ok := core.IsAppError(err); ok {
+			return nil, err
+		}
+		return nil, core.InternalServerError("failed to create invitation").WithError(err)
+	}
+
+	s.auditLog(ctx, &invitedBy, "invitation.created", strPtr("user_invitations"), &inv.ID, map[string]interface{}{
+		"email":      inv.Email,
+		"role_ids":   inv.RoleIDs,
+		"expires_at": inv.ExpiresAt,
+	})
+
+	resp := model.InvitationToResponse(*inv)
+	return &resp, nil
+}
+
+func (s *invitationService) List(ctx context.Context, p repository.ListParams) (model.InvitationListResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.List")()
+	}
+
+	pr, err := s.repo.List(ctx, p)
+	if err != nil {
+		return model.InvitationListResponse{}, core.InternalServerError("failed to list invitations").WithError(err)
+	}
+
+	data := make([]model.InvitationResponse, 0, len(pr.Data))
+	for _, m := range pr.Data {
+		data = append(data, model.InvitationToResponse(m))
+	}
+	return model.InvitationListResponse{
+		Data:       data,
+		Total:      pr.Total,
+		Page:       pr.Page,
+		PageSize:   pr.PageSize,
+		TotalPages: pr.TotalPages,
+	}, nil
+}
+
+// Resend issues a fresh link (the old one stops working) and restarts the
+// expiry window
+func (s *invitationService) Resend(ctx context.Context, id, actorID string) (*model.InvitationResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.Resend")()
+	}
+
+	if err := s.checkCanSend(); err != nil {
+		return nil, err
+	}
+
+	inv, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.NotFound("invitation", id)
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if inv.Status != model.InvitationPending {
+		return nil, core.Conflict(fmt.Sprintf("Invitation is already %s", inv.Status))
+	}
+
+	rawToken, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, core.InternalServerError("failed to generate invitation token").WithError(err)
+	}
+	expiresAt := time.Now().Add(s.defaultTTL)
+
+	err = s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.repo.Reissue(txCtx, inv.ID, tokenHash, expiresAt); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.Conflict("Invitation is no longer pending")
+			}
+			return err
+		}
+		return s.sendInvitation(txCtx, inv, rawToken, actorID)
+	})
+	if err != nil {
+		if _, ok := core.IsAppError(err); ok {
+			return nil, err
+		}
+		return nil, core.InternalServerError("failed to resend invitation").WithError(err)
+	}
+
+	s.auditLog(ctx, &actorID, "invitation.resent", strPtr("user_invitations"), &inv.ID, map[string]interface{}{
+		"email":      inv.Email,
+		"send_count": inv.SendCount + 1,
+		"expires_at": expiresAt,
+	})
+
+	updated, err := s.repo.GetByID(ctx, inv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	resp := model.InvitationToResponse(*updated)
+	return &resp, nil
+}
+
+// Revoke cancels a pending invitation
+func (s *invitationService) Revoke(ctx context.Context, id, actorID string) error {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.Revoke")()
+	}
+
+	inv, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("invitation", id)
+		}
+		return fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	if err := s.repo.Revoke(ctx, id, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.Conflict(fmt.Sprintf("Invitation is already %s", inv.Status))
+		}
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	s.auditLog(ctx, &actorID, "invitation.revoked", strPtr("user_invitations"), &id, map[string]interface{}{
+		"email": inv.Email,
+	})
+
+	return nil
+}
+
+// Preview returns what the invitee needs to fill in the accept page
+func (s *invitationService) Preview(ctx context.Context, token string) (*model.InvitationPreviewResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.Preview")()
+	}
+
+	inv, err := s.findPending(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.InvitationPreviewResponse{
+		Email:     inv.Email,
+		FullName:  inv.FullName,
+		ExpiresAt: inv.ExpiresAt,
+	}
+	if inv.Inviter != nil {
+		resp.InvitedBy = inv.Inviter.FullName
+	}
+	return resp, nil
+}
+
+// AcceptWithPassword creates the invited user with the chosen password
+func (s *invitationService) AcceptWithPassword(ctx context.Context, req model.AcceptInvitationRequest) (*model.UserResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.AcceptWithPassword")()
+	}
+
+	if err := validator.Validate(req); err != nil {
+		return nil, core.ValidationError(err)
+	}
+
+	inv, err := s.findPending(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := invitationFullName(inv, req.FullName)
+	if err := checkPasswordPolicy(s.log, s.passwordPolicy, "password", req.Password, inv.Email, fullName); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Email:        inv.Email,
+		PasswordHash: &hashedPassword,
+		FullName:     fullName,
+		IsActive:     true,
+	}
+	return s.accept(ctx, inv, user, nil, "password")
+}
+
+// AcceptWithOAuth creates the invited user linked to the provider account
+// they signed in with. The user's email is the invited one.
+func (s *invitationService) AcceptWithOAuth(ctx context.Context, token, provider, providerID, fullName string, providerData map[string]interface{}) (*model.UserResponse, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "InvitationService.AcceptWithOAuth")()
+	}
+
+	inv, err := s.findPending(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.authRepo.GetOAuthAccount(ctx, provider, providerID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check existing OAuth account: %w", err)
+	}
+	if linked != nil {
+		return nil, core.Conflict("This provider account is already linked to another user")
+	}
+
+	user := &model.User{
+		Email:    inv.Email,
+		FullName: invitationFullName(inv, fullName),
+		IsActive: true,
+	}
+	if user.FullName == "" {
+		user.FullName = inv.Email
+	}
+	account := &model.OAuthAccount{
+		Provider:     provider,
+		ProviderID:   providerID,
+		ProviderData: providerData,
+	}
+	return s.accept(ctx, inv, user, account, "oauth:"+provider)
+}
+
+// accept consumes the invitation and creates the user with the invited
+// roles in one transaction
+func (s *invitationService) accept(ctx context.Context, inv *model.UserInvitation, user *model.User, account *model.OAuthAccount, method string) (*model.UserResponse, error) {
+	if user.FullName == "" {
+		return nil, core.BadRequest("full_name is required")
+	}
+
+	var userResp *model.UserResponse
+	err := s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		existing, err := s.authRepo.GetUserByEmail(txCtx, inv.Email)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if existing != nil {
+			return core.Conflict("A user with this email already exists")
+		}
+
+		if err := s.authRepo.CreateUser(txCtx, user); err != nil {
+			return err
+		}
+
+		// Claim the invitation; a concurrent accept loses here
+		if err := s.repo.MarkAccepted(txCtx, inv.ID, user.ID, time.Now()); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return core.BadRequest("invalid or expired invitation")
+			}
+			return err
+		}
+
+		// Roles deleted since the invitation was sent are skipped
+		if len(inv.RoleIDs) > 0 {
+			roles, err := s.permissionRepo.GetRolesByIDs(txCtx, inv.RoleIDs)
+			if err != nil {
+				return err
+			}
+			roleIDs := make([]string, 0, len(roles))
+			for _, role := range roles {
+				roleIDs = append(roleIDs, role.ID)
+			}
+			if len(roleIDs) > 0 {
+				if err := s.authRepo.AssignRolesToUser(txCtx, user.ID, roleIDs, inv.InvitedBy); err != nil {
+					return err
+				}
+			}
+		}
+
+		if account != nil {
+			account.UserID = user.ID
+			if err := s.authRepo.CreateOAuthAccount(txCtx, account); err != nil {
+				return err
+			}
+		}
+
+		userWithRoles, err := s.authRepo.GetUserWithRoles(txCtx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp := userWithRoles.ToUserResponse()
+		userResp = &resp
+		return nil
+	})
+	if err != nil {
+		s.auditLog(ctx, nil, "invitation.accept.failed", strPtr("user_invitations"), &inv.ID, map[string]interface{}{
+			"email":  inv.Email,
+			"method": method,
+			"reason": err.Error(),
+		})
+		if _, ok := core.IsAppError(err); ok {
+			return nil, err
+		}
+		return nil, core.InternalServerError("failed to accept invitation").WithError(err)
+	}
+
+	s.auditLog(ctx, &user.ID, "invitation.accepted", strPtr("user_invitations"), &inv.ID, map[string]interface{}{
+		"email":      inv.Email,
+		"method":     method,
+		"role_ids":   inv.RoleIDs,
+		"invited_by": inv.InvitedBy,
+	})
+
+	return userResp, nil
+}
+
+func (s *invitationService) findPending(ctx context.Context, token string) (*model.UserInvitation, error) {
+	sum := sha256.Sum256([]byte(token))
+	inv, err := s.repo.FindPendingByHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.BadRequest("invalid or expired invitation")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *invitationService) validateRoles(ctx context.Context, roleIDs []string) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	roles, err := s.permissionRepo.GetRolesByIDs(ctx, roleIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+	found := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		found[role.ID] = true
+	}
+	for _, id := range roleIDs {
+		if !found[id] {
+			return core.BadRequest("unknown role").WithDetail("role_id", id)
+		}
+	}
+	return nil
+}
+
+// checkCanSend refuses to create an invitation that could not be emailed,
+// so an admin is never told an invite went out when it did not
+func (s *invitationService) checkCanSend() error {
+	if s.outbox == nil || s.acceptURL == "" {
+		return core.ServiceUnavailable("invitation emails are not configured")
+	}
+	return nil
+}
+
+// sendInvitation queues the invitation email in the caller's transaction
+func (s *invitationService) sendInvitation(ctx context.Context, inv *model.UserInvitation, rawToken, actorID string) error {
+	if err := s.checkCanSend(); err != nil {
+		return err
+	}
+
+	inviterName := "An administrator"
+	if actor, err := s.authRepo.GetUserByID(ctx, actorID); err == nil {
+		inviterName = actor.FullName
+	}
+
+	name := ""
+	if inv.FullName != nil {
+		name = *inv.FullName
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.acceptURL, rawToken)
+	body, err := mailer.RenderInvitationEmail(name, inviterName, link, inv.ExpiresAt.Format("2 January 2006"))
+	if err != nil {
+		return fmt.Errorf("failed to render invitation email: %w", err)
+	}
+	return s.outbox.Enqueue(ctx, inv.Email, "You're Invited to ITTS Community", body)
+}
+
+// invitationFullName prefers the name the invitee entered over the one the
+// admin suggested
+func invitationFullName(inv *model.UserInvitation, entered string) string {
+	if name := strings.TrimSpace(entered); name != "" {
+		return name
+	}
+	if inv.FullName != nil {
+		return *inv.FullName
+	}
+	return ""
+}
+
+func (s *invitationService) auditLog(ctx context.Context, userID *string, action string, resourceType *string, resourceID *string, metadata map[string]interface{}) {
+	log := &model.AuditLog{
+		UserID:         userID,
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Metadata:       metadata,
+		IPAddress:      getIPFromContext(ctx),
+		UserAgent:      getUserAgentFromContext(ctx),
+		APITokenID:     getAPITokenIDFromContext(ctx),
+		ImpersonatorID: getImpersonatorIDFromContext(ctx),
+	}
+
+	// Non-blocking audit log (fire and forget)
+	go func() {
+		_ = s.auditRepo.CreateAuditLog(context.Background(), log)
+	}()
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/observability/nr"
+)
+
+// InvitationService lets admins invite people by email with pre-selected
+// roles. The invitee accepts by choosing a password or signing in with an
+// OAuth provider.
+type InvitationService interface {
+	// Admin
+	Invite(ctx context.Context, req model.CreateInvitationRequest, invitedBy string) (*model.InvitationResponse, error)
+	List(ctx context.Context, p repository.ListParams) (model.InvitationListResponse, error)
+	Resend(ctx context.Context, id, actorID string) (*model.InvitationResponse, error)
+	Revoke(ctx context.Context, id, actorID string) error
+
+	// Invitee
+	Preview(ctx context.Context, token string) (*model.InvitationPreviewResponse, error)
+	AcceptWithPassword(ctx context.Context, req model.AcceptInvitationRequest) (*model.UserResponse, error)
+	AcceptWithOAuth(ctx context.Context, token, provider, providerID, fullName string, providerData map[string]interface{}) (*model.UserResponse, error)
+}
+
+func NewInvitationService(
+	repo repository.InvitationRepository,
+	authRepo repository.AuthRepository,
+	permissionRepo repository.PermissionRepository,
+	auditRepo repository.AuditLogRepository,
+	outbox EmailOutboxService,
+	acceptURL string,
+	passwordPolicy auth.PasswordPolicy,
+	tracer nr.Tracer,
+	log *core.Logger,
+) InvitationService {
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "invitations"})
+	}
+	return &invitationService{
+		repo:           repo,
+		authRepo:       authRepo,
+		permissionRepo: permissionRepo,
+		auditRepo:      auditRepo,
+		outbox:         outbox,
+		acceptURL:      acceptURL,
+		passwordPolicy: passwordPolicy.WithDefaults(),
+		defaultTTL:     7 * 24 * time.Hour,
+		tracer:         tracer,
+		log:            log,
+	}
+}
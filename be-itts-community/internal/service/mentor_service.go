@@ -9,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"be-itts-community/internal/model"
+	"be-itts-community/internal/rbac"
 	"be-itts-community/internal/repository"
 	"be-itts-community/pkg/lock"
 	"be-itts-community/pkg/observability/nr"
@@ -31,6 +32,10 @@ func (s *mentorService) Create(ctx context.Context, req model.CreateMentorReques
 	}
 
 	m := req.ToModel()
+	m.CreatedBy = getUserIDFromContext(ctx)
+	if err := authorize(ctx, rbac.MentorsCreate, mentorAttrs(&m)); err != nil {
+		return model.MentorResponse{}, err
+	}
 
 	if err := s.locker.WithLock(ctx, "lock:mentors:create", 5*time.Second, func(ctx context.Context) error {
 		return s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
@@ -70,6 +75,9 @@ func (s *mentorService) Update(ctx context.Context, id string, req model.UpdateM
 		}
 		return model.MentorResponse{}, core.InternalServerError("failed to fetch mentor").WithError(err)
 	}
+	if err := authorize(ctx, rbac.MentorsUpdate, mentorAttrs(m)); err != nil {
+		return model.MentorResponse{}, err
+	}
 
 	if req.FullName != nil {
 		m.FullName = *req.FullName
@@ -93,6 +101,13 @@ func (s *mentorService) Update(ctx context.Context, id string, req model.UpdateM
 		m.Priority = *req.Priority
 	}
 
+	// A program-scoped editor must keep the mentor within their programs
+	if req.Programs != nil {
+		if err := authorize(ctx, rbac.MentorsUpdate, mentorAttrs(m)); err != nil {
+			return model.MentorResponse{}, err
+		}
+	}
+
 	if err := s.locker.WithLock(ctx, "lock:mentors:"+id, 5*time.Second, func(ctx context.Context) error {
 		return s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
 			return s.repo.Update(txCtx, m)
@@ -108,6 +123,18 @@ func (s *mentorService) Delete(ctx context.Context, id string) error {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "MentorService.Delete")()
 	}
+
+	m, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("mentor", id)
+		}
+		return core.InternalServerError("failed to fetch mentor").WithError(err)
+	}
+	if err := authorize(ctx, rbac.MentorsDelete, mentorAttrs(m)); err != nil {
+		return err
+	}
+
 	return s.locker.WithLock(ctx, "lock:mentors:"+id, 5*time.Second, func(ctx context.Context) error {
 		return s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
 			return s.repo.Delete(txCtx, id)
@@ -139,6 +166,9 @@ func (s *mentorService) SetActive(ctx context.Context, req model.SetMentorActive
 		}
 		return model.MentorResponse{}, core.InternalServerError("failed to fetch mentor").WithError(err)
 	}
+	if err := authorize(ctx, rbac.MentorsActivate, mentorAttrs(m)); err != nil {
+		return model.MentorResponse{}, err
+	}
 
 	m.IsActive = req.Active
 
@@ -169,6 +199,9 @@ func (s *mentorService) SetPriority(ctx context.Context, req model.SetMentorPrio
 		}
 		return model.MentorResponse{}, core.InternalServerError("failed to fetch mentor").WithError(err)
 	}
+	if err := authorize(ctx, rbac.MentorsUpdate, mentorAttrs(m)); err != nil {
+		return model.MentorResponse{}, err
+	}
 
 	m.Priority = req.Priority
 
@@ -31,6 +31,7 @@ func (s *partnerService) Create(ctx context.Context, req model.CreatePartnerRequ
 	}
 
 	p := req.ToModel()
+	p.CreatedBy = getUserIDFromContext(ctx)
 
 	if err := s.locker.WithLock(ctx, "lock:partners:create", 5*time.Second, func(ctx context.Context) error {
 		return s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
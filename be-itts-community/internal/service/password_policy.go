@@ -0,0 +1,29 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/pkg/auth"
+	"be-itts-community/pkg/validator"
+)
+
+// checkPasswordPolicy returns a validation error on field when password
+// violates the policy. personal is the user's email and name.
+func checkPasswordPolicy(log *core.Logger, policy auth.PasswordPolicy, field, password string, personal ...string) error {
+	violations, err := policy.Check(password, personal...)
+	if err != nil {
+		// Fail open: an unreadable breach list must not block password changes
+		log.WithError(err).Warn("failed to check breached passwords")
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	message := strings.Join(violations, "; ")
+	verr := validator.ValidationErrors{{Field: field, Message: message}}
+	return core.ValidationError(verr).
+		WithDetail("fields", core.FieldErrors{field: message}).
+		WithError(verr)
+}
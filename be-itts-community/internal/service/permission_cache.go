@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+	"be-itts-community/pkg/permcache"
+)
+
+// userWithPermissions loads a user with their roles and permission list like
+// AuthRepository.GetUserWithPermissions, reading the list through the cache
+func userWithPermissions(
+	ctx context.Context,
+	authRepo repository.AuthRepository,
+	permissionRepo repository.PermissionRepository,
+	cache *permcache.Cache,
+	userID string,
+) (*model.User, []string, error) {
+	user, err := authRepo.GetUserWithRoles(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.IsSuperAdmin {
+		return user, []string{"*:*"}, nil
+	}
+
+	permissions, err := cache.Load(ctx, userID, func(ctx context.Context) ([]string, error) {
+		return permissionRepo.GetUserPermissionNames(ctx, userID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, permissions, nil
+}
+
+// dropCachedPermissions invalidates the cached permissions of users whose
+// roles changed. The change is already committed, so a failure is only
+// logged; the entry expires with the cache TTL.
+func dropCachedPermissions(ctx context.Context, log *core.Logger, cache *permcache.Cache, userIDs ...string) {
+	if err := cache.Invalidate(ctx, userIDs...); err != nil {
+		log.WithError(err).WithField("user_ids", userIDs).Warn("failed to invalidate permission cache")
+	}
+}
+
+// dropAllCachedPermissions invalidates every cached permission list, for role
+// changes that reach an unknown set of users
+func dropAllCachedPermissions(ctx context.Context, log *core.Logger, cache *permcache.Cache) {
+	if err := cache.InvalidateAll(ctx); err != nil {
+		log.WithError(err).Warn("failed to invalidate permission cache")
+	}
+}
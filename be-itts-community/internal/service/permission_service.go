@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/daisyorscry/itts/core"
 	"gorm.io/gorm"
@@ -11,39 +12,53 @@ import (
 	"be-itts-community/internal/model"
 	"be-itts-community/internal/repository"
 	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
+	"be-itts-community/pkg/validator"
 )
 
 type permissionService struct {
 	permissionRepo repository.PermissionRepository
 	auditRepo      repository.AuditLogRepository
+	cache          *permcache.Cache
 	tracer         nr.Tracer
+	log            *core.Logger
 }
 
-// NewPermissionService creates a new permission service
+// NewPermissionService creates a new permission service. cache and log may
+// be nil.
 func NewPermissionService(
 	permissionRepo repository.PermissionRepository,
 	auditRepo repository.AuditLogRepository,
+	cache *permcache.Cache,
 	tracer nr.Tracer,
+	log *core.Logger,
 ) PermissionService {
+	if log == nil {
+		log = core.NewLogger(core.LogConfig{Level: core.LevelInfo, ServiceName: "permissions"})
+	}
 	return &permissionService{
 		permissionRepo: permissionRepo,
 		auditRepo:      auditRepo,
+		cache:          cache,
 		tracer:         tracer,
+		log:            log,
 	}
 }
 
-// CheckPermission checks if user has specific permission
+// CheckPermission checks if user has specific permission. The decision is
+// made on the user's cached permission list, like the repository query:
+// scoped grants do not count and denies win.
 func (s *permissionService) CheckPermission(ctx context.Context, userID string, permission string) (bool, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "PermissionService.CheckPermission")()
 	}
 
-	hasPermission, err := s.permissionRepo.CheckUserHasPermission(ctx, userID, permission)
+	permissions, err := s.userPermissions(ctx, userID)
 	if err != nil {
 		return false, fmt.Errorf("failed to check permission: %w", err)
 	}
 
-	return hasPermission, nil
+	return model.PermissionsCover(permissions, permission), nil
 }
 
 // GetUserPermissions retrieves all permissions for a user
@@ -52,7 +67,7 @@ func (s *permissionService) GetUserPermissions(ctx context.Context, userID strin
 		defer s.tracer.StartSegment(ctx, "PermissionService.GetUserPermissions")()
 	}
 
-	permissions, err := s.permissionRepo.GetUserPermissionNames(ctx, userID)
+	permissions, err := s.userPermissions(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user permissions: %w", err)
 	}
@@ -60,6 +75,18 @@ func (s *permissionService) GetUserPermissions(ctx context.Context, userID strin
 	return permissions, nil
 }
 
+// userPermissions reads the permission list of a user through the cache
+func (s *permissionService) userPermissions(ctx context.Context, userID string) ([]string, error) {
+	return s.cache.Load(ctx, userID, func(ctx context.Context) ([]string, error) {
+		return s.permissionRepo.GetUserPermissionNames(ctx, userID)
+	})
+}
+
+// CacheStats reports the permission cache counters
+func (s *permissionService) CacheStats() permcache.Stats {
+	return s.cache.Stats()
+}
+
 // CreateRole creates a new role
 func (s *permissionService) CreateRole(ctx context.Context, req model.CreateRoleRequest) (*model.RoleResponse, error) {
 	if s.tracer != nil {
@@ -75,11 +102,20 @@ func (s *permissionService) CreateRole(ctx context.Context, req model.CreateRole
 		return nil, core.Conflict("Role name already exists")
 	}
 
+	if req.ParentRoleID != nil && *req.ParentRoleID != "" {
+		if err := s.checkRoleParent(ctx, "", *req.ParentRoleID); err != nil {
+			return nil, err
+		}
+	} else {
+		req.ParentRoleID = nil
+	}
+
 	// Create role
 	role := &model.Role{
 		Name:         req.Name,
 		Description:  req.Description,
 		ParentRoleID: req.ParentRoleID,
+		RequiresMFA:  req.RequiresMFA,
 		IsSystem:     false, // User-created roles are not system roles
 	}
 
@@ -93,7 +129,7 @@ func (s *permissionService) CreateRole(ctx context.Context, req model.CreateRole
 
 		// Assign permissions if provided
 		if len(req.PermissionIDs) > 0 {
-			if err := s.permissionRepo.AssignPermissionsToRole(txCtx, role.ID, req.PermissionIDs); err != nil {
+			if err := s.permissionRepo.AssignPermissionsToRole(txCtx, role.ID, req.PermissionIDs, "", model.PermissionAllow); err != nil {
 				return err
 			}
 		}
@@ -210,7 +246,18 @@ func (s *permissionService) UpdateRole(ctx context.Context, roleID string, req m
 		role.Description = req.Description
 	}
 	if req.ParentRoleID != nil {
-		role.ParentRoleID = req.ParentRoleID
+		// An empty parent detaches the role from the hierarchy
+		if *req.ParentRoleID == "" {
+			role.ParentRoleID = nil
+		} else {
+			if err := s.checkRoleParent(ctx, roleID, *req.ParentRoleID); err != nil {
+				return nil, err
+			}
+			role.ParentRoleID = req.ParentRoleID
+		}
+	}
+	if req.RequiresMFA != nil {
+		role.RequiresMFA = *req.RequiresMFA
 	}
 
 	// Update role and permissions in transaction
@@ -221,6 +268,13 @@ func (s *permissionService) UpdateRole(ctx context.Context, roleID string, req m
 			return err
 		}
 
+		// Members' access tokens no longer reflect the role
+		if req.PermissionIDs != nil || req.ParentRoleID != nil {
+			if err := s.permissionRepo.BumpRoleMembersTokenVersion(txCtx, roleID); err != nil {
+				return err
+			}
+		}
+
 		// Update permissions if provided
 		if req.PermissionIDs != nil {
 			// Remove all existing permissions
@@ -237,7 +291,7 @@ func (s *permissionService) UpdateRole(ctx context.Context, roleID string, req m
 
 			// Assign new permissions
 			if len(req.PermissionIDs) > 0 {
-				if err := s.permissionRepo.AssignPermissionsToRole(txCtx, roleID, req.PermissionIDs); err != nil {
+				if err := s.permissionRepo.AssignPermissionsToRole(txCtx, roleID, req.PermissionIDs, "", model.PermissionAllow); err != nil {
 					return err
 				}
 			}
@@ -257,6 +311,9 @@ func (s *permissionService) UpdateRole(ctx context.Context, roleID string, req m
 	if err != nil {
 		return nil, fmt.Errorf("failed to update role: %w", err)
 	}
+	if req.PermissionIDs != nil || req.ParentRoleID != nil {
+		dropAllCachedPermissions(ctx, s.log, s.cache)
+	}
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -287,10 +344,17 @@ func (s *permissionService) DeleteRole(ctx context.Context, roleID string) error
 		return core.Forbidden("Cannot delete system role")
 	}
 
-	// Delete role
-	if err := s.permissionRepo.DeleteRole(ctx, roleID); err != nil {
+	// Delete role (bump members first, memberships go with the role)
+	err = s.permissionRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.permissionRepo.BumpRoleMembersTokenVersion(txCtx, roleID); err != nil {
+			return err
+		}
+		return s.permissionRepo.DeleteRole(txCtx, roleID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete role: %w", err)
 	}
+	dropAllCachedPermissions(ctx, s.log, s.cache)
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -301,12 +365,30 @@ func (s *permissionService) DeleteRole(ctx context.Context, roleID string) error
 	return nil
 }
 
-// AssignPermissionsToRole assigns permissions to a role
-func (s *permissionService) AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string) error {
+// AssignPermissionsToRole assigns permissions to a role by ID or by name.
+// Wildcard names are validated against the catalogue and added to it on
+// first use. With effect "deny" the role denies them instead.
+func (s *permissionService) AssignPermissionsToRole(ctx context.Context, roleID string, req model.AssignPermissionsRequest) error {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "PermissionService.AssignPermissionsToRole")()
 	}
 
+	if err := validator.Validate(req); err != nil {
+		return core.ValidationError(err)
+	}
+	if len(req.PermissionIDs) == 0 && len(req.Permissions) == 0 {
+		return core.BadRequest("permission_ids or permissions is required")
+	}
+	if !model.ValidPermissionScope(req.Scope) {
+		return core.BadRequest("scope must be \"own\" or \"program:<program>\"").WithDetail("scope", req.Scope)
+	}
+	if req.Effect == "" {
+		req.Effect = model.PermissionAllow
+	}
+	if req.Effect == model.PermissionDeny && req.Scope != "" {
+		return core.BadRequest("deny entries cannot be scoped")
+	}
+
 	// Check if role exists
 	role, err := s.permissionRepo.GetRoleByID(ctx, roleID)
 	if err != nil {
@@ -322,19 +404,144 @@ func (s *permissionService) AssignPermissionsToRole(ctx context.Context, roleID
 	}
 
 	// Assign permissions
-	if err := s.permissionRepo.AssignPermissionsToRole(ctx, roleID, permissionIDs); err != nil {
+	var permissions []model.Permission
+	err = s.permissionRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		permissions, err = s.resolvePermissions(txCtx, req.PermissionIDs, req.Permissions)
+		if err != nil {
+			return err
+		}
+		if req.Scope != "" {
+			for _, perm := range permissions {
+				if !scopablePermission(perm.Name) {
+					return core.BadRequest("permission cannot be scoped").WithDetail("permission", perm.Name)
+				}
+			}
+		}
+
+		permissionIDs := make([]string, len(permissions))
+		for i, perm := range permissions {
+			permissionIDs[i] = perm.ID
+		}
+		if err := s.permissionRepo.AssignPermissionsToRole(txCtx, roleID, permissionIDs, req.Scope, req.Effect); err != nil {
+			return err
+		}
+		return s.permissionRepo.BumpRoleMembersTokenVersion(txCtx, roleID)
+	})
+	if err != nil {
+		if _, ok := core.IsAppError(err); ok {
+			return err
+		}
 		return fmt.Errorf("failed to assign permissions: %w", err)
 	}
+	dropAllCachedPermissions(ctx, s.log, s.cache)
 
 	// Audit log
+	permissionIDs := make([]string, len(permissions))
+	names := make([]string, len(permissions))
+	for i, perm := range permissions {
+		permissionIDs[i] = perm.ID
+		names[i] = perm.Name
+	}
 	adminID := getUserIDFromContext(ctx)
 	s.auditLog(ctx, adminID, "role.permissions.assign", strPtr("roles"), &roleID, map[string]interface{}{
-		"permission_ids": permissionIDs,
+		"permission_ids":   permissionIDs,
+		"permission_names": names,
+		"scope":            req.Scope,
+		"effect":           req.Effect,
 	})
 
 	return nil
 }
 
+// resolvePermissions loads the permissions to assign. IDs must exist; names
+// must be "resource:action" patterns whose parts are catalogue entries or
+// "*". A wildcard pattern missing from the catalogue is created.
+func (s *permissionService) resolvePermissions(ctx context.Context, ids, names []string) ([]model.Permission, error) {
+	var out []model.Permission
+	seen := make(map[string]bool)
+
+	if len(ids) > 0 {
+		permissions, err := s.permissionRepo.GetPermissionsByIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get permissions: %w", err)
+		}
+		for _, perm := range permissions {
+			seen[perm.ID] = true
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				return nil, core.BadRequest("unknown permission").WithDetail("permission_id", id)
+			}
+		}
+		out = append(out, permissions...)
+	}
+
+	for _, name := range names {
+		perm, err := s.permissionFromPattern(ctx, strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[perm.ID] {
+			seen[perm.ID] = true
+			out = append(out, *perm)
+		}
+	}
+
+	return out, nil
+}
+
+// permissionFromPattern looks up a permission by name, creating wildcard
+// patterns the catalogue does not have yet
+func (s *permissionService) permissionFromPattern(ctx context.Context, name string) (*model.Permission, error) {
+	resourceName, actionName, ok := model.SplitPermission(name)
+	if !ok {
+		return nil, core.BadRequest("permission must be in the form resource:action").WithDetail("permission", name)
+	}
+	if resourceName == model.PermissionWildcard && actionName == model.PermissionWildcard {
+		return nil, core.BadRequest("*:* cannot be assigned to a role; use a super admin account instead").WithDetail("permission", name)
+	}
+
+	perm, err := s.permissionRepo.GetPermissionByName(ctx, name)
+	if err == nil {
+		return perm, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+
+	// Concrete permissions are only those in the catalogue
+	if resourceName != model.PermissionWildcard && actionName != model.PermissionWildcard {
+		return nil, core.BadRequest("unknown permission").WithDetail("permission", name)
+	}
+
+	resource, err := s.permissionRepo.GetResourceByName(ctx, resourceName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.BadRequest("unknown resource").WithDetail("permission", name)
+		}
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	action, err := s.permissionRepo.GetActionByName(ctx, actionName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, core.BadRequest("unknown action").WithDetail("permission", name)
+		}
+		return nil, fmt.Errorf("failed to get action: %w", err)
+	}
+
+	perm = &model.Permission{
+		ResourceID: resource.ID,
+		ActionID:   action.ID,
+		Name:       name,
+		Resource:   *resource,
+		Action:     *action,
+	}
+	if err := s.permissionRepo.CreatePermission(ctx, perm); err != nil {
+		return nil, fmt.Errorf("failed to create permission: %w", err)
+	}
+	return perm, nil
+}
+
 // RemovePermissionsFromRole removes permissions from a role
 func (s *permissionService) RemovePermissionsFromRole(ctx context.Context, roleID string, permissionIDs []string) error {
 	if s.tracer != nil {
@@ -356,9 +563,16 @@ func (s *permissionService) RemovePermissionsFromRole(ctx context.Context, roleI
 	}
 
 	// Remove permissions
-	if err := s.permissionRepo.RemovePermissionsFromRole(ctx, roleID, permissionIDs); err != nil {
+	err = s.permissionRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.permissionRepo.RemovePermissionsFromRole(txCtx, roleID, permissionIDs); err != nil {
+			return err
+		}
+		return s.permissionRepo.BumpRoleMembersTokenVersion(txCtx, roleID)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to remove permissions: %w", err)
 	}
+	dropAllCachedPermissions(ctx, s.log, s.cache)
 
 	// Audit log
 	adminID := getUserIDFromContext(ctx)
@@ -369,23 +583,101 @@ func (s *permissionService) RemovePermissionsFromRole(ctx context.Context, roleI
 	return nil
 }
 
-// GetRolePermissions retrieves all permissions for a role
-func (s *permissionService) GetRolePermissions(ctx context.Context, roleID string) ([]model.PermissionResponse, error) {
+// GetRolePermissions retrieves what a role allows and denies. With effective
+// set it also includes entries inherited from parent roles, each marked with
+// the ancestor it comes from.
+func (s *permissionService) GetRolePermissions(ctx context.Context, roleID string, effective bool) (*model.RolePermissionsResponse, error) {
 	if s.tracer != nil {
 		defer s.tracer.StartSegment(ctx, "PermissionService.GetRolePermissions")()
 	}
 
-	permissions, err := s.permissionRepo.GetRolePermissions(ctx, roleID)
+	grants, err := s.permissionRepo.GetRolePermissionGrants(ctx, roleID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get role permissions: %w", err)
 	}
 
-	respData := make([]model.PermissionResponse, len(permissions))
-	for i, perm := range permissions {
-		respData[i] = perm.ToPermissionResponse()
+	resp := &model.RolePermissionsResponse{
+		Allow: make([]model.PermissionResponse, 0, len(grants)),
+		Deny:  make([]model.PermissionResponse, 0),
+	}
+	seen := make(map[string]bool, len(grants))
+	add := func(grant model.RolePermission, inheritedFrom *string) {
+		key := string(grant.Effect) + grant.PermissionID + grant.Scope
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		perm := grantToResponse(grant)
+		perm.InheritedFrom = inheritedFrom
+		if grant.Effect == model.PermissionDeny {
+			resp.Deny = append(resp.Deny, perm)
+		} else {
+			resp.Allow = append(resp.Allow, perm)
+		}
 	}
 
-	return respData, nil
+	for _, grant := range grants {
+		add(grant, nil)
+	}
+	if !effective {
+		return resp, nil
+	}
+
+	// Nearest ancestor first, so a permission is attributed to the closest
+	// role that grants it
+	ancestors, err := s.permissionRepo.GetRoleAncestors(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent roles: %w", err)
+	}
+	for _, ancestor := range ancestors {
+		inherited, err := s.permissionRepo.GetRolePermissionGrants(ctx, ancestor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get role permissions: %w", err)
+		}
+		for _, grant := range inherited {
+			add(grant, strPtr(ancestor.Name))
+		}
+	}
+
+	return resp, nil
+}
+
+func grantToResponse(grant model.RolePermission) model.PermissionResponse {
+	resp := grant.Permission.ToPermissionResponse()
+	resp.Scope = grant.Scope
+	return resp
+}
+
+// checkRoleParent validates a new parent for roleID (empty for a role being
+// created): the parent must exist and must not have roleID among its own
+// ancestors, which would make the hierarchy cyclic
+func (s *permissionService) checkRoleParent(ctx context.Context, roleID, parentID string) error {
+	if parentID == roleID {
+		return core.BadRequest("A role cannot be its own parent")
+	}
+
+	if _, err := s.permissionRepo.GetRoleByID(ctx, parentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return core.NotFound("parent_role", parentID)
+		}
+		return fmt.Errorf("failed to get parent role: %w", err)
+	}
+
+	ancestors, err := s.permissionRepo.GetRoleAncestors(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent roles: %w", err)
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == roleID {
+			return core.BadRequest("Parent role would create a cycle in the role hierarchy").
+				WithDetail("parent_role_id", parentID)
+		}
+	}
+	if len(ancestors)+1 >= repository.MaxRoleDepth {
+		return core.BadRequest(fmt.Sprintf("Role hierarchy cannot be deeper than %d levels", repository.MaxRoleDepth))
+	}
+
+	return nil
 }
 
 // GetPermission retrieves permission by ID
@@ -493,13 +785,15 @@ func (s *permissionService) ListActions(ctx context.Context) ([]model.ActionResp
 // Helper: audit logging
 func (s *permissionService) auditLog(ctx context.Context, userID *string, action string, resourceType *string, resourceID *string, metadata map[string]interface{}) {
 	log := &model.AuditLog{
-		UserID:       userID,
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		Metadata:     metadata,
-		IPAddress:    getIPFromContext(ctx),
-		UserAgent:    getUserAgentFromContext(ctx),
+		UserID:         userID,
+		Action:         action,
+		ResourceType:   resourceType,
+		ResourceID:     resourceID,
+		Metadata:       metadata,
+		IPAddress:      getIPFromContext(ctx),
+		UserAgent:      getUserAgentFromContext(ctx),
+		APITokenID:     getAPITokenIDFromContext(ctx),
+		ImpersonatorID: getImpersonatorIDFromContext(ctx),
 	}
 
 	// Non-blocking audit log
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/rbac"
+)
+
+// SyncCatalogue upserts the resources, actions and permissions declared in
+// internal/rbac and reports catalogue permissions no code declares.
+// Wildcard patterns are created on assignment and are not orphans.
+func (s *permissionService) SyncCatalogue(ctx context.Context) (*model.CatalogueSyncReport, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "PermissionService.SyncCatalogue")()
+	}
+
+	report := &model.CatalogueSyncReport{
+		CreatedResources:   []string{},
+		CreatedActions:     []string{},
+		CreatedPermissions: []string{},
+		Orphaned:           []string{},
+	}
+
+	err := s.permissionRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		resourceIDs := make(map[string]string)
+		for _, entry := range rbac.Resources() {
+			resource, created, err := s.permissionRepo.EnsureResource(txCtx, entry.Name, entry.Description)
+			if err != nil {
+				return fmt.Errorf("failed to sync resource %s: %w", entry.Name, err)
+			}
+			resourceIDs[entry.Name] = resource.ID
+			if created {
+				report.CreatedResources = append(report.CreatedResources, entry.Name)
+			}
+		}
+
+		actionIDs := make(map[string]string)
+		for _, entry := range rbac.Actions() {
+			action, created, err := s.permissionRepo.EnsureAction(txCtx, entry.Name, entry.Description)
+			if err != nil {
+				return fmt.Errorf("failed to sync action %s: %w", entry.Name, err)
+			}
+			actionIDs[entry.Name] = action.ID
+			if created {
+				report.CreatedActions = append(report.CreatedActions, entry.Name)
+			}
+		}
+
+		for _, decl := range rbac.Permissions() {
+			created, err := s.permissionRepo.EnsurePermission(txCtx, &model.Permission{
+				ResourceID:  resourceIDs[decl.Resource],
+				ActionID:    actionIDs[decl.Action],
+				Name:        decl.Name,
+				Description: strPtr(decl.Description),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to sync permission %s: %w", decl.Name, err)
+			}
+			if created {
+				report.CreatedPermissions = append(report.CreatedPermissions, decl.Name)
+			}
+		}
+
+		names, err := s.permissionRepo.ListPermissionNames(txCtx)
+		if err != nil {
+			return fmt.Errorf("failed to list permissions: %w", err)
+		}
+		for _, name := range names {
+			if !rbac.IsDeclared(name) && !strings.Contains(name, model.PermissionWildcard) {
+				report.Orphaned = append(report.Orphaned, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(report.CreatedResources)+len(report.CreatedActions)+len(report.CreatedPermissions) > 0 {
+		s.auditLog(ctx, getUserIDFromContext(ctx), "permissions.catalogue.sync", strPtr("permissions"), nil, map[string]interface{}{
+			"created_resources":   report.CreatedResources,
+			"created_actions":     report.CreatedActions,
+			"created_permissions": report.CreatedPermissions,
+		})
+	}
+
+	return report, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"be-itts-community/internal/model"
+	"be-itts-community/pkg/permcache"
 )
 
 // PermissionService handles authorization and permission operations
@@ -11,6 +12,7 @@ type PermissionService interface {
 	// Authorization
 	CheckPermission(ctx context.Context, userID string, permission string) (bool, error)
 	GetUserPermissions(ctx context.Context, userID string) ([]string, error)
+	CacheStats() permcache.Stats
 
 	// Role Management
 	CreateRole(ctx context.Context, req model.CreateRoleRequest) (*model.RoleResponse, error)
@@ -20,14 +22,21 @@ type PermissionService interface {
 	DeleteRole(ctx context.Context, roleID string) error
 
 	// Role Permissions
-	AssignPermissionsToRole(ctx context.Context, roleID string, permissionIDs []string) error
+	AssignPermissionsToRole(ctx context.Context, roleID string, req model.AssignPermissionsRequest) error
 	RemovePermissionsFromRole(ctx context.Context, roleID string, permissionIDs []string) error
-	GetRolePermissions(ctx context.Context, roleID string) ([]model.PermissionResponse, error)
+	GetRolePermissions(ctx context.Context, roleID string, effective bool) (*model.RolePermissionsResponse, error)
 
 	// Permission Queries
 	GetPermission(ctx context.Context, permissionID string) (*model.PermissionResponse, error)
 	ListPermissions(ctx context.Context, search string, page, pageSize int, filters map[string]interface{}) (*model.PageResult[model.PermissionResponse], error)
 
+	// Catalogue
+	SyncCatalogue(ctx context.Context) (*model.CatalogueSyncReport, error)
+
+	// Export / Import
+	ExportRBAC(ctx context.Context) (*model.RBACDocument, error)
+	ImportRBAC(ctx context.Context, doc model.RBACDocument, dryRun bool) (*model.RBACImportResult, error)
+
 	// Resource & Action Queries
 	ListResources(ctx context.Context) ([]model.ResourceResponse, error)
 	ListActions(ctx context.Context) ([]model.ActionResponse, error)
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/daisyorscry/itts/core"
+
+	"be-itts-community/internal/model"
+	"be-itts-community/internal/repository"
+)
+
+// errDryRun rolls back a dry-run import after the diff has been computed
+var errDryRun = errors.New("rbac import dry run")
+
+// ExportRBAC describes every role, its parent and its grants by name
+func (s *permissionService) ExportRBAC(ctx context.Context) (*model.RBACDocument, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "PermissionService.ExportRBAC")()
+	}
+
+	roles, err := s.permissionRepo.ListAllRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	grants, err := s.permissionRepo.ListAllRolePermissionGrants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role permissions: %w", err)
+	}
+
+	names := make(map[string]string, len(roles))
+	for _, role := range roles {
+		names[role.ID] = role.Name
+	}
+	byRole := groupRoleGrants(grants)
+
+	doc := &model.RBACDocument{
+		Version: model.RBACDocumentVersion,
+		Roles:   make([]model.RBACRole, 0, len(roles)),
+	}
+	for _, role := range roles {
+		entry := model.RBACRole{
+			Name:        role.Name,
+			Description: role.Description,
+			RequiresMFA: role.RequiresMFA,
+			System:      role.IsSystem,
+			Allow:       grantsOf(byRole, role.ID).allow.sorted(),
+			Deny:        grantsOf(byRole, role.ID).deny.sorted(),
+		}
+		if role.ParentRoleID != nil {
+			entry.Parent = names[*role.ParentRoleID]
+		}
+		doc.Roles = append(doc.Roles, entry)
+	}
+
+	return doc, nil
+}
+
+// ImportRBAC brings the roles of doc to the described state in one
+// transaction: missing roles are created, attributes and parents updated and
+// grants replaced. Roles absent from doc are not touched. With dryRun the
+// transaction is rolled back, so the returned diff is exactly what an apply
+// would write.
+func (s *permissionService) ImportRBAC(ctx context.Context, doc model.RBACDocument, dryRun bool) (*model.RBACImportResult, error) {
+	if s.tracer != nil {
+		defer s.tracer.StartSegment(ctx, "PermissionService.ImportRBAC")()
+	}
+
+	if doc.Version != model.RBACDocumentVersion {
+		return nil, core.BadRequest("unsupported rbac document version").WithDetail("version", doc.Version)
+	}
+	seen := make(map[string]bool, len(doc.Roles))
+	for i := range doc.Roles {
+		doc.Roles[i].Name = strings.TrimSpace(doc.Roles[i].Name)
+		doc.Roles[i].Parent = strings.TrimSpace(doc.Roles[i].Parent)
+		name := doc.Roles[i].Name
+		if name == "" {
+			return nil, core.BadRequest("role name is required").WithDetail("index", i)
+		}
+		if seen[name] {
+			return nil, core.BadRequest("role is listed more than once").WithDetail("role", name)
+		}
+		seen[name] = true
+	}
+
+	result := &model.RBACImportResult{
+		DryRun:    dryRun,
+		Changes:   []model.RBACRoleDiff{},
+		Unchanged: []string{},
+	}
+	var changedRoleIDs []string
+
+	err := s.permissionRepo.RunInTransaction(ctx, func(txCtx context.Context) error {
+		roles, err := s.permissionRepo.ListAllRoles(txCtx)
+		if err != nil {
+			return fmt.Errorf("failed to list roles: %w", err)
+		}
+		grants, err := s.permissionRepo.ListAllRolePermissionGrants(txCtx)
+		if err != nil {
+			return fmt.Errorf("failed to list role permissions: %w", err)
+		}
+		existing := make(map[string]*model.Role, len(roles))
+		names := make(map[string]string, len(roles))
+		for i := range roles {
+			existing[roles[i].Name] = &roles[i]
+			names[roles[i].ID] = roles[i].Name
+		}
+		current := groupRoleGrants(grants)
+
+		// Parents after the import, by name, to reject cycles before writing
+		parents := make(map[string]string, len(roles)+len(doc.Roles))
+		for _, role := range roles {
+			if role.ParentRoleID != nil {
+				parents[role.Name] = names[*role.ParentRoleID]
+			}
+		}
+		for _, entry := range doc.Roles {
+			if entry.Parent != "" && !seen[entry.Parent] && existing[entry.Parent] == nil {
+				return core.BadRequest("unknown parent role").WithDetail("role", entry.Name).WithDetail("parent", entry.Parent)
+			}
+			parents[entry.Name] = entry.Parent
+		}
+		for _, entry := range doc.Roles {
+			if err := checkImportedParents(entry.Name, parents); err != nil {
+				return err
+			}
+		}
+		// Re-parenting a role moves the roles below it too, even those the
+		// document leaves alone. Any cycle involves a document role and was
+		// reported above, so these walks end.
+		for _, role := range roles {
+			if err := checkImportedParents(role.Name, parents); err != nil {
+				return err
+			}
+		}
+
+		type plannedRole struct {
+			entry   model.RBACRole
+			role    *model.Role
+			diff    model.RBACRoleDiff
+			grants  []model.RolePermission
+			regrant bool
+		}
+		plan := make([]plannedRole, 0, len(doc.Roles))
+
+		for _, entry := range doc.Roles {
+			desired, err := s.resolveImportedGrants(txCtx, entry)
+			if err != nil {
+				return err
+			}
+
+			p := plannedRole{entry: entry, role: existing[entry.Name], diff: model.RBACRoleDiff{Role: entry.Name}}
+			if p.role == nil {
+				p.diff.Op = "create"
+				p.diff.AddAllow = desired.allow.sorted()
+				p.diff.AddDeny = desired.deny.sorted()
+			} else {
+				p.diff.Op = "update"
+				if stringValue(p.role.Description) != stringValue(entry.Description) {
+					p.diff.Fields = append(p.diff.Fields, "description")
+				}
+				currentParent := ""
+				if p.role.ParentRoleID != nil {
+					currentParent = names[*p.role.ParentRoleID]
+				}
+				if currentParent != entry.Parent {
+					p.diff.Fields = append(p.diff.Fields, "parent")
+				}
+				if p.role.RequiresMFA != entry.RequiresMFA {
+					p.diff.Fields = append(p.diff.Fields, "requires_mfa")
+				}
+				have := grantsOf(current, p.role.ID)
+				p.diff.AddAllow = desired.allow.minus(have.allow)
+				p.diff.RemoveAllow = have.allow.minus(desired.allow)
+				p.diff.AddDeny = desired.deny.minus(have.deny)
+				p.diff.RemoveDeny = have.deny.minus(desired.deny)
+			}
+			p.regrant = len(p.diff.AddAllow)+len(p.diff.RemoveAllow)+len(p.diff.AddDeny)+len(p.diff.RemoveDeny) > 0
+			p.grants = desired.grants
+
+			if p.diff.Op == "update" && len(p.diff.Fields) == 0 && !p.regrant {
+				result.Unchanged = append(result.Unchanged, entry.Name)
+				continue
+			}
+			if p.role != nil && p.role.IsSystem {
+				return core.Forbidden("Cannot modify system role").WithDetail("role", entry.Name)
+			}
+			result.Changes = append(result.Changes, p.diff)
+			plan = append(plan, p)
+		}
+
+		// Create every new role first so parents can refer to them
+		for i := range plan {
+			if plan[i].role != nil {
+				continue
+			}
+			role := &model.Role{
+				Name:        plan[i].entry.Name,
+				Description: plan[i].entry.Description,
+				RequiresMFA: plan[i].entry.RequiresMFA,
+			}
+			if err := s.permissionRepo.CreateRole(txCtx, role); err != nil {
+				return fmt.Errorf("failed to create role %s: %w", role.Name, err)
+			}
+			plan[i].role = role
+			existing[role.Name] = role
+		}
+
+		for _, p := range plan {
+			p.role.Description = p.entry.Description
+			p.role.RequiresMFA = p.entry.RequiresMFA
+			p.role.ParentRoleID = nil
+			if p.entry.Parent != "" {
+				p.role.ParentRoleID = &existing[p.entry.Parent].ID
+			}
+			if err := s.permissionRepo.UpdateRole(txCtx, p.role); err != nil {
+				return fmt.Errorf("failed to update role %s: %w", p.role.Name, err)
+			}
+
+			if p.regrant {
+				if err := s.permissionRepo.ReplaceRoleGrants(txCtx, p.role.ID, p.grants); err != nil {
+					return fmt.Errorf("failed to update permissions of role %s: %w", p.role.Name, err)
+				}
+			}
+			if p.diff.Op == "update" && (p.regrant || slices.Contains(p.diff.Fields, "parent")) {
+				// Members' access tokens no longer reflect the role
+				if err := s.permissionRepo.BumpRoleMembersTokenVersion(txCtx, p.role.ID); err != nil {
+					return err
+				}
+			}
+			changedRoleIDs = append(changedRoleIDs, p.role.ID)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		if _, ok := core.IsAppError(err); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to import rbac document: %w", err)
+	}
+
+	if !dryRun && len(result.Changes) > 0 {
+		dropAllCachedPermissions(ctx, s.log, s.cache)
+
+		changed := make([]string, len(result.Changes))
+		for i, diff := range result.Changes {
+			changed[i] = diff.Role
+		}
+		adminID := getUserIDFromContext(ctx)
+		s.auditLog(ctx, adminID, "rbac.import", strPtr("roles"), nil, map[string]interface{}{
+			"roles":    changed,
+			"role_ids": changedRoleIDs,
+		})
+	}
+
+	return result, nil
+}
+
+// resolveImportedGrants turns the allow and deny entries of an imported role
+// into grants, validating them like AssignPermissionsToRole does
+func (s *permissionService) resolveImportedGrants(ctx context.Context, entry model.RBACRole) (*roleGrantSet, error) {
+	set := newRoleGrantSet()
+
+	for _, raw := range entry.Allow {
+		name, scope := model.SplitPermissionScope(strings.TrimSpace(raw))
+		if !model.ValidPermissionScope(scope) {
+			return nil, core.BadRequest("scope must be \"own\" or \"program:<program>\"").
+				WithDetail("role", entry.Name).WithDetail("permission", raw)
+		}
+		perm, err := s.permissionFromPattern(ctx, name)
+		if err != nil {
+			return nil, withRoleDetail(err, entry.Name)
+		}
+		if scope != "" && !scopablePermission(perm.Name) {
+			return nil, core.BadRequest("permission cannot be scoped").
+				WithDetail("role", entry.Name).WithDetail("permission", raw)
+		}
+		set.add(*perm, scope, model.PermissionAllow)
+	}
+
+	for _, raw := range entry.Deny {
+		name, scope := model.SplitPermissionScope(strings.TrimSpace(raw))
+		if scope != "" {
+			return nil, core.BadRequest("deny entries cannot be scoped").
+				WithDetail("role", entry.Name).WithDetail("permission", raw)
+		}
+		perm, err := s.permissionFromPattern(ctx, name)
+		if err != nil {
+			return nil, withRoleDetail(err, entry.Name)
+		}
+		set.add(*perm, "", model.PermissionDeny)
+	}
+
+	return set, nil
+}
+
+// checkImportedParents walks up from role and rejects cycles and hierarchies
+// deeper than the repository resolves
+func checkImportedParents(role string, parents map[string]string) error {
+	depth := 0
+	for current := parents[role]; current != ""; current = parents[current] {
+		if current == role {
+			return core.BadRequest("Parent role would create a cycle in the role hierarchy").WithDetail("role", role)
+		}
+		depth++
+		if depth >= repository.MaxRoleDepth {
+			return core.BadRequest(fmt.Sprintf("Role hierarchy cannot be deeper than %d levels", repository.MaxRoleDepth)).
+				WithDetail("role", role)
+		}
+	}
+	return nil
+}
+
+func withRoleDetail(err error, role string) error {
+	if appErr, ok := core.IsAppError(err); ok {
+		return appErr.WithDetail("role", role)
+	}
+	return err
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// grantNames is a set of grant strings as written in an RBAC document
+type grantNames map[string]bool
+
+func (g grantNames) sorted() []string {
+	out := make([]string, 0, len(g))
+	for name := range g {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// minus returns the sorted entries of g missing from other
+func (g grantNames) minus(other grantNames) []string {
+	var out []string
+	for name := range g {
+		if !other[name] {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// roleGrantSet holds the grants of one role, both as document entries and
+// as rows to write
+type roleGrantSet struct {
+	allow  grantNames
+	deny   grantNames
+	grants []model.RolePermission
+}
+
+func newRoleGrantSet() *roleGrantSet {
+	return &roleGrantSet{allow: grantNames{}, deny: grantNames{}}
+}
+
+func (s *roleGrantSet) add(perm model.Permission, scope string, effect model.PermissionEffect) {
+	target, key := s.allow, model.ScopedPermission(perm.Name, scope)
+	if effect == model.PermissionDeny {
+		target, key = s.deny, perm.Name
+	}
+	if target[key] {
+		return
+	}
+	target[key] = true
+	s.grants = append(s.grants, model.RolePermission{
+		PermissionID: perm.ID,
+		Scope:        scope,
+		Effect:       effect,
+	})
+}
+
+// grantsOf returns the grants of roleID, empty when it has none
+func grantsOf(byRole map[string]*roleGrantSet, roleID string) *roleGrantSet {
+	if set := byRole[roleID]; set != nil {
+		return set
+	}
+	return newRoleGrantSet()
+}
+
+// groupRoleGrants indexes grants by role ID
+func groupRoleGrants(grants []model.RolePermission) map[string]*roleGrantSet {
+	out := make(map[string]*roleGrantSet)
+	for _, grant := range grants {
+		set := out[grant.RoleID]
+		if set == nil {
+			set = newRoleGrantSet()
+			out[grant.RoleID] = set
+		}
+		set.add(grant.Permission, grant.Scope, grant.Effect)
+	}
+	return out
+}
@@ -24,7 +24,7 @@ import (
 type registrationService struct {
 	regRepo  repository.RegistrationRepository
 	evRepo   repository.EmailVerificationRepository
-	mailer   Mailer
+	outbox   EmailOutboxService
 	tokenTTL time.Duration
 	locker   lock.Locker
 	tracer   nr.Tracer
@@ -51,7 +51,6 @@ func (s *registrationService) Register(ctx context.Context, req model.RegisterRe
 	}
 
 	reg := req.ToModel()
-	var rawToken string
 
 	if err := s.locker.WithLock(ctx, "lock:registrations:"+req.Email, 10*time.Second, func(ctx context.Context) error {
 		return s.runTransaction(ctx, func(txCtx context.Context) error {
@@ -59,11 +58,10 @@ func (s *registrationService) Register(ctx context.Context, req model.RegisterRe
 				return core.InternalServerError("failed to create registration").WithError(err)
 			}
 
-			tRaw, tHash, err := generateToken()
+			rawToken, tHash, err := generateToken()
 			if err != nil {
 				return core.InternalServerError("failed to generate verification token").WithError(err)
 			}
-			rawToken = tRaw
 
 			ev := model.EmailVerification{
 				RegistrationID: reg.ID,
@@ -73,24 +71,24 @@ func (s *registrationService) Register(ctx context.Context, req model.RegisterRe
 			if err := s.evRepo.Create(txCtx, &ev); err != nil {
 				return core.InternalServerError("failed to save verification token").WithError(err)
 			}
+
+			// Queue verification email in the same transaction as the registration
+			if s.outbox != nil && verifyURL != "" {
+				link := fmt.Sprintf("%s?token=%s", verifyURL, rawToken)
+				body, err := mailer.RenderVerificationEmail(reg.FullName, string(reg.Program), link)
+				if err != nil {
+					return core.InternalServerError("failed to render verification email").WithError(err)
+				}
+				if err := s.outbox.Enqueue(txCtx, reg.Email, "Verify Your Email - ITTS Community", body); err != nil {
+					return core.InternalServerError("failed to queue verification email").WithError(err)
+				}
+			}
 			return nil
 		})
 	}); err != nil {
 		return model.RegistrationResponse{}, err
 	}
 
-	// Send verification email with beautiful HTML template
-	if s.mailer != nil && verifyURL != "" {
-		link := fmt.Sprintf("%s?token=%s", verifyURL, rawToken)
-		body, err := mailer.RenderVerificationEmail(reg.FullName, string(reg.Program), link)
-		if err != nil {
-			return model.RegistrationToResponse(reg), fmt.Errorf("failed to render verification email: %w", err)
-		}
-		if err := s.mailer.Send(reg.Email, "Verify Your Email - ITTS Community", body); err != nil {
-			return model.RegistrationToResponse(reg), fmt.Errorf("failed to send verification email: %w", err)
-		}
-	}
-
 	return model.RegistrationToResponse(reg), nil
 }
 
@@ -132,6 +130,17 @@ func (s *registrationService) VerifyEmail(ctx context.Context, rawToken string)
 				}
 			}
 
+			// Queue thank you email after successful verification
+			if s.outbox != nil {
+				body, err := mailer.RenderThankYouEmail(r.FullName, string(r.Program))
+				if err != nil {
+					return core.InternalServerError("failed to render thank you email").WithError(err)
+				}
+				if err := s.outbox.Enqueue(txCtx, r.Email, "Email Verified - Welcome to ITTS Community!", body); err != nil {
+					return core.InternalServerError("failed to queue thank you email").WithError(err)
+				}
+			}
+
 			reg = *r
 			return nil
 		})
@@ -139,16 +148,6 @@ func (s *registrationService) VerifyEmail(ctx context.Context, rawToken string)
 		return model.RegistrationResponse{}, err
 	}
 
-	// Send thank you email after successful verification
-	if s.mailer != nil {
-		go func() {
-			body, err := mailer.RenderThankYouEmail(reg.FullName, string(reg.Program))
-			if err == nil {
-				_ = s.mailer.Send(reg.Email, "Email Verified - Welcome to ITTS Community!", body)
-			}
-		}()
-	}
-
 	return model.RegistrationToResponse(reg), nil
 }
 
@@ -206,6 +205,18 @@ func (s *registrationService) AdminApprove(ctx context.Context, req model.AdminA
 			if err := s.regRepo.Update(txCtx, r); err != nil {
 				return core.InternalServerError("failed to update registration").WithError(err)
 			}
+
+			// Queue approval email to notify user
+			if s.outbox != nil {
+				body, err := mailer.RenderApprovalEmail(r.FullName, string(r.Program), r.Email)
+				if err != nil {
+					return core.InternalServerError("failed to render approval email").WithError(err)
+				}
+				if err := s.outbox.Enqueue(txCtx, r.Email, "Congratulations! Your Registration is Approved - ITTS Community", body); err != nil {
+					return core.InternalServerError("failed to queue approval email").WithError(err)
+				}
+			}
+
 			out = *r
 			return nil
 		})
@@ -214,16 +225,6 @@ func (s *registrationService) AdminApprove(ctx context.Context, req model.AdminA
 		return model.RegistrationResponse{}, err
 	}
 
-	// Send approval email to notify user
-	if s.mailer != nil {
-		go func() {
-			body, err := mailer.RenderApprovalEmail(out.FullName, string(out.Program), out.Email)
-			if err == nil {
-				_ = s.mailer.Send(out.Email, "Congratulations! Your Registration is Approved - ITTS Community", body)
-			}
-		}()
-	}
-
 	return model.RegistrationToResponse(out), nil
 }
 
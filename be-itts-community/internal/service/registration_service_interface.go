@@ -28,12 +28,12 @@ type RegistrationService interface {
 func NewRegistrationService(
 	regRepo repository.RegistrationRepository,
 	evRepo repository.EmailVerificationRepository,
-	mailer Mailer,
+	outbox EmailOutboxService,
 	locker lock.Locker,
 	tracer nr.Tracer,
 ) RegistrationService {
 	return &registrationService{
-		regRepo: regRepo, evRepo: evRepo, mailer: mailer,
+		regRepo: regRepo, evRepo: evRepo, outbox: outbox,
 		tokenTTL: 24 * time.Hour,
 		locker:   locker, tracer: tracer,
 	}
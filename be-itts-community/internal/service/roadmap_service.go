@@ -31,6 +31,7 @@ func (s *roadmapService) Create(ctx context.Context, req model.CreateRoadmapRequ
 	}
 
 	rm := req.ToModel()
+	rm.CreatedBy = getUserIDFromContext(ctx)
 
 	if err := s.locker.WithLock(ctx, "lock:roadmaps:create", 5*time.Second, func(ctx context.Context) error {
 		return s.repo.RunInTransaction(ctx, func(txCtx context.Context) error {
@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ========================================
+-- Durable outbound email queue
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recipient CITEXT NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, sending, sent, dead
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 8,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_email_outbox_status CHECK (status IN ('pending', 'sending', 'sent', 'dead'))
+);
+
+-- Worker polls by status + due time
+CREATE INDEX idx_email_outbox_due ON email_outbox(status, next_attempt_at);
+CREATE INDEX idx_email_outbox_recipient ON email_outbox(recipient);
+CREATE INDEX idx_email_outbox_created ON email_outbox(created_at DESC);
+
+-- Permissions for the admin outbox endpoints
+INSERT INTO resources (id, name, description) VALUES
+    ('10000000-0000-0000-0000-000000000012', 'email_outbox', 'Outbound email queue')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (resource_id, action_id, name, description)
+SELECT r.id, a.id, r.name || ':' || a.name, 'Permission to ' || a.description || ' on ' || r.description
+FROM resources r
+CROSS JOIN actions a
+WHERE r.name = 'email_outbox'
+  AND a.name IN ('read', 'list', 'manage')
+ON CONFLICT (resource_id, action_id) DO NOTHING;
+
+-- super_admin and admin can inspect and resend
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT ro.id, p.id
+FROM roles ro
+JOIN permissions p ON p.name IN ('email_outbox:read', 'email_outbox:list', 'email_outbox:manage')
+WHERE ro.name IN ('super_admin', 'admin')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM permissions WHERE name IN ('email_outbox:read', 'email_outbox:list', 'email_outbox:manage');
+DELETE FROM resources WHERE name = 'email_outbox';
+DROP TABLE IF EXISTS email_outbox CASCADE;
+
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- TOTP state on users. The secret is AES-GCM encrypted by the application;
+-- mfa_last_step stores the last accepted TOTP time step to block code replay.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS mfa_secret TEXT,
+    ADD COLUMN IF NOT EXISTS mfa_enabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS mfa_last_step BIGINT;
+
+-- Roles flagged as sensitive force their members to use MFA
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS requires_mfa BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE roles SET requires_mfa = true WHERE name = 'super_admin';
+
+-- One-time recovery codes (SHA-256 hashed)
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user ON mfa_recovery_codes(user_id);
+CREATE UNIQUE INDEX ux_mfa_recovery_codes_user_hash ON mfa_recovery_codes(user_id, code_hash);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mfa_recovery_codes;
+ALTER TABLE roles DROP COLUMN IF EXISTS requires_mfa;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS mfa_last_step,
+    DROP COLUMN IF EXISTS mfa_enabled_at,
+    DROP COLUMN IF EXISTS mfa_secret,
+    DROP COLUMN IF EXISTS mfa_enabled;
+-- +goose StatementEnd
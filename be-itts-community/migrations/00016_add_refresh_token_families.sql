@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every login starts a family; each refresh rotates the token within it.
+-- Existing tokens each become their own family.
+ALTER TABLE refresh_tokens
+  ADD COLUMN IF NOT EXISTS family_id uuid NOT NULL DEFAULT gen_random_uuid(),
+  ADD COLUMN IF NOT EXISTS replaced_by_id uuid NULL REFERENCES refresh_tokens(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+ALTER TABLE refresh_tokens
+  DROP COLUMN IF EXISTS replaced_by_id,
+  DROP COLUMN IF EXISTS family_id;
+-- +goose StatementEnd
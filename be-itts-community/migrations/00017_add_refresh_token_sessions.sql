@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens
+  ADD COLUMN IF NOT EXISTS ip_address inet NULL,
+  ADD COLUMN IF NOT EXISTS user_agent text NULL,
+  ADD COLUMN IF NOT EXISTS last_used_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_active ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_refresh_tokens_user_active;
+ALTER TABLE refresh_tokens
+  DROP COLUMN IF EXISTS last_used_at,
+  DROP COLUMN IF EXISTS user_agent,
+  DROP COLUMN IF EXISTS ip_address;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Bumped whenever a user's access must be re-evaluated (roles, permissions,
+-- activation, password); access tokens carrying an older version are rejected.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS token_version integer NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+  DROP COLUMN IF EXISTS token_version;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_tokens (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name varchar(100) NOT NULL,
+  token_prefix varchar(16) NOT NULL,
+  token_hash char(64) NOT NULL,
+  scopes jsonb NOT NULL DEFAULT '[]'::jsonb,
+  expires_at timestamptz NOT NULL,
+  last_used_at timestamptz NULL,
+  last_used_ip inet NULL,
+  revoked_at timestamptz NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_api_tokens_token_hash ON api_tokens(token_hash);
+
+-- Which API token (if any) performed the audited action
+ALTER TABLE audit_logs
+  ADD COLUMN IF NOT EXISTS api_token_id uuid NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_api_token ON audit_logs(api_token_id) WHERE api_token_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_audit_logs_api_token;
+ALTER TABLE audit_logs
+  DROP COLUMN IF EXISTS api_token_id;
+
+DROP INDEX IF EXISTS ux_api_tokens_token_hash;
+DROP INDEX IF EXISTS idx_api_tokens_user;
+DROP TABLE IF EXISTS api_tokens;
+-- +goose StatementEnd
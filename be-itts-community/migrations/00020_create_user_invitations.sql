@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ========================================
+-- Admin invitations for new users
+-- ========================================
+
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email CITEXT NOT NULL,
+    full_name VARCHAR(255),
+    role_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
+    token_hash CHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, accepted, revoked
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    accepted_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    send_count INT NOT NULL DEFAULT 1,
+    last_sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_user_invitations_status CHECK (status IN ('pending', 'accepted', 'revoked'))
+);
+
+CREATE UNIQUE INDEX ux_user_invitations_token_hash ON user_invitations(token_hash);
+-- At most one open invitation per email
+CREATE UNIQUE INDEX ux_user_invitations_pending_email ON user_invitations(email) WHERE status = 'pending';
+CREATE INDEX idx_user_invitations_created ON user_invitations(created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_invitations_created;
+DROP INDEX IF EXISTS ux_user_invitations_pending_email;
+DROP INDEX IF EXISTS ux_user_invitations_token_hash;
+DROP TABLE IF EXISTS user_invitations;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+
+INSERT INTO actions (id, name, description) VALUES
+    ('20000000-0000-0000-0000-000000000010', 'impersonate', 'Act as another user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (resource_id, action_id, name, description)
+SELECT r.id, a.id, r.name || ':' || a.name, 'Permission to ' || a.description || ' on ' || r.description
+FROM resources r
+CROSS JOIN actions a
+WHERE r.name = 'users'
+  AND a.name = 'impersonate'
+ON CONFLICT (resource_id, action_id) DO NOTHING;
+
+-- Only super_admin by default; grant to other roles explicitly
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT ro.id, p.id
+FROM roles ro
+JOIN permissions p ON p.name = 'users:impersonate'
+WHERE ro.name = 'super_admin'
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- Admin behind an impersonated action; user_id is the impersonated user
+ALTER TABLE audit_logs
+  ADD COLUMN IF NOT EXISTS impersonator_id uuid NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_impersonator ON audit_logs(impersonator_id) WHERE impersonator_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_audit_logs_impersonator;
+ALTER TABLE audit_logs
+  DROP COLUMN IF EXISTS impersonator_id;
+
+DELETE FROM permissions WHERE name = 'users:impersonate';
+DELETE FROM actions WHERE name = 'impersonate';
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS email_changes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  old_email citext NOT NULL,
+  new_email citext NOT NULL,
+  token_hash char(64) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  used_at timestamptz NULL,
+  confirmed_at timestamptz NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user ON email_changes(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_email_changes_token_hash ON email_changes(token_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ux_email_changes_token_hash;
+DROP INDEX IF EXISTS idx_email_changes_user;
+DROP TABLE IF EXISTS email_changes;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- "*" stands for any resource or any action in a permission name
+INSERT INTO resources (id, name, description) VALUES
+    ('10000000-0000-0000-0000-000000000000', '*', 'Any resource')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO actions (id, name, description) VALUES
+    ('20000000-0000-0000-0000-000000000000', '*', 'Any action')
+ON CONFLICT (name) DO NOTHING;
+
+-- resource:* for every resource and *:action for every action. *:* is left
+-- out: full access is what is_super_admin is for.
+INSERT INTO permissions (resource_id, action_id, name, description)
+SELECT r.id, a.id, r.name || ':' || a.name,
+       CASE WHEN a.name = '*' THEN 'Any action on ' || r.description
+            ELSE 'Permission to ' || a.description || ' on any resource' END
+FROM resources r
+CROSS JOIN actions a
+WHERE (a.name = '*') <> (r.name = '*')
+ON CONFLICT (resource_id, action_id) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM permissions WHERE name LIKE '*:%' OR name LIKE '%:*';
+DELETE FROM actions WHERE name = '*';
+DELETE FROM resources WHERE name = '*';
+
+-- +goose StatementEnd
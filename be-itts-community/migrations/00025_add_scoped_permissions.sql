@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Scope limits a grant to some instances of the resource: '' (any), 'own'
+-- (created_by is the user) or 'program:<program>'. A role may hold the same
+-- permission with several scopes.
+ALTER TABLE role_permissions
+  ADD COLUMN IF NOT EXISTS scope varchar(150) NOT NULL DEFAULT '';
+
+ALTER TABLE role_permissions
+  DROP CONSTRAINT IF EXISTS role_permissions_role_id_permission_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_role_permissions_role_permission_scope
+  ON role_permissions(role_id, permission_id, scope);
+
+-- Owner of managed entities, for 'own' scoped grants
+ALTER TABLE events ADD COLUMN IF NOT EXISTS created_by uuid NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE mentors ADD COLUMN IF NOT EXISTS created_by uuid NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE partners ADD COLUMN IF NOT EXISTS created_by uuid NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE roadmaps ADD COLUMN IF NOT EXISTS created_by uuid NULL REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_created_by ON events(created_by);
+CREATE INDEX IF NOT EXISTS idx_mentors_created_by ON mentors(created_by);
+CREATE INDEX IF NOT EXISTS idx_partners_created_by ON partners(created_by);
+CREATE INDEX IF NOT EXISTS idx_roadmaps_created_by ON roadmaps(created_by);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_roadmaps_created_by;
+DROP INDEX IF EXISTS idx_partners_created_by;
+DROP INDEX IF EXISTS idx_mentors_created_by;
+DROP INDEX IF EXISTS idx_events_created_by;
+
+ALTER TABLE roadmaps DROP COLUMN IF EXISTS created_by;
+ALTER TABLE partners DROP COLUMN IF EXISTS created_by;
+ALTER TABLE mentors DROP COLUMN IF EXISTS created_by;
+ALTER TABLE events DROP COLUMN IF EXISTS created_by;
+
+DELETE FROM role_permissions WHERE scope <> '';
+DROP INDEX IF EXISTS ux_role_permissions_role_permission_scope;
+ALTER TABLE role_permissions
+  ADD CONSTRAINT role_permissions_role_id_permission_id_key UNIQUE (role_id, permission_id);
+ALTER TABLE role_permissions DROP COLUMN IF EXISTS scope;
+
+-- +goose StatementEnd
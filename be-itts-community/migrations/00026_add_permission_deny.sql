@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A deny entry overrides every allow for the permissions it covers, whichever
+-- role the allow comes from. Denies apply to all instances, so they carry no
+-- scope.
+ALTER TABLE role_permissions
+  ADD COLUMN IF NOT EXISTS effect varchar(10) NOT NULL DEFAULT 'allow';
+
+ALTER TABLE role_permissions
+  ADD CONSTRAINT chk_role_permissions_effect CHECK (effect IN ('allow', 'deny'));
+
+ALTER TABLE role_permissions
+  ADD CONSTRAINT chk_role_permissions_deny_unscoped CHECK (effect = 'allow' OR scope = '');
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_deny
+  ON role_permissions(role_id) WHERE effect = 'deny';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM role_permissions WHERE effect = 'deny';
+DROP INDEX IF EXISTS idx_role_permissions_deny;
+ALTER TABLE role_permissions DROP CONSTRAINT IF EXISTS chk_role_permissions_deny_unscoped;
+ALTER TABLE role_permissions DROP CONSTRAINT IF EXISTS chk_role_permissions_effect;
+ALTER TABLE role_permissions DROP COLUMN IF EXISTS effect;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The notice sent to the current address carries its own link that cancels
+-- the change, so a hijacked session cannot move the account silently.
+ALTER TABLE email_changes
+  ADD COLUMN IF NOT EXISTS cancel_token_hash char(64) NULL,
+  ADD COLUMN IF NOT EXISTS cancelled_at timestamptz NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_email_changes_cancel_token_hash
+  ON email_changes(cancel_token_hash) WHERE cancel_token_hash IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS ux_email_changes_cancel_token_hash;
+ALTER TABLE email_changes
+  DROP COLUMN IF EXISTS cancelled_at,
+  DROP COLUMN IF EXISTS cancel_token_hash;
+
+-- +goose StatementEnd
@@ -0,0 +1,104 @@
+package attempt
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepThreshold bounds memory use: expired entries are purged once the
+// map grows past it
+const sweepThreshold = 10000
+
+type memoryEntry struct {
+	count       int64
+	expiresAt   time.Time
+	lockedUntil time.Time
+}
+
+// MemoryStore implements Store in process memory (single instance / dev)
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) > sweepThreshold {
+		s.sweep(now)
+	}
+
+	e := s.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	if e.expiresAt.Before(now) {
+		e.count = 0
+		e.expiresAt = now.Add(window)
+	}
+	e.count++
+	return e.count, nil
+}
+
+func (s *MemoryStore) Count(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil || e.expiresAt.Before(time.Now()) {
+		return 0, nil
+	}
+	return e.count, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	e.lockedUntil = time.Now().Add(d)
+	return nil
+}
+
+func (s *MemoryStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.entries[key]
+	if e == nil {
+		return 0, nil
+	}
+	if left := time.Until(e.lockedUntil); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep removes entries whose window and lock have both expired
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if e.expiresAt.Before(now) && e.lockedUntil.Before(now) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package attempt
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements Store on Redis so counters are shared across instances
+type RedisStore struct {
+	Client *redis.Client
+	Prefix string
+}
+
+func NewRedisStore(c *redis.Client) *RedisStore {
+	return &RedisStore{Client: c, Prefix: "attempt:"}
+}
+
+func (s *RedisStore) countKey(key string) string { return s.Prefix + "count:" + key }
+func (s *RedisStore) lockKey(key string) string  { return s.Prefix + "lock:" + key }
+
+// incrScript increments a counter and, on the first failure, opens the
+// window in the same step so a counter can never be left without expiry
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.Client, []string{s.countKey(key)}, window.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) Count(ctx context.Context, key string) (int64, error) {
+	n, err := s.Client.Get(ctx, s.countKey(key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return s.Client.Set(ctx, s.lockKey(key), 1, d).Err()
+}
+
+func (s *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.Client.PTTL(ctx, s.lockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2: no key, -1: no expiry (never set by Lock)
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.Client.Del(ctx, s.countKey(key), s.lockKey(key)).Err()
+}
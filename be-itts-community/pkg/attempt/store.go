@@ -0,0 +1,22 @@
+package attempt
+
+import (
+	"context"
+	"time"
+)
+
+// Store counts failed attempts per key within a sliding window and keeps
+// temporary locks. Implementations must be safe for concurrent use.
+type Store interface {
+	// Incr records a failure for key and returns the number of failures
+	// within the window. The window starts at the first failure.
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Count returns the current number of failures for key
+	Count(ctx context.Context, key string) (int64, error)
+	// Lock blocks key for d
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor returns how long key remains locked (0 if not locked)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset clears the failure count and any lock for key
+	Reset(ctx context.Context, key string) error
+}
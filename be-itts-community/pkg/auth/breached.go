@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswordList checks passwords against a local copy of a breached
+// password corpus as published by Have I Been Pwned, in either layout:
+//
+//   - a single file of "HASH:COUNT" lines holding full SHA-1 hashes, sorted
+//     by hash (the "ordered by hash" download)
+//   - a directory of k-anonymity range files, one per 5 character SHA-1
+//     prefix, named after the prefix (optionally with .txt) and holding
+//     "SUFFIX:COUNT" lines
+//
+// A lookup binary-searches the file or reads only the password's range
+// file, so the corpus never sits in memory.
+type BreachedPasswordList struct {
+	path  string
+	isDir bool
+}
+
+// NewBreachedPasswordList opens a sorted hash file or a directory of range
+// files
+func NewBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	return &BreachedPasswordList{path: path, isDir: info.IsDir()}, nil
+}
+
+// Contains reports whether password appears in the corpus. A missing range
+// file means no breached password has that prefix.
+func (l *BreachedPasswordList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if !l.isDir {
+		return l.searchFile(hash)
+	}
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := l.openRange(prefix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breached password range %s: %w", prefix, err)
+	}
+	return false, nil
+}
+
+func (l *BreachedPasswordList) openRange(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(l.path, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.Open(filepath.Join(l.path, prefix+".txt"))
+	}
+	return f, err
+}
+
+// searchFile binary-searches the sorted hash file for hash. lo only ever
+// moves to a line start, and every line starting before it sorts below hash.
+func (l *BreachedPasswordList) searchFile(hash string) (bool, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	size := info.Size()
+
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		key, next, err := hashLineAt(f, size, mid)
+		if err != nil {
+			return false, err
+		}
+		if key != "" && key < hash {
+			lo = next
+		} else {
+			hi = mid
+		}
+	}
+
+	key, _, err := hashLineAt(f, size, lo)
+	if err != nil {
+		return false, err
+	}
+	return key == hash, nil
+}
+
+// hashLineAt returns the hash of the first line starting at or after pos
+// and the offset of the line after it. The hash is empty past the last line.
+func hashLineAt(f io.ReaderAt, size, pos int64) (string, int64, error) {
+	start := pos
+	if pos > 0 {
+		// pos may itself be a line start, so look from the byte before it
+		start = pos - 1
+	}
+	r := bufio.NewReader(io.NewSectionReader(f, start, size-start))
+	if pos > 0 {
+		skipped, err := r.ReadString('\n')
+		if err == io.EOF {
+			return "", size, nil
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read breached password list: %w", err)
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	key, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if key == "" && err == io.EOF {
+		return "", size, nil
+	}
+	return strings.ToUpper(key), start + int64(len(line)), nil
+}
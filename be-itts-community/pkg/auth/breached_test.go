@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachedCorpus returns the sorted hashes of n generated passwords
+func breachedCorpus(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = sha1Hex(fmt.Sprintf("breached-%d", i))
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// writeHashFile writes hashes as "HASH:COUNT" lines ending in eol
+func writeHashFile(t *testing.T, hashes []string, eol string) string {
+	t.Helper()
+	var b strings.Builder
+	for i, h := range hashes {
+		fmt.Fprintf(&b, "%s:%d%s", h, i+1, eol)
+	}
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeRangeDir writes hashes as range files named after their prefix
+func writeRangeDir(t *testing.T, hashes []string, ext string) string {
+	t.Helper()
+	ranges := make(map[string][]string)
+	for _, h := range hashes {
+		ranges[h[:5]] = append(ranges[h[:5]], h[5:]+":1")
+	}
+	dir := t.TempDir()
+	for prefix, lines := range ranges {
+		if err := os.WriteFile(filepath.Join(dir, prefix+ext), []byte(strings.Join(lines, "\r\n")), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBreachedPasswordList_Contains(t *testing.T) {
+	hashes := breachedCorpus(300)
+
+	tests := []struct {
+		name   string
+		path   string
+		listed []string
+	}{
+		{"sorted file", writeHashFile(t, hashes, "\n"), hashes},
+		{"sorted file with CRLF", writeHashFile(t, hashes, "\r\n"), hashes},
+		{"single line file", writeHashFile(t, hashes[:1], ""), hashes[:1]},
+		{"range directory", writeRangeDir(t, hashes, ""), hashes},
+		{"range directory with .txt", writeRangeDir(t, hashes, ".txt"), hashes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := NewBreachedPasswordList(tt.path)
+			if err != nil {
+				t.Fatalf("NewBreachedPasswordList() error = %v", err)
+			}
+			listed := make(map[string]bool)
+			for _, h := range tt.listed {
+				listed[h] = true
+			}
+
+			for i := 0; i < 300; i++ {
+				password := fmt.Sprintf("breached-%d", i)
+				got, err := list.Contains(password)
+				if err != nil {
+					t.Fatalf("Contains(%q) error = %v", password, err)
+				}
+				if want := listed[sha1Hex(password)]; got != want {
+					t.Errorf("Contains(%q) = %v, want %v", password, got, want)
+				}
+
+				clean := fmt.Sprintf("clean-%d", i)
+				if got, err := list.Contains(clean); err != nil || got {
+					t.Errorf("Contains(%q) = (%v, %v), want (false, nil)", clean, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewBreachedPasswordList_Missing(t *testing.T) {
+	if _, err := NewBreachedPasswordList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("NewBreachedPasswordList() on a missing path: want an error")
+	}
+}
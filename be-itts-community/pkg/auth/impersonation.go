@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type impersonatorContextKey struct{}
+
+// WithImpersonatorID marks ctx as a request made by the given admin while
+// impersonating another user
+func WithImpersonatorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, impersonatorContextKey{}, actorID)
+}
+
+// ImpersonatorIDFromContext returns the admin behind an impersonated
+// request, or "" for normal requests
+func ImpersonatorIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(impersonatorContextKey{}).(string)
+	return id
+}
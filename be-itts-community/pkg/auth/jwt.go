@@ -19,7 +19,7 @@ var (
 
 // JWTManager handles JWT token generation and validation
 type JWTManager struct {
-	secretKey            []byte
+	keys                 *KeySet
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
 	issuer               string
@@ -32,24 +32,123 @@ type Claims struct {
 	IsSuperAdmin bool     `json:"is_super_admin"`
 	Roles        []string `json:"roles"`
 	Permissions  []string `json:"permissions"`
+	SessionID    string   `json:"sid,omitempty"` // refresh token family the token was issued for
+	TokenVersion int      `json:"tv"`            // must match the user's current token version
+	Actor        *Actor   `json:"act,omitempty"` // set on impersonation tokens
 	jwt.RegisteredClaims
 }
 
-// NewJWTManager creates a new JWT manager
+// Actor identifies the admin behind an impersonation token (RFC 8693 "act").
+// The token's UserID is the impersonated user.
+type Actor struct {
+	UserID       string `json:"sub"`
+	Email        string `json:"email"`
+	TokenVersion int    `json:"tv"` // must match the actor's current token version
+}
+
+// ImpersonationDuration is how long an impersonation token is valid. No
+// refresh token is issued, so impersonation ends when it expires.
+const ImpersonationDuration = 15 * time.Minute
+
+// MFA challenge token purposes
+const (
+	MFAPurposeVerify = "mfa_verify" // user has MFA and must submit a code
+	MFAPurposeEnroll = "mfa_enroll" // user's role requires MFA but none is set up yet
+)
+
+// mfaAudience marks challenge tokens so they can never be used as access tokens
+const mfaAudience = "mfa_challenge"
+
+// MFAChallengeDuration is how long a user has to complete the second login step
+const MFAChallengeDuration = 5 * time.Minute
+
+// MFAClaims represents the claims of a short-lived MFA challenge token
+type MFAClaims struct {
+	Purpose string `json:"purpose"`
+	Method  string `json:"method,omitempty"` // login method that passed the first factor
+	jwt.RegisteredClaims
+}
+
+// oauthLinkAudience marks pending OAuth link tokens
+const oauthLinkAudience = "oauth_link"
+
+// OAuthLinkDuration is how long a user has to confirm linking a provider
+// account found by email
+const OAuthLinkDuration = 10 * time.Minute
+
+// OAuthLinkClaims identify a provider account waiting to be linked to the
+// user in Subject once that user signs in and confirms
+type OAuthLinkClaims struct {
+	Provider     string                 `json:"provider"`
+	ProviderID   string                 `json:"provider_id"`
+	ProviderData map[string]interface{} `json:"provider_data,omitempty"`
+	Method       string                 `json:"method,omitempty"` // how the link was started, for the audit log
+	jwt.RegisteredClaims
+}
+
+// NewJWTManager creates a new JWT manager signing with a shared HS256 secret
 func NewJWTManager(
 	secretKey string,
 	accessTokenDuration time.Duration,
 	refreshTokenDuration time.Duration,
 	issuer string,
+) (*JWTManager, error) {
+	if secretKey == "" {
+		return nil, errors.New("jwt secret must not be empty")
+	}
+	keys, err := NewKeySet(NewHMACKey(LegacyHMACKeyID, []byte(secretKey)))
+	if err != nil {
+		return nil, err
+	}
+	return NewJWTManagerWithKeys(keys, accessTokenDuration, refreshTokenDuration, issuer), nil
+}
+
+// NewJWTManagerWithKeys creates a new JWT manager signing with the active key
+// of keys and verifying against any key in the set
+func NewJWTManagerWithKeys(
+	keys *KeySet,
+	accessTokenDuration time.Duration,
+	refreshTokenDuration time.Duration,
+	issuer string,
 ) *JWTManager {
 	return &JWTManager{
-		secretKey:            []byte(secretKey),
+		keys:                 keys,
 		accessTokenDuration:  accessTokenDuration,
 		refreshTokenDuration: refreshTokenDuration,
 		issuer:               issuer,
 	}
 }
 
+// Keys returns the key set (e.g. to publish the JWKS)
+func (m *JWTManager) Keys() *KeySet {
+	return m.keys
+}
+
+// sign signs claims with the active key and tags the token with its kid
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
+	key := m.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// keyFunc selects the verification key by kid and rejects tokens whose alg
+// does not match that key
+func (m *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidSignature, kid)
+	}
+	if key.Retired(time.Now()) {
+		return nil, fmt.Errorf("%w: key %q is retired", ErrInvalidSignature, key.ID)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: unexpected signing method %v", ErrInvalidSignature, token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
 // GenerateAccessToken generates a new access token
 func (m *JWTManager) GenerateAccessToken(
 	userID string,
@@ -57,6 +156,8 @@ func (m *JWTManager) GenerateAccessToken(
 	isSuperAdmin bool,
 	roles []string,
 	permissions []string,
+	sessionID string,
+	tokenVersion int,
 ) (string, error) {
 	now := time.Now()
 	claims := Claims{
@@ -65,6 +166,8 @@ func (m *JWTManager) GenerateAccessToken(
 		IsSuperAdmin: isSuperAdmin,
 		Roles:        roles,
 		Permissions:  permissions,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    m.issuer,
 			Subject:   userID,
@@ -74,8 +177,37 @@ func (m *JWTManager) GenerateAccessToken(
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secretKey)
+	return m.sign(claims)
+}
+
+// GenerateImpersonationToken issues a short-lived access token for the target
+// user on behalf of actor. It never carries super admin rights.
+func (m *JWTManager) GenerateImpersonationToken(
+	userID string,
+	email string,
+	roles []string,
+	permissions []string,
+	tokenVersion int,
+	actor Actor,
+) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:       userID,
+		Email:        email,
+		Roles:        roles,
+		Permissions:  permissions,
+		TokenVersion: tokenVersion,
+		Actor:        &actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ImpersonationDuration)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	return m.sign(claims)
 }
 
 // VerifyAccessToken verifies and parses an access token
@@ -83,13 +215,7 @@ func (m *JWTManager) VerifyAccessToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&Claims{},
-		func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("%w: unexpected signing method %v", ErrInvalidSignature, token.Header["alg"])
-			}
-			return m.secretKey, nil
-		},
+		m.keyFunc,
 	)
 
 	if err != nil {
@@ -100,7 +226,65 @@ func (m *JWTManager) VerifyAccessToken(tokenString string) (*Claims, error) {
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.Actor != nil && (claims.Actor.UserID == "" || claims.IsSuperAdmin) {
+		return nil, ErrInvalidToken
+	}
+	for _, aud := range claims.Audience {
+		if aud == mfaAudience || aud == oauthLinkAudience {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	return claims, nil
+}
+
+// GenerateMFAChallengeToken issues a token proving the first login factor
+// passed. Its ID lets the caller count attempts and consume the challenge.
+func (m *JWTManager) GenerateMFAChallengeToken(userID, purpose, method string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate challenge id: %w", err)
+	}
+
+	now := time.Now()
+	claims := MFAClaims{
+		Purpose: purpose,
+		Method:  method,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(id),
+			Issuer:    m.issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFAChallengeDuration)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	return m.sign(claims)
+}
+
+// VerifyMFAChallengeToken verifies and parses an MFA challenge token
+func (m *JWTManager) VerifyMFAChallengeToken(tokenString string) (*MFAClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&MFAClaims{},
+		m.keyFunc,
+		jwt.WithAudience(mfaAudience),
+	)
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(*MFAClaims)
+	if !ok || !token.Valid || claims.Subject == "" || claims.ID == "" {
 		return nil, ErrInvalidToken
 	}
 
@@ -131,3 +315,48 @@ func (m *JWTManager) GetAccessTokenDuration() time.Duration {
 func (m *JWTManager) GetRefreshTokenDuration() time.Duration {
 	return m.refreshTokenDuration
 }
+
+// GenerateOAuthLinkToken issues a token for a pending provider account link
+func (m *JWTManager) GenerateOAuthLinkToken(userID, provider, providerID, method string, providerData map[string]interface{}) (string, error) {
+	now := time.Now()
+	claims := OAuthLinkClaims{
+		Provider:     provider,
+		ProviderID:   providerID,
+		ProviderData: providerData,
+		Method:       method,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{oauthLinkAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(OAuthLinkDuration)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	return m.sign(claims)
+}
+
+// VerifyOAuthLinkToken verifies and parses a pending OAuth link token
+func (m *JWTManager) VerifyOAuthLinkToken(tokenString string) (*OAuthLinkClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&OAuthLinkClaims{},
+		m.keyFunc,
+		jwt.WithAudience(oauthLinkAudience),
+	)
+
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(*OAuthLinkClaims)
+	if !ok || !token.Valid || claims.Subject == "" || claims.Provider == "" || claims.ProviderID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
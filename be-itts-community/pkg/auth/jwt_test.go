@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewJWTManager(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"secret", "secret", false},
+		{"empty secret", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewJWTManager(tt.secret, time.Minute, time.Hour, "itts")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewJWTManager() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && m.Keys().Active().ID != LegacyHMACKeyID {
+				t.Errorf("active key = %q, want %q", m.Keys().Active().ID, LegacyHMACKeyID)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy decides whether a new password is acceptable
+type PasswordPolicy struct {
+	MinLength  int // default 8
+	MinClasses int // distinct classes of lowercase, uppercase, digit and symbol required (default 3)
+	// Breached optionally rejects passwords found in a breach corpus
+	Breached *BreachedPasswordList
+}
+
+// minFragmentLength ignores short name and email parts such as initials
+const minFragmentLength = 3
+
+// WithDefaults fills in unset limits
+func (p PasswordPolicy) WithDefaults() PasswordPolicy {
+	if p.MinLength <= 0 {
+		p.MinLength = 8
+	}
+	if p.MinClasses <= 0 {
+		p.MinClasses = 3
+	}
+	if p.MinClasses > 4 {
+		p.MinClasses = 4
+	}
+	return p
+}
+
+// Check returns the rules password violates. personal holds the user's
+// email and name, which must not appear in the password. err is only set
+// when the breach lookup fails; the other rules are still reported.
+func (p PasswordPolicy) Check(password string, personal ...string) (violations []string, err error) {
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinClasses))
+	}
+
+	lowered := strings.ToLower(password)
+	for _, fragment := range personalFragments(personal...) {
+		if strings.Contains(lowered, fragment) {
+			violations = append(violations, "must not contain your name or email")
+			break
+		}
+	}
+
+	if p.Breached != nil {
+		breached, lookupErr := p.Breached.Contains(password)
+		if lookupErr != nil {
+			err = lookupErr
+		} else if breached {
+			violations = append(violations, "has appeared in a data breach, choose a different password")
+		}
+	}
+
+	return violations, err
+}
+
+// personalFragments splits emails and names into lowercase words
+func personalFragments(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if local, _, ok := strings.Cut(v, "@"); ok {
+			v = local
+		}
+		words := strings.FieldsFunc(v, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, w := range words {
+			if len([]rune(w)) >= minFragmentLength {
+				out = append(out, w)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPasswordPolicy_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PasswordPolicy
+		want   PasswordPolicy
+	}{
+		{"unset", PasswordPolicy{}, PasswordPolicy{MinLength: 8, MinClasses: 3}},
+		{"negative", PasswordPolicy{MinLength: -1, MinClasses: -1}, PasswordPolicy{MinLength: 8, MinClasses: 3}},
+		{"explicit", PasswordPolicy{MinLength: 12, MinClasses: 2}, PasswordPolicy{MinLength: 12, MinClasses: 2}},
+		{"too many classes", PasswordPolicy{MinLength: 8, MinClasses: 9}, PasswordPolicy{MinLength: 8, MinClasses: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.WithDefaults(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithDefaults() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	const (
+		tooShort   = "must be at least 8 characters"
+		fewClasses = "must contain at least 3 of: lowercase letters, uppercase letters, digits, symbols"
+		personal   = "must not contain your name or email"
+	)
+	policy := PasswordPolicy{}.WithDefaults()
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		want     []string
+	}{
+		{"valid", "Correct-Horse9", nil, nil},
+		{"three classes without symbols", "CorrectHorse9", nil, nil},
+		{"too short", "Ab1!", nil, []string{tooShort}},
+		{"length counts runes", "Ünïcödé1", nil, nil},
+		{"single class", "correcthorsebattery", nil, []string{fewClasses}},
+		{"two classes", "correcthorse99", nil, []string{fewClasses}},
+		{"short and single class", "abc", nil, []string{tooShort, fewClasses}},
+		{"contains email local part", "Xbudi.s4nt0so!", []string{"budi@example.com", "Someone Else"}, []string{personal}},
+		{"contains name", "Santoso#2024x", []string{"x@example.com", "Budi Santoso"}, []string{personal}},
+		{"case insensitive name", "SANTOSO#2024x", []string{"", "budi santoso"}, []string{personal}},
+		{"short fragments ignored", "Tidak#Ada2024", []string{"al@example.com", "Al Bo"}, nil},
+		{"domain ignored", "Example#2024x", []string{"budi@example.com"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Check(tt.password, tt.personal...)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_CheckBreached(t *testing.T) {
+	// A sorted hash file listing "Password1!" but not "Unlisted#2024"
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	content := "0000000000000000000000000000000000000001:1\n" +
+		sha1Hex("Password1!") + ":42\n" +
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:3\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := NewBreachedPasswordList(path)
+	if err != nil {
+		t.Fatalf("NewBreachedPasswordList() error = %v", err)
+	}
+	policy := PasswordPolicy{Breached: list}.WithDefaults()
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"breached", "Password1!", []string{"has appeared in a data breach, choose a different password"}},
+		{"not breached", "Unlisted#2024", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Check(tt.password)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+
+	// A list that became unreadable is reported, the other rules still apply
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := policy.Check("abc")
+	if err == nil {
+		t.Errorf("Check() with a missing list: want an error")
+	}
+	if len(got) != 2 {
+		t.Errorf("Check() with a missing list = %q, want the length and class violations", got)
+	}
+}
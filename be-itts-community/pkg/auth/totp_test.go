@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8 digit codes; a 6 digit code is their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			at := time.Unix(tt.unix, 0)
+			ok, step := ValidateTOTP(rfc6238Secret, tt.code, at)
+			if !ok {
+				t.Fatalf("ValidateTOTP(%q) at %d = false, want true", tt.code, tt.unix)
+			}
+			if want := tt.unix / 30; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / 30
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", rfc6238Secret, hotp(key, uint64(step)), true, step},
+		{"previous step within skew", rfc6238Secret, hotp(key, uint64(step-1)), true, step - 1},
+		{"next step within skew", rfc6238Secret, hotp(key, uint64(step+1)), true, step + 1},
+		{"two steps behind", rfc6238Secret, hotp(key, uint64(step-2)), false, 0},
+		{"two steps ahead", rfc6238Secret, hotp(key, uint64(step+2)), false, 0},
+		{"surrounding spaces", rfc6238Secret, " " + hotp(key, uint64(step)) + " ", true, step},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", hotp(key, uint64(step)), true, step},
+		{"too short", rfc6238Secret, "12345", false, 0},
+		{"too long", rfc6238Secret, "1234567", false, 0},
+		{"empty", rfc6238Secret, "", false, 0},
+		{"invalid secret", "not base32!", "005924", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, gotStep := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP() = (%v, %d), want (%v, %d)", ok, gotStep, tt.wantOK, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret decodes to %d bytes, want 20", len(key))
+	}
+
+	now := time.Now()
+	code := hotp(key, uint64(now.Unix()/30))
+	if ok, _ := ValidateTOTP(secret, code, now); !ok {
+		t.Errorf("ValidateTOTP rejected a code for a generated secret")
+	}
+
+	other, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+	if other == secret {
+		t.Errorf("GenerateTOTPSecret returned the same secret twice")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	raw := TOTPProvisioningURI("ITTS Community", "user@example.com", rfc6238Secret)
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid uri %q: %v", raw, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("uri = %q, want otpauth://totp/...", raw)
+	}
+	if want := "/ITTS Community:user@example.com"; u.Path != want {
+		t.Errorf("label = %q, want %q", u.Path, want)
+	}
+
+	q := u.Query()
+	for key, want := range map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "ITTS Community",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	} {
+		if got := q.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
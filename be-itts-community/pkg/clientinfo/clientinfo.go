@@ -0,0 +1,57 @@
+package clientinfo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Info describes the client that issued a request
+type Info struct {
+	IP        string
+	UserAgent string
+}
+
+// WithInfo stores client info in ctx
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns client info stored by WithInfo (zero value if absent)
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
+
+// FromRequest extracts client info from r. Forwarding headers are only
+// honoured when trustProxy is set, since clients can spoof them otherwise.
+// The IP is empty if it cannot be parsed.
+func FromRequest(r *http.Request, trustProxy bool) Info {
+	ip := ""
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			ip = strings.TrimSpace(strings.Split(fwd, ",")[0])
+		} else if real := r.Header.Get("X-Real-IP"); real != "" {
+			ip = strings.TrimSpace(real)
+		}
+	}
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		ip = parsed.String()
+	} else {
+		ip = ""
+	}
+
+	return Info{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+	}
+}
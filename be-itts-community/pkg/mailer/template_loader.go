@@ -20,6 +20,13 @@ type TemplateData struct {
 	Program    string
 	Email      string
 	VerifyLink string
+	ResetLink  string
+	LockedFor  string
+	InviteLink string
+	InvitedBy  string
+	ExpiresAt  string
+	LoginLink  string
+	CancelLink string
 }
 
 // initTemplates loads all email templates once
@@ -70,3 +77,61 @@ func RenderApprovalEmail(fullName, program, email string) (string, error) {
 		Email:    email,
 	})
 }
+
+// RenderPasswordResetEmail renders the password reset email template
+func RenderPasswordResetEmail(fullName, resetLink string) (string, error) {
+	return RenderTemplate("password_reset.html", TemplateData{
+		FullName:  fullName,
+		ResetLink: resetLink,
+	})
+}
+
+// RenderAccountLockedEmail renders the account lockout notification template
+func RenderAccountLockedEmail(fullName, lockedFor, resetLink string) (string, error) {
+	return RenderTemplate("account_locked.html", TemplateData{
+		FullName:  fullName,
+		LockedFor: lockedFor,
+		ResetLink: resetLink,
+	})
+}
+
+// RenderInvitationEmail renders the admin invitation email template
+func RenderInvitationEmail(fullName, invitedBy, inviteLink, expiresAt string) (string, error) {
+	return RenderTemplate("invitation.html", TemplateData{
+		FullName:   fullName,
+		InvitedBy:  invitedBy,
+		InviteLink: inviteLink,
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// RenderMagicLinkEmail renders the passwordless login email template
+func RenderMagicLinkEmail(fullName, loginLink, expiresIn string) (string, error) {
+	return RenderTemplate("magic_link.html", TemplateData{
+		FullName:  fullName,
+		LoginLink: loginLink,
+		ExpiresAt: expiresIn,
+	})
+}
+
+// RenderEmailChangeConfirmEmail renders the email change confirmation
+// template sent to the new address
+func RenderEmailChangeConfirmEmail(fullName, newEmail, confirmLink, expiresIn string) (string, error) {
+	return RenderTemplate("email_change_confirm.html", TemplateData{
+		FullName:   fullName,
+		Email:      newEmail,
+		VerifyLink: confirmLink,
+		ExpiresAt:  expiresIn,
+	})
+}
+
+// RenderEmailChangeNoticeEmail renders the email change notice template sent
+// to the current address, with a link that cancels the change
+func RenderEmailChangeNoticeEmail(fullName, newEmail, cancelLink, expiresIn string) (string, error) {
+	return RenderTemplate("email_change_notice.html", TemplateData{
+		FullName:   fullName,
+		Email:      newEmail,
+		CancelLink: cancelLink,
+		ExpiresAt:  expiresIn,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 )
 
 // GitHubUser represents the GitHub user profile
@@ -39,24 +40,77 @@ func NewGitHubOAuthClient(clientID, clientSecret, redirectURI string) *GitHubOAu
 	}
 }
 
-// GetAuthURL returns the GitHub OAuth authorization URL
-func (c *GitHubOAuthClient) GetAuthURL(state string) string {
+// Name implements Provider
+func (c *GitHubOAuthClient) Name() string {
+	return "github"
+}
+
+// AuthURL implements Provider
+func (c *GitHubOAuthClient) AuthURL(_ context.Context, state *State) (string, error) {
+	return c.GetAuthURL(state.Nonce, PKCEChallenge(state.CodeVerifier)), nil
+}
+
+// Exchange implements Provider
+func (c *GitHubOAuthClient) Exchange(ctx context.Context, code string, state *State) (*UserInfo, error) {
+	accessToken, err := c.ExchangeCode(ctx, code, state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.GetUser(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to login if name not set
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	return &UserInfo{
+		ProviderUserID: strconv.FormatInt(user.ID, 10),
+		Email:          user.Email,
+		// Only verified addresses are returned by getPrimaryEmail; public
+		// profile emails must be verified on GitHub too
+		EmailVerified: user.Email != "",
+		Name:          name,
+		Data: map[string]interface{}{
+			"login":      user.Login,
+			"avatar_url": user.AvatarURL,
+			"bio":        user.Bio,
+			"location":   user.Location,
+			"company":    user.Company,
+		},
+	}, nil
+}
+
+// GetAuthURL returns the GitHub OAuth authorization URL. codeChallenge is
+// the PKCE S256 challenge of the verifier later passed to ExchangeCode.
+func (c *GitHubOAuthClient) GetAuthURL(state, codeChallenge string) string {
 	params := url.Values{}
 	params.Add("client_id", c.clientID)
 	params.Add("redirect_uri", c.redirectURI)
 	params.Add("scope", "read:user user:email")
 	params.Add("state", state)
+	if codeChallenge != "" {
+		params.Add("code_challenge", codeChallenge)
+		params.Add("code_challenge_method", PKCEMethod)
+	}
 
 	return fmt.Sprintf("https://github.com/login/oauth/authorize?%s", params.Encode())
 }
 
 // ExchangeCode exchanges authorization code for access token
-func (c *GitHubOAuthClient) ExchangeCode(ctx context.Context, code string) (string, error) {
+func (c *GitHubOAuthClient) ExchangeCode(ctx context.Context, code, codeVerifier string) (string, error) {
 	params := url.Values{}
 	params.Add("client_id", c.clientID)
 	params.Add("client_secret", c.clientSecret)
 	params.Add("code", code)
 	params.Add("redirect_uri", c.redirectURI)
+	if codeVerifier != "" {
+		params.Add("code_verifier", codeVerifier)
+	}
 
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -0,0 +1,316 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+
+	"be-itts-community/pkg/auth"
+)
+
+// OIDCConfig configures a generic OpenID Connect provider
+type OIDCConfig struct {
+	Name         string
+	Issuer       string // e.g. https://accounts.google.com
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Scopes       []string // defaults to openid email profile
+}
+
+// oidcDiscovery is the subset of the discovery document we use
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// idTokenClaims are the ID token claims we read
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // some providers send "true"
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+	Locale        string `json:"locale"`
+}
+
+// OIDCProvider logs users in with any OpenID Connect provider. The discovery
+// document and signing keys are fetched lazily and cached. Concurrent misses
+// share one fetch, made without holding mu.
+type OIDCProvider struct {
+	cfg        OIDCConfig
+	httpClient *http.Client
+	fetches    singleflight.Group
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]auth.JWK
+	keysFetchedAt time.Time
+}
+
+// jwksMinRefresh bounds how often an unknown kid triggers a JWKS refetch
+const jwksMinRefresh = time.Minute
+
+// NewOIDCProvider creates a provider from cfg
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	return &OIDCProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewGoogleProvider is the OIDC preset for Google accounts
+func NewGoogleProvider(clientID, clientSecret, redirectURI string) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "google",
+		Issuer:       "https://accounts.google.com",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+	})
+}
+
+// Name implements Provider
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthURL implements Provider
+func (p *OIDCProvider) AuthURL(ctx context.Context, state *State) (string, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Add("client_id", p.cfg.ClientID)
+	params.Add("redirect_uri", p.cfg.RedirectURI)
+	params.Add("response_type", "code")
+	params.Add("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Add("state", state.Nonce)
+	params.Add("nonce", state.Nonce)
+	params.Add("code_challenge", PKCEChallenge(state.CodeVerifier))
+	params.Add("code_challenge_method", PKCEMethod)
+
+	sep := "?"
+	if strings.Contains(disc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return disc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange implements Provider. The identity is taken from the verified ID
+// token, not from the userinfo endpoint.
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, state *State) (*UserInfo, error) {
+	disc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Add("grant_type", "authorization_code")
+	form.Add("code", code)
+	form.Add("redirect_uri", p.cfg.RedirectURI)
+	form.Add("client_id", p.cfg.ClientID)
+	form.Add("client_secret", p.cfg.ClientSecret)
+	form.Add("code_verifier", state.CodeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", disc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s returned status %d: %s", p.cfg.Name, resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.IDToken == "" {
+		return nil, fmt.Errorf("no id token in response")
+	}
+
+	claims, err := p.verifyIDToken(ctx, disc, result.IDToken, state.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"issuer": claims.Issuer,
+	}
+	if claims.Picture != "" {
+		data["avatar_url"] = claims.Picture
+	}
+	if claims.Locale != "" {
+		data["locale"] = claims.Locale
+	}
+
+	return &UserInfo{
+		ProviderUserID: claims.Subject,
+		Email:          claims.Email,
+		EmailVerified:  claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:           claims.Name,
+		Data:           data,
+	}, nil
+}
+
+// verifyIDToken checks signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, disc *oidcDiscovery, raw, nonce string) (*idTokenClaims, error) {
+	algs := disc.SigningAlgs
+	if len(algs) == 0 {
+		algs = []string{"RS256"}
+	}
+
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		jwk, err := p.getKey(ctx, disc, kid)
+		if err != nil {
+			return nil, err
+		}
+		return jwk.PublicKey()
+	},
+		jwt.WithValidMethods(algs),
+		jwt.WithIssuer(disc.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid id token: missing subject")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	disc := p.discovery
+	p.mu.Unlock()
+	if disc != nil {
+		return disc, nil
+	}
+
+	// The fetch is shared, so one caller's cancellation must not fail the rest
+	ctx = context.WithoutCancel(ctx)
+	v, err, _ := p.fetches.Do("discovery", func() (interface{}, error) {
+		var disc oidcDiscovery
+		if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &disc); err != nil {
+			return nil, fmt.Errorf("failed to load %s discovery document: %w", p.cfg.Name, err)
+		}
+		if disc.Issuer != p.cfg.Issuer {
+			return nil, fmt.Errorf("discovery issuer %q does not match %q", disc.Issuer, p.cfg.Issuer)
+		}
+		if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.JWKSURI == "" {
+			return nil, fmt.Errorf("incomplete %s discovery document", p.cfg.Name)
+		}
+
+		p.mu.Lock()
+		p.discovery = &disc
+		p.mu.Unlock()
+		return &disc, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*oidcDiscovery), nil
+}
+
+// getKey returns the signing key with kid, refetching the JWKS when the
+// provider rotated its keys
+func (p *OIDCProvider) getKey(ctx context.Context, disc *oidcDiscovery, kid string) (auth.JWK, error) {
+	p.mu.Lock()
+	jwk, ok := p.keys[kid]
+	recent := p.keys != nil && time.Since(p.keysFetchedAt) < jwksMinRefresh
+	p.mu.Unlock()
+	if ok {
+		return jwk, nil
+	}
+	if recent {
+		return auth.JWK{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	v, err, _ := p.fetches.Do("jwks", func() (interface{}, error) {
+		var set auth.JWKS
+		if err := p.getJSON(ctx, disc.JWKSURI, &set); err != nil {
+			return nil, fmt.Errorf("failed to load %s signing keys: %w", p.cfg.Name, err)
+		}
+		keys := make(map[string]auth.JWK, len(set.Keys))
+		for _, k := range set.Keys {
+			if k.Use == "" || k.Use == "sig" {
+				keys[k.Kid] = k
+			}
+		}
+
+		p.mu.Lock()
+		p.keys = keys
+		p.keysFetchedAt = time.Now()
+		p.mu.Unlock()
+		return keys, nil
+	})
+	if err != nil {
+		return auth.JWK{}, err
+	}
+
+	jwk, ok = v.(map[string]auth.JWK)[kid]
+	if !ok {
+		return auth.JWK{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return jwk, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,65 @@
+package oauth
+
+import (
+	"context"
+	"sort"
+)
+
+// UserInfo is the identity a provider returned after a successful login
+type UserInfo struct {
+	ProviderUserID string
+	Email          string
+	EmailVerified  bool
+	Name           string
+	// Data is stored on the linked OAuth account as provider_data
+	Data map[string]interface{}
+}
+
+// Provider is an OAuth 2.0 / OpenID Connect login provider
+type Provider interface {
+	// Name is the path segment and OAuthAccount.Provider value, e.g. "google"
+	Name() string
+	// AuthURL returns the provider URL to send the browser to. The state
+	// carries the CSRF nonce and PKCE verifier.
+	AuthURL(ctx context.Context, state *State) (string, error)
+	// Exchange trades the callback code for the user's identity
+	Exchange(ctx context.Context, code string, state *State) (*UserInfo, error)
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates a registry; nil providers are skipped so callers can
+// pass optional ones unconditionally
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		if p != nil {
+			r.Register(p)
+		}
+	}
+	return r
+}
+
+// Register adds or replaces a provider
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+}
+
+// Get returns the provider with the given name
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names returns the registered provider names, sorted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,159 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidState = errors.New("invalid oauth state")
+	ErrExpiredState = errors.New("oauth state has expired")
+)
+
+// State purposes
+const (
+	StatePurposeLogin      = "login"
+	StatePurposeLink       = "link"        // link the provider account to LinkUserID
+	StatePurposeLinkTicket = "link_ticket" // short-lived ticket that starts a link flow
+	StatePurposeInvite     = "invite"      // accept the Invitation token with the provider account
+)
+
+// LinkTicketDuration is how long a link ticket can be used to start a flow
+const LinkTicketDuration = 2 * time.Minute
+
+// State is what we remember between redirecting to the provider and the
+// callback. It travels in a signed cookie so no server-side store is needed.
+type State struct {
+	Purpose      string    `json:"k"`
+	Provider     string    `json:"p"`
+	LinkUserID   string    `json:"u,omitempty"`
+	Nonce        string    `json:"n"`  // echoed back by the provider as ?state= and used as the OIDC nonce
+	CodeVerifier string    `json:"cv"` // PKCE verifier
+	RedirectTo   string    `json:"r,omitempty"`
+	Invitation   string    `json:"i,omitempty"`
+	ExpiresAt    time.Time `json:"exp"`
+}
+
+// StateCodec signs and verifies State values with HMAC-SHA256
+type StateCodec struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewStateCodec creates a codec; ttl bounds how long a login may take
+func NewStateCodec(secret string, ttl time.Duration) (*StateCodec, error) {
+	if secret == "" {
+		return nil, errors.New("oauth state secret is empty")
+	}
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	sum := sha256.Sum256([]byte("oauth-state:" + secret))
+	return &StateCodec{key: sum[:], ttl: ttl}, nil
+}
+
+// TTL returns how long issued states stay valid
+func (c *StateCodec) TTL() time.Duration {
+	return c.ttl
+}
+
+// New creates a fresh state with a random nonce and PKCE verifier
+func (c *StateCodec) New(provider, redirectTo string) (*State, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate state: %w", err)
+	}
+	verifier, _, err := GeneratePKCE()
+	if err != nil {
+		return nil, err
+	}
+	return &State{
+		Purpose:      StatePurposeLogin,
+		Provider:     provider,
+		Nonce:        base64.RawURLEncoding.EncodeToString(b),
+		CodeVerifier: verifier,
+		RedirectTo:   redirectTo,
+		ExpiresAt:    time.Now().Add(c.ttl),
+	}, nil
+}
+
+// Encode serializes and signs s as payload.signature
+func (c *StateCodec) Encode(s *State) (string, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state: %w", err)
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + c.sign(payload), nil
+}
+
+// Decode verifies the signature and expiry of an encoded state
+func (c *StateCodec) Decode(value string) (*State, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(c.sign(payload))) {
+		return nil, ErrInvalidState
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	var s State
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, ErrInvalidState
+	}
+	if time.Now().After(s.ExpiresAt) {
+		return nil, ErrExpiredState
+	}
+	return &s, nil
+}
+
+// Verify decodes value and checks that it was issued for provider and that
+// the provider echoed our nonce
+func (c *StateCodec) Verify(value, provider, nonce string) (*State, error) {
+	s, err := c.Decode(value)
+	if err != nil {
+		return nil, err
+	}
+	if s.Purpose == StatePurposeLinkTicket || s.Provider != provider || nonce == "" || subtle.ConstantTimeCompare([]byte(s.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrInvalidState
+	}
+	return s, nil
+}
+
+// NewLinkTicket issues a ticket that lets userID start linking provider.
+// It is requested with the user's access token and then passed to the
+// browser redirect, which cannot carry an Authorization header.
+func (c *StateCodec) NewLinkTicket(provider, userID string) (string, error) {
+	return c.Encode(&State{
+		Purpose:    StatePurposeLinkTicket,
+		Provider:   provider,
+		LinkUserID: userID,
+		ExpiresAt:  time.Now().Add(LinkTicketDuration),
+	})
+}
+
+// VerifyLinkTicket returns the user a link ticket was issued to
+func (c *StateCodec) VerifyLinkTicket(value, provider string) (string, error) {
+	s, err := c.Decode(value)
+	if err != nil {
+		return "", err
+	}
+	if s.Purpose != StatePurposeLinkTicket || s.Provider != provider || s.LinkUserID == "" {
+		return "", ErrInvalidState
+	}
+	return s.LinkUserID, nil
+}
+
+func (c *StateCodec) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package oauth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCodec(t *testing.T, secret string) *StateCodec {
+	t.Helper()
+	c, err := NewStateCodec(secret, time.Minute)
+	if err != nil {
+		t.Fatalf("NewStateCodec() error = %v", err)
+	}
+	return c
+}
+
+func TestNewStateCodec(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		ttl     time.Duration
+		wantTTL time.Duration
+		wantErr bool
+	}{
+		{"explicit ttl", "secret", 5 * time.Minute, 5 * time.Minute, false},
+		{"zero ttl uses default", "secret", 0, 10 * time.Minute, false},
+		{"negative ttl uses default", "secret", -time.Second, 10 * time.Minute, false},
+		{"empty secret", "", time.Minute, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewStateCodec(tt.secret, tt.ttl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStateCodec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && c.TTL() != tt.wantTTL {
+				t.Errorf("TTL() = %v, want %v", c.TTL(), tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestStateCodec_RoundTrip(t *testing.T) {
+	c := newTestCodec(t, "secret")
+
+	s, err := c.New("google", "/dashboard")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if s.Purpose != StatePurposeLogin || s.Nonce == "" || s.CodeVerifier == "" {
+		t.Fatalf("New() = %+v, want a login state with nonce and verifier", s)
+	}
+
+	encoded, err := c.Encode(s)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	got, err := c.Verify(encoded, "google", s.Nonce)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.Provider != s.Provider || got.Nonce != s.Nonce || got.CodeVerifier != s.CodeVerifier || got.RedirectTo != s.RedirectTo {
+		t.Errorf("Verify() = %+v, want %+v", got, s)
+	}
+}
+
+func TestStateCodec_Verify(t *testing.T) {
+	c := newTestCodec(t, "secret")
+	other := newTestCodec(t, "other secret")
+
+	valid := &State{
+		Purpose:   StatePurposeLogin,
+		Provider:  "google",
+		Nonce:     "nonce",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	encode := func(c *StateCodec, s State) string {
+		t.Helper()
+		v, err := c.Encode(&s)
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		return v
+	}
+	expired := *valid
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	ticket := *valid
+	ticket.Purpose = StatePurposeLinkTicket
+
+	good := encode(c, *valid)
+	payload, sig, _ := strings.Cut(good, ".")
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"k":"login","p":"github","n":"nonce"}`)) + "." + sig
+
+	tests := []struct {
+		name     string
+		value    string
+		provider string
+		nonce    string
+		wantErr  error
+	}{
+		{"valid", good, "google", "nonce", nil},
+		{"wrong provider", good, "github", "nonce", ErrInvalidState},
+		{"wrong nonce", good, "google", "other", ErrInvalidState},
+		{"empty nonce", good, "google", "", ErrInvalidState},
+		{"signed with another key", encode(other, *valid), "google", "nonce", ErrInvalidState},
+		{"tampered payload", tampered, "google", "nonce", ErrInvalidState},
+		{"missing signature", payload, "google", "nonce", ErrInvalidState},
+		{"empty", "", "google", "nonce", ErrInvalidState},
+		{"expired", encode(c, expired), "google", "nonce", ErrExpiredState},
+		{"link ticket", encode(c, ticket), "google", "nonce", ErrInvalidState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Verify(tt.value, tt.provider, tt.nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStateCodec_LinkTicket(t *testing.T) {
+	c := newTestCodec(t, "secret")
+
+	ticket, err := c.NewLinkTicket("github", "user-1")
+	if err != nil {
+		t.Fatalf("NewLinkTicket() error = %v", err)
+	}
+	login, err := c.Encode(&State{
+		Purpose:    StatePurposeLogin,
+		Provider:   "github",
+		LinkUserID: "user-1",
+		ExpiresAt:  time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	noUser, err := c.Encode(&State{
+		Purpose:   StatePurposeLinkTicket,
+		Provider:  "github",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		provider string
+		wantUser string
+		wantErr  error
+	}{
+		{"valid", ticket, "github", "user-1", nil},
+		{"wrong provider", ticket, "google", "", ErrInvalidState},
+		{"login state", login, "github", "", ErrInvalidState},
+		{"no user", noUser, "github", "", ErrInvalidState},
+		{"garbage", "not.a-ticket", "github", "", ErrInvalidState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := c.VerifyLinkTicket(tt.value, tt.provider)
+			if !errors.Is(err, tt.wantErr) || user != tt.wantUser {
+				t.Errorf("VerifyLinkTicket() = (%q, %v), want (%q, %v)", user, err, tt.wantUser, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package permcache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	userID      string
+	permissions []string
+	expiresAt   time.Time
+}
+
+// LRUStore implements Store in process memory (single instance / dev). The
+// least recently used entry is evicted once size entries are held.
+type LRUStore struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+	// epoch counts invalidations; a Set stamped with an older one is dropped
+	epoch uint64
+}
+
+func NewLRUStore(size int, ttl time.Duration) *LRUStore {
+	return &LRUStore{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (s *LRUStore) Get(ctx context.Context, userID string) ([]string, string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stamp := strconv.FormatUint(s.epoch, 10)
+	el := s.entries[userID]
+	if el == nil {
+		return nil, stamp, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if e.expiresAt.Before(time.Now()) {
+		s.order.Remove(el)
+		delete(s.entries, userID)
+		return nil, stamp, false, nil
+	}
+	s.order.MoveToFront(el)
+	return e.permissions, stamp, true, nil
+}
+
+func (s *LRUStore) Set(ctx context.Context, userID string, permissions []string, stamp string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stamp != strconv.FormatUint(s.epoch, 10) {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	if el := s.entries[userID]; el != nil {
+		e := el.Value.(*lruEntry)
+		e.permissions = permissions
+		e.expiresAt = expiresAt
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.entries[userID] = s.order.PushFront(&lruEntry{userID: userID, permissions: permissions, expiresAt: expiresAt})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).userID)
+	}
+	return nil
+}
+
+func (s *LRUStore) Delete(ctx context.Context, userIDs ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epoch++
+	for _, userID := range userIDs {
+		if el := s.entries[userID]; el != nil {
+			s.order.Remove(el)
+			delete(s.entries, userID)
+		}
+	}
+	return nil
+}
+
+func (s *LRUStore) Clear(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.epoch++
+	s.order.Init()
+	s.entries = make(map[string]*list.Element)
+	return nil
+}
@@ -0,0 +1,192 @@
+package permcache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fill caches permissions for userID the way Cache.Load does
+func fill(t *testing.T, s Store, userID string, permissions ...string) {
+	t.Helper()
+	ctx := context.Background()
+	_, stamp, _, err := s.Get(ctx, userID)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", userID, err)
+	}
+	if err := s.Set(ctx, userID, permissions, stamp); err != nil {
+		t.Fatalf("Set(%q) error = %v", userID, err)
+	}
+}
+
+func TestLRUStore(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		size    int
+		ttl     time.Duration
+		run     func(t *testing.T, s *LRUStore)
+		cached  []string
+		missing []string
+	}{
+		{
+			name:    "hit and miss",
+			size:    4,
+			ttl:     time.Minute,
+			run:     func(t *testing.T, s *LRUStore) { fill(t, s, "a", "events:read") },
+			cached:  []string{"a"},
+			missing: []string{"b"},
+		},
+		{
+			name:    "expired entry",
+			size:    4,
+			ttl:     -time.Second,
+			run:     func(t *testing.T, s *LRUStore) { fill(t, s, "a", "events:read") },
+			missing: []string{"a"},
+		},
+		{
+			name: "evicts least recently set",
+			size: 2,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				fill(t, s, "a")
+				fill(t, s, "b")
+				fill(t, s, "c")
+			},
+			cached:  []string{"b", "c"},
+			missing: []string{"a"},
+		},
+		{
+			name: "get refreshes recency",
+			size: 2,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				fill(t, s, "a")
+				fill(t, s, "b")
+				if _, _, ok, _ := s.Get(ctx, "a"); !ok {
+					t.Fatalf("Get(a) missed before eviction")
+				}
+				fill(t, s, "c")
+			},
+			cached:  []string{"a", "c"},
+			missing: []string{"b"},
+		},
+		{
+			name: "set refreshes recency",
+			size: 2,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				fill(t, s, "a")
+				fill(t, s, "b")
+				fill(t, s, "a", "events:read")
+				fill(t, s, "c")
+			},
+			cached:  []string{"a", "c"},
+			missing: []string{"b"},
+		},
+		{
+			name: "delete",
+			size: 4,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				fill(t, s, "a")
+				fill(t, s, "b")
+				fill(t, s, "c")
+				if err := s.Delete(ctx, "a", "b", "unknown"); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			},
+			cached:  []string{"c"},
+			missing: []string{"a", "b"},
+		},
+		{
+			name: "clear",
+			size: 4,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				fill(t, s, "a")
+				fill(t, s, "b")
+				if err := s.Clear(ctx); err != nil {
+					t.Fatalf("Clear() error = %v", err)
+				}
+			},
+			missing: []string{"a", "b"},
+		},
+		{
+			name: "set after delete of another user is dropped",
+			size: 4,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				_, stamp, _, _ := s.Get(ctx, "a")
+				if err := s.Delete(ctx, "b"); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+				if err := s.Set(ctx, "a", []string{"events:*"}, stamp); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			},
+			missing: []string{"a"},
+		},
+		{
+			name: "set after clear is dropped",
+			size: 4,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				_, stamp, _, _ := s.Get(ctx, "a")
+				if err := s.Clear(ctx); err != nil {
+					t.Fatalf("Clear() error = %v", err)
+				}
+				if err := s.Set(ctx, "a", []string{"events:*"}, stamp); err != nil {
+					t.Fatalf("Set() error = %v", err)
+				}
+			},
+			missing: []string{"a"},
+		},
+		{
+			name: "fresh stamp after invalidation",
+			size: 4,
+			ttl:  time.Minute,
+			run: func(t *testing.T, s *LRUStore) {
+				if err := s.Delete(ctx, "a"); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+				fill(t, s, "a")
+			},
+			cached: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewLRUStore(tt.size, tt.ttl)
+			tt.run(t, s)
+
+			for _, userID := range tt.cached {
+				if _, _, ok, err := s.Get(ctx, userID); err != nil || !ok {
+					t.Errorf("Get(%q) = (ok %v, %v), want a hit", userID, ok, err)
+				}
+			}
+			for _, userID := range tt.missing {
+				if _, _, ok, err := s.Get(ctx, userID); err != nil || ok {
+					t.Errorf("Get(%q) = (ok %v, %v), want a miss", userID, ok, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLRUStore_GetReturnsLatestSet(t *testing.T) {
+	s := NewLRUStore(4, time.Minute)
+	fill(t, s, "a", "events:read")
+	fill(t, s, "a", "events:read", "events:update")
+
+	got, _, ok, err := s.Get(context.Background(), "a")
+	if err != nil || !ok {
+		t.Fatalf("Get() = (ok %v, %v), want a hit", ok, err)
+	}
+	if want := []string{"events:read", "events:update"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package permcache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements Store on Redis so invalidation reaches every
+// instance. Entries are keyed under a generation counter; Clear bumps the
+// generation instead of scanning for keys and old entries expire on their own.
+// Set compares the generation and an epoch counter bumped by Delete, so a
+// list read before an invalidation is never stored. Each operation is a
+// single round trip.
+type RedisStore struct {
+	Client *redis.Client
+	Prefix string
+	TTL    time.Duration
+}
+
+func NewRedisStore(c *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{Client: c, Prefix: "permcache:", TTL: ttl}
+}
+
+func (s *RedisStore) genKey() string   { return s.Prefix + "gen" }
+func (s *RedisStore) epochKey() string { return s.Prefix + "epoch" }
+
+// The scripts build entry keys as prefix..gen..":"..userID from ARGV, since
+// the generation is only known inside the script.
+
+// getScript returns the stamp "gen:epoch" and the entry, if any
+var getScript = redis.NewScript(`
+local gen = redis.call("GET", KEYS[1]) or "0"
+local epoch = redis.call("GET", KEYS[2]) or "0"
+local raw = redis.call("GET", ARGV[1] .. gen .. ":" .. ARGV[2])
+return {gen .. ":" .. epoch, raw}
+`)
+
+// setScript stores the entry only while the stamp is still current
+var setScript = redis.NewScript(`
+local gen = redis.call("GET", KEYS[1]) or "0"
+local epoch = redis.call("GET", KEYS[2]) or "0"
+if gen .. ":" .. epoch ~= ARGV[3] then
+	return 0
+end
+redis.call("SET", ARGV[1] .. gen .. ":" .. ARGV[2], ARGV[4], "PX", ARGV[5])
+return 1
+`)
+
+// deleteScript drops the entries of ARGV[2..] and bumps the epoch
+var deleteScript = redis.NewScript(`
+local gen = redis.call("GET", KEYS[1]) or "0"
+redis.call("INCR", KEYS[2])
+for i = 2, #ARGV do
+	redis.call("DEL", ARGV[1] .. gen .. ":" .. ARGV[i])
+end
+return 1
+`)
+
+func (s *RedisStore) keys() []string {
+	return []string{s.genKey(), s.epochKey()}
+}
+
+func (s *RedisStore) Get(ctx context.Context, userID string) ([]string, string, bool, error) {
+	res, err := getScript.Run(ctx, s.Client, s.keys(), s.Prefix, userID).Slice()
+	if err != nil {
+		return nil, "", false, err
+	}
+	stamp, _ := res[0].(string)
+	raw, ok := res[1].(string)
+	if !ok {
+		return nil, stamp, false, nil
+	}
+	var permissions []string
+	if err := json.Unmarshal([]byte(raw), &permissions); err != nil {
+		return nil, stamp, false, err
+	}
+	return permissions, stamp, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, userID string, permissions []string, stamp string) error {
+	raw, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+	return setScript.Run(ctx, s.Client, s.keys(), s.Prefix, userID, stamp, raw, s.TTL.Milliseconds()).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, userIDs ...string) error {
+	args := make([]interface{}, 0, len(userIDs)+1)
+	args = append(args, s.Prefix)
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	return deleteScript.Run(ctx, s.Client, s.keys(), args...).Err()
+}
+
+func (s *RedisStore) Clear(ctx context.Context) error {
+	return s.Client.Incr(ctx, s.genKey()).Err()
+}
@@ -0,0 +1,105 @@
+package permcache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Store keeps permission lists per user. Implementations must be safe for
+// concurrent use and expire entries on their own.
+type Store interface {
+	// Get returns the cached permissions of userID; ok is false on a miss.
+	// stamp identifies the invalidation state seen, for a following Set.
+	Get(ctx context.Context, userID string) (permissions []string, stamp string, ok bool, err error)
+	// Set caches permissions read after a Get returned stamp. It does
+	// nothing when an invalidation happened since, so a list read before a
+	// role change cannot outlive it.
+	Set(ctx context.Context, userID string, permissions []string, stamp string) error
+	// Delete drops the entries of the given users
+	Delete(ctx context.Context, userIDs ...string) error
+	// Clear drops every entry, for changes that affect unknown users
+	Clear(ctx context.Context) error
+}
+
+// Stats are the cache counters since start
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Errors counts store failures; they are served as misses
+	Errors int64 `json:"errors"`
+}
+
+// Cache wraps a Store with hit/miss counters. A nil Cache is valid and
+// never hits, so callers work without one.
+type Cache struct {
+	store  Store
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func New(store Store) *Cache {
+	return &Cache{store: store}
+}
+
+// Load returns the permissions of userID from the store, or from load on a
+// miss and caches them. Store errors count as a miss and are not cached over.
+func (c *Cache) Load(ctx context.Context, userID string, load func(ctx context.Context) ([]string, error)) ([]string, error) {
+	if c == nil {
+		return load(ctx)
+	}
+
+	permissions, stamp, ok, err := c.store.Get(ctx, userID)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	if err == nil && ok {
+		c.hits.Add(1)
+		return permissions, nil
+	}
+	c.misses.Add(1)
+
+	permissions, loadErr := load(ctx)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if err != nil {
+		return permissions, nil
+	}
+	cached := permissions
+	if cached == nil {
+		cached = []string{}
+	}
+	// Failures only cost a later miss
+	if err := c.store.Set(ctx, userID, cached, stamp); err != nil {
+		c.errors.Add(1)
+	}
+	return permissions, nil
+}
+
+// Invalidate drops the entries of the given users
+func (c *Cache) Invalidate(ctx context.Context, userIDs ...string) error {
+	if c == nil || len(userIDs) == 0 {
+		return nil
+	}
+	return c.store.Delete(ctx, userIDs...)
+}
+
+// InvalidateAll drops every entry
+func (c *Cache) InvalidateAll(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.store.Clear(ctx)
+}
+
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Errors: c.errors.Load(),
+	}
+}
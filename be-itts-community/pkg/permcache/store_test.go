@@ -0,0 +1,147 @@
+package permcache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// failingStore fails every call
+type failingStore struct{}
+
+var errStore = errors.New("store unavailable")
+
+func (failingStore) Get(context.Context, string) ([]string, string, bool, error) {
+	return nil, "", false, errStore
+}
+func (failingStore) Set(context.Context, string, []string, string) error { return errStore }
+func (failingStore) Delete(context.Context, ...string) error             { return errStore }
+func (failingStore) Clear(context.Context) error                         { return errStore }
+
+func TestCache_Load(t *testing.T) {
+	ctx := context.Background()
+	errLoad := errors.New("database down")
+
+	tests := []struct {
+		name      string
+		cache     *Cache
+		loads     []error
+		want      []string
+		wantErr   error
+		wantCalls int
+		wantStats Stats
+	}{
+		{
+			name:      "nil cache always loads",
+			cache:     nil,
+			loads:     []error{nil, nil},
+			want:      []string{"events:read"},
+			wantCalls: 2,
+		},
+		{
+			name:      "second load is a hit",
+			cache:     New(NewLRUStore(4, time.Minute)),
+			loads:     []error{nil, nil},
+			want:      []string{"events:read"},
+			wantCalls: 1,
+			wantStats: Stats{Hits: 1, Misses: 1},
+		},
+		{
+			name:      "load errors are not cached",
+			cache:     New(NewLRUStore(4, time.Minute)),
+			loads:     []error{errLoad, nil},
+			want:      []string{"events:read"},
+			wantCalls: 2,
+			wantStats: Stats{Misses: 2},
+		},
+		{
+			name:      "load error is returned",
+			cache:     New(NewLRUStore(4, time.Minute)),
+			loads:     []error{errLoad},
+			wantErr:   errLoad,
+			wantCalls: 1,
+			wantStats: Stats{Misses: 1},
+		},
+		{
+			name:      "store errors are served as misses",
+			cache:     New(failingStore{}),
+			loads:     []error{nil, nil},
+			want:      []string{"events:read"},
+			wantCalls: 2,
+			wantStats: Stats{Misses: 2, Errors: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			var got []string
+			var err error
+			for _, loadErr := range tt.loads {
+				got, err = tt.cache.Load(ctx, "a", func(context.Context) ([]string, error) {
+					calls++
+					if loadErr != nil {
+						return nil, loadErr
+					}
+					return []string{"events:read"}, nil
+				})
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %q, want %q", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("load called %d times, want %d", calls, tt.wantCalls)
+			}
+			if stats := tt.cache.Stats(); stats != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewLRUStore(4, time.Minute))
+	load := func(context.Context) ([]string, error) { return []string{"events:read"}, nil }
+
+	for _, userID := range []string{"a", "b"} {
+		if _, err := c.Load(ctx, userID, load); err != nil {
+			t.Fatalf("Load(%q) error = %v", userID, err)
+		}
+	}
+	if err := c.Invalidate(ctx, "a"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	for _, userID := range []string{"a", "b"} {
+		if _, err := c.Load(ctx, userID, load); err != nil {
+			t.Fatalf("Load(%q) error = %v", userID, err)
+		}
+	}
+	if want := (Stats{Hits: 1, Misses: 3}); c.Stats() != want {
+		t.Errorf("Stats() after Invalidate = %+v, want %+v", c.Stats(), want)
+	}
+
+	if err := c.InvalidateAll(ctx); err != nil {
+		t.Fatalf("InvalidateAll() error = %v", err)
+	}
+	if _, err := c.Load(ctx, "b", load); err != nil {
+		t.Fatalf("Load(b) error = %v", err)
+	}
+	if want := (Stats{Hits: 1, Misses: 4}); c.Stats() != want {
+		t.Errorf("Stats() after InvalidateAll = %+v, want %+v", c.Stats(), want)
+	}
+
+	var nilCache *Cache
+	if err := nilCache.Invalidate(ctx, "a"); err != nil {
+		t.Errorf("nil Invalidate() error = %v", err)
+	}
+	if err := nilCache.InvalidateAll(ctx); err != nil {
+		t.Errorf("nil InvalidateAll() error = %v", err)
+	}
+}
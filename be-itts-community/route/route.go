@@ -3,32 +3,46 @@ package routes
 import (
 	"time"
 
+	"github.com/daisyorscry/itts/core"
 	"github.com/go-chi/chi/v5"
 
 	"be-itts-community/internal/db"
 	"be-itts-community/internal/handler/rest"
 	"be-itts-community/internal/middleware"
+	"be-itts-community/internal/rbac"
 	"be-itts-community/internal/repository"
 	"be-itts-community/internal/service"
 	"be-itts-community/pkg/auth"
 	"be-itts-community/pkg/lock"
 	"be-itts-community/pkg/oauth"
 	"be-itts-community/pkg/observability/nr"
+	"be-itts-community/pkg/permcache"
 )
 
 type RouteDeps struct {
-	DBConn              db.Connection
-	VerifyEmailURL      string
-	Mailer              service.Mailer
-	Locker              lock.Locker
-	Tracer              nr.Tracer
-	JWTSecret           string
-	JWTAccessDur        time.Duration
-	JWTRefreshDur       time.Duration
-	JWTIssuer           string
-	GitHubClientID      string
-	GitHubClientSecret  string
-	GitHubRedirectURI   string
+	DBConn               db.Connection
+	VerifyEmailURL       string
+	ResetPasswordURL     string
+	InviteAcceptURL      string
+	MagicLinkURL         string
+	EmailChangeURL       string
+	EmailChangeCancelURL string
+	EmailOutbox          service.EmailOutboxService
+	Locker               lock.Locker
+	Tracer               nr.Tracer
+	JWTAccessDur         time.Duration
+	JWTRefreshDur        time.Duration
+	JWTIssuer            string
+	JWTKeys              *auth.KeySet    // required
+	MFASecretBox         *auth.SecretBox // without it MFA enrollment and verification fail
+	TrustProxy           bool
+	LoginProtection      service.LoginProtection
+	PasswordPolicy       auth.PasswordPolicy
+	PermissionCache      *permcache.Cache
+	OAuthStates          *oauth.StateCodec // required
+	OAuthFrontendURL     string
+	OAuthProviders       *oauth.Registry
+	Logger               *core.Logger
 }
 
 func RegisterRoutes(r chi.Router, deps RouteDeps) {
@@ -40,38 +54,68 @@ func RegisterRoutes(r chi.Router, deps RouteDeps) {
 	}
 
 	// ===== JWT MANAGER =====
-	jwtManager := auth.NewJWTManager(
-		deps.JWTSecret,
-		deps.JWTAccessDur,
-		deps.JWTRefreshDur,
-		deps.JWTIssuer,
-	)
+	if deps.JWTKeys == nil {
+		panic("routes: JWTKeys is required")
+	}
+	jwtManager := auth.NewJWTManagerWithKeys(deps.JWTKeys, deps.JWTAccessDur, deps.JWTRefreshDur, deps.JWTIssuer)
+	jwksH := rest.NewJWKSHandler(jwtManager.Keys())
+
+	if deps.OAuthStates == nil {
+		panic("routes: OAuthStates is required")
+	}
+
+	if deps.LoginProtection.ResetPasswordURL == "" {
+		deps.LoginProtection.ResetPasswordURL = deps.ResetPasswordURL
+	}
 
 	// ===== RBAC REPOSITORIES =====
 	authRepo := repository.NewAuthRepository(deps.DBConn)
 	permissionRepo := repository.NewPermissionRepository(deps.DBConn)
 	auditRepo := repository.NewAuditLogRepository(deps.DBConn)
+	passwordResetRepo := repository.NewPasswordResetRepository(deps.DBConn)
+	magicLinkRepo := repository.NewMagicLinkRepository(deps.DBConn)
+	emailChangeRepo := repository.NewEmailChangeRepository(deps.DBConn)
+	apiTokenRepo := repository.NewAPITokenRepository(deps.DBConn)
+	invitationRepo := repository.NewInvitationRepository(deps.DBConn)
+
+	// Without a worker-backed outbox, still queue messages so they are
+	// delivered once a mailer is configured.
+	if deps.EmailOutbox == nil {
+		deps.EmailOutbox = service.NewEmailOutboxService(repository.NewEmailOutboxRepository(deps.DBConn), auditRepo, nil, 0, deps.Tracer, nil)
+	}
 
 	// ===== RBAC SERVICES =====
-	authSvc := service.NewAuthService(authRepo, permissionRepo, auditRepo, jwtManager, deps.Tracer)
-	permissionSvc := service.NewPermissionService(permissionRepo, auditRepo, deps.Tracer)
+	authSvc := service.NewAuthService(authRepo, permissionRepo, auditRepo, passwordResetRepo, magicLinkRepo, emailChangeRepo, deps.EmailOutbox, jwtManager, deps.MFASecretBox, deps.LoginProtection, deps.PasswordPolicy, deps.PermissionCache, deps.Tracer, deps.Logger)
+	permissionSvc := service.NewPermissionService(permissionRepo, auditRepo, deps.PermissionCache, deps.Tracer, deps.Logger)
+	apiTokenSvc := service.NewAPITokenService(apiTokenRepo, authRepo, permissionRepo, auditRepo, deps.PermissionCache, deps.Tracer, deps.Logger)
+	invitationSvc := service.NewInvitationService(invitationRepo, authRepo, permissionRepo, auditRepo, deps.EmailOutbox, deps.InviteAcceptURL, deps.PasswordPolicy, deps.Tracer, deps.Logger)
 
 	// ===== RBAC HANDLERS =====
-	authH := rest.NewAuthHandler(authSvc)
+	authH := rest.NewAuthHandler(authSvc, deps.ResetPasswordURL, deps.MagicLinkURL, deps.EmailChangeURL, deps.EmailChangeCancelURL)
 	userH := rest.NewUserHandler(authSvc)
 	roleH := rest.NewRoleHandler(permissionSvc)
 	permissionH := rest.NewPermissionHandler(permissionSvc)
+	apiTokenH := rest.NewAPITokenHandler(apiTokenSvc)
+	invitationH := rest.NewInvitationHandler(invitationSvc, authSvc)
 
 	// ===== OAUTH =====
-	githubClient := oauth.NewGitHubOAuthClient(deps.GitHubClientID, deps.GitHubClientSecret, deps.GitHubRedirectURI)
-	oauthH := rest.NewOAuthHandler(authSvc, githubClient)
+	if deps.OAuthFrontendURL == "" {
+		deps.OAuthFrontendURL = "http://localhost:3000"
+	}
+	if deps.OAuthProviders == nil {
+		deps.OAuthProviders = oauth.NewRegistry()
+	}
+	oauthH := rest.NewOAuthHandler(authSvc, invitationSvc, deps.OAuthProviders, deps.OAuthStates, deps.OAuthFrontendURL, deps.Logger)
 
 	// ===== AUTH / REGISTRATION =====
 	regRepo := repository.NewRegistrationRepository(deps.DBConn)
 	emailVerRepo := repository.NewEmailVerificationRepository(deps.DBConn)
-	regSvc := service.NewRegistrationService(regRepo, emailVerRepo, deps.Mailer, deps.Locker, deps.Tracer)
+	regSvc := service.NewRegistrationService(regRepo, emailVerRepo, deps.EmailOutbox, deps.Locker, deps.Tracer)
 	regH := rest.NewRegistrationHandler(regSvc, deps.VerifyEmailURL)
 
+	// ===== EMAIL OUTBOX =====
+	outboxH := rest.NewEmailOutboxHandler(deps.EmailOutbox)
+
 	// ===== ROADMAPS =====
 	roadmapRepo := repository.NewRoadmapRepository(deps.DBConn)
 	roadmapSvc := service.NewRoadmapService(roadmapRepo, deps.Locker, deps.Tracer)
@@ -102,9 +146,17 @@ func RegisterRoutes(r chi.Router, deps RouteDeps) {
 	eventH := rest.NewEventHandler(eventSvc, eventSpeakerSvc, eventRegSvc)
 
 	// ========= ROUTES =========
+	r.Get("/.well-known/jwks.json", jwksH.JWKS)
+
 	r.Route("/api/v1", func(api chi.Router) {
+		// Record client IP/user agent for audit logs and sessions
+		api.Use(middleware.ClientInfo(deps.TrustProxy))
+
 		// Apply JWT middleware globally
-		api.Use(middleware.JWTMiddleware(jwtManager))
+		api.Use(middleware.JWTMiddleware(jwtManager, authRepo, apiTokenSvc))
+
+		// Tag every request made while impersonating in the audit log
+		api.Use(middleware.AuditImpersonation(auditRepo))
 
 		// ===== PUBLIC AUTH ROUTES =====
 		api.Route("/auth", func(auth chi.Router) {
@@ -112,10 +164,26 @@ func RegisterRoutes(r chi.Router, deps RouteDeps) {
 			auth.Post("/login", authH.Login)
 			auth.Post("/refresh", authH.RefreshToken)
 			auth.Post("/logout", authH.Logout)
+			auth.Post("/forgot-password", authH.ForgotPassword)
+			auth.Post("/reset-password", authH.ResetPassword)
+			auth.Post("/magic-link", authH.RequestMagicLink)
+			auth.Post("/magic-link/consume", authH.ConsumeMagicLink)
+			auth.Post("/email/confirm", authH.ConfirmEmailChange)
+			auth.Post("/email/cancel", authH.CancelEmailChange)
+
+			// Second login step (authorized by the MFA challenge token)
+			auth.Post("/mfa/verify", authH.VerifyMFA)
+			auth.Post("/mfa/setup", authH.BeginMFASetup)
+			auth.Post("/mfa/setup/confirm", authH.CompleteMFASetup)
 
 			// OAuth endpoints
-			auth.Get("/oauth/github", oauthH.HandleGitHubAuth)
-			auth.Get("/oauth/github/callback", oauthH.HandleGitHubCallback)
+			auth.Get("/oauth", oauthH.ListProviders)
+			auth.Get("/oauth/{provider}", oauthH.HandleAuth)
+			auth.Get("/oauth/{provider}/callback", oauthH.HandleCallback)
+
+			// Admin invitations (authorized by the emailed token)
+			auth.Get("/invitations/{token}", invitationH.Preview)
+			auth.Post("/invitations/accept", invitationH.Accept)
 
 			// Member registration (public)
 			auth.Post("/register", regH.Register)
@@ -125,8 +193,30 @@ func RegisterRoutes(r chi.Router, deps RouteDeps) {
 			auth.Group(func(protected chi.Router) {
 				protected.Use(middleware.RequireAuth())
 				protected.Get("/me", authH.Me)
-				protected.Patch("/me", authH.UpdateProfile)
-				protected.Post("/change-password", authH.ChangePassword)
+				protected.With(middleware.DenyAPITokens()).Patch("/me", authH.UpdateProfile)
+
+				// Credential management is not available to API tokens or
+				// while impersonating
+				protected.Group(func(interactive chi.Router) {
+					interactive.Use(middleware.DenyAPITokens())
+					interactive.Use(middleware.DenyImpersonation())
+					interactive.Post("/change-password", authH.ChangePassword)
+					interactive.Post("/me/email", authH.RequestEmailChange)
+					interactive.Post("/mfa/enroll", authH.EnrollMFA)
+					interactive.Post("/mfa/activate", authH.ActivateMFA)
+					interactive.Post("/mfa/disable", authH.DisableMFA)
+					interactive.Post("/mfa/recovery-codes", authH.RegenerateRecoveryCodes)
+					interactive.Get("/me/sessions", authH.ListSessions)
+					interactive.Delete("/me/sessions", authH.RevokeOtherSessions)
+					interactive.Delete("/me/sessions/{sessionId}", authH.RevokeSession)
+					interactive.Get("/me/identities", authH.ListIdentities)
+					interactive.Post("/me/identities/confirm", authH.ConfirmIdentityLink)
+					interactive.Post("/me/identities/{provider}", oauthH.StartLink)
+					interactive.Delete("/me/identities/{identityId}", authH.UnlinkIdentity)
+					interactive.Get("/me/tokens", apiTokenH.List)
+					interactive.Post("/me/tokens", apiTokenH.Create)
+					interactive.Delete("/me/tokens/{tokenId}", apiTokenH.Revoke)
+				})
 			})
 		})
 
@@ -140,86 +230,109 @@ func RegisterRoutes(r chi.Router, deps RouteDeps) {
 			admin.Use(middleware.RequireAuth())
 
 			// ===== USER MANAGEMENT =====
-			admin.With(middleware.RequirePermission("users:create")).Post("/users", userH.CreateUser)
-			admin.With(middleware.RequirePermission("users:list")).Get("/users", userH.ListUsers)
-			admin.With(middleware.RequirePermission("users:read")).Get("/users/{id}", userH.GetUser)
-			admin.With(middleware.RequirePermission("users:update")).Patch("/users/{id}", userH.UpdateUser)
-			admin.With(middleware.RequirePermission("users:delete")).Delete("/users/{id}", userH.DeleteUser)
-			admin.With(middleware.RequirePermission("users:manage")).Post("/users/{id}/reset-password", userH.ResetPassword)
-			admin.With(middleware.RequirePermission("users:manage")).Post("/users/{id}/roles", userH.AssignRoles)
+			// User and RBAC changes are refused while impersonating, so an
+			// impersonation cannot be used to grant the actor more access
+			admin.With(middleware.RequirePermission(rbac.UsersCreate), middleware.DenyImpersonation()).Post("/users", userH.CreateUser)
+			admin.With(middleware.RequirePermission(rbac.UsersList)).Get("/users", userH.ListUsers)
+			admin.With(middleware.RequirePermission(rbac.UsersRead)).Get("/users/{id}", userH.GetUser)
+			admin.With(middleware.RequirePermission(rbac.UsersUpdate), middleware.DenyImpersonation()).Patch("/users/{id}", userH.UpdateUser)
+			admin.With(middleware.RequirePermission(rbac.UsersDelete), middleware.DenyImpersonation()).Delete("/users/{id}", userH.DeleteUser)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Post("/users/{id}/reset-password", userH.ResetPassword)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Post("/users/{id}/mfa/reset", userH.ResetMFA)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Post("/users/{id}/unlock", userH.Unlock)
+			admin.With(middleware.RequirePermission(rbac.UsersRead)).Get("/users/{id}/sessions", userH.ListSessions)
+			admin.With(middleware.RequirePermission(rbac.UsersRead)).Get("/users/{id}/explain", userH.ExplainPermission)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Delete("/users/{id}/sessions", userH.RevokeAllSessions)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Delete("/users/{id}/sessions/{sessionId}", userH.RevokeSession)
+			admin.With(middleware.RequirePermission(rbac.UsersManage), middleware.DenyImpersonation()).Post("/users/{id}/roles", userH.AssignRoles)
+			admin.With(middleware.RequirePermission(rbac.UsersImpersonate), middleware.DenyAPITokens(), middleware.DenyImpersonation()).Post("/users/{id}/impersonate", userH.Impersonate)
+
+			// ===== USER INVITATIONS =====
+			admin.With(middleware.RequirePermission(rbac.UsersCreate), middleware.DenyImpersonation()).Post("/invitations", invitationH.AdminCreate)
+			admin.With(middleware.RequirePermission(rbac.UsersList)).Get("/invitations", invitationH.AdminList)
+			admin.With(middleware.RequirePermission(rbac.UsersCreate), middleware.DenyImpersonation()).Post("/invitations/{id}/resend", invitationH.AdminResend)
+			admin.With(middleware.RequirePermission(rbac.UsersCreate), middleware.DenyImpersonation()).Delete("/invitations/{id}", invitationH.AdminRevoke)
 
 			// ===== ROLE MANAGEMENT =====
-			admin.With(middleware.RequirePermission("roles:create")).Post("/roles", roleH.CreateRole)
-			admin.With(middleware.RequirePermission("roles:list")).Get("/roles", roleH.ListRoles)
-			admin.With(middleware.RequirePermission("roles:read")).Get("/roles/{id}", roleH.GetRole)
-			admin.With(middleware.RequirePermission("roles:update")).Patch("/roles/{id}", roleH.UpdateRole)
-			admin.With(middleware.RequirePermission("roles:delete")).Delete("/roles/{id}", roleH.DeleteRole)
-			admin.With(middleware.RequirePermission("roles:manage")).Post("/roles/{id}/permissions", roleH.AssignPermissions)
-			admin.With(middleware.RequirePermission("roles:read")).Get("/roles/{id}/permissions", roleH.GetRolePermissions)
+			admin.With(middleware.RequirePermission(rbac.RolesCreate), middleware.DenyImpersonation()).Post("/roles", roleH.CreateRole)
+			admin.With(middleware.RequirePermission(rbac.RolesList)).Get("/roles", roleH.ListRoles)
+			admin.With(middleware.RequirePermission(rbac.RolesRead)).Get("/roles/{id}", roleH.GetRole)
+			admin.With(middleware.RequirePermission(rbac.RolesUpdate), middleware.DenyImpersonation()).Patch("/roles/{id}", roleH.UpdateRole)
+			admin.With(middleware.RequirePermission(rbac.RolesDelete), middleware.DenyImpersonation()).Delete("/roles/{id}", roleH.DeleteRole)
+			admin.With(middleware.RequirePermission(rbac.RolesManage), middleware.DenyImpersonation()).Post("/roles/{id}/permissions", roleH.AssignPermissions)
+			admin.With(middleware.RequirePermission(rbac.RolesRead)).Get("/roles/{id}/permissions", roleH.GetRolePermissions)
+			admin.With(middleware.RequirePermission(rbac.RolesList)).Get("/rbac/export", roleH.ExportRBAC)
+			admin.With(middleware.RequireAllPermissions(rbac.RolesManage, rbac.RolesCreate, rbac.RolesUpdate), middleware.DenyImpersonation()).Post("/rbac/import", roleH.ImportRBAC)
 
 			// ===== PERMISSION & RESOURCE QUERIES (Read-only) =====
-			admin.With(middleware.RequirePermission("permissions:list")).Get("/permissions", permissionH.ListPermissions)
-			admin.With(middleware.RequirePermission("permissions:read")).Get("/permissions/{id}", permissionH.GetPermission)
-			admin.With(middleware.RequireAnyPermission("permissions:list", "roles:create", "roles:update")).Get("/resources", permissionH.ListResources)
-			admin.With(middleware.RequireAnyPermission("permissions:list", "roles:create", "roles:update")).Get("/actions", permissionH.ListActions)
+			admin.With(middleware.RequirePermission(rbac.PermissionsList)).Get("/permissions", permissionH.ListPermissions)
+			admin.With(middleware.RequirePermission(rbac.PermissionsRead)).Get("/permissions/cache/stats", permissionH.GetCacheStats)
+			admin.With(middleware.RequirePermission(rbac.PermissionsRead)).Get("/permissions/{id}", permissionH.GetPermission)
+			admin.With(middleware.RequireAnyPermission(rbac.PermissionsList, rbac.RolesCreate, rbac.RolesUpdate)).Get("/resources", permissionH.ListResources)
+			admin.With(middleware.RequireAnyPermission(rbac.PermissionsList, rbac.RolesCreate, rbac.RolesUpdate)).Get("/actions", permissionH.ListActions)
 
 			// ===== MEMBER REGISTRATIONS =====
-			admin.With(middleware.RequirePermission("registrations:list")).Get("/registrations", regH.AdminList)
-			admin.With(middleware.RequirePermission("registrations:read")).Get("/registrations/{id}", regH.AdminGet)
-			admin.With(middleware.RequirePermission("registrations:approve")).Patch("/registrations/{id}/approve", regH.AdminApprove)
-			admin.With(middleware.RequirePermission("registrations:reject")).Patch("/registrations/{id}/reject", regH.AdminReject)
-			admin.With(middleware.RequirePermission("registrations:delete")).Delete("/registrations/{id}", regH.AdminDelete)
+			admin.With(middleware.RequirePermission(rbac.RegistrationsList)).Get("/registrations", regH.AdminList)
+			admin.With(middleware.RequirePermission(rbac.RegistrationsRead)).Get("/registrations/{id}", regH.AdminGet)
+			admin.With(middleware.RequirePermission(rbac.RegistrationsApprove)).Patch("/registrations/{id}/approve", regH.AdminApprove)
+			admin.With(middleware.RequirePermission(rbac.RegistrationsReject)).Patch("/registrations/{id}/reject", regH.AdminReject)
+			admin.With(middleware.RequirePermission(rbac.RegistrationsDelete)).Delete("/registrations/{id}", regH.AdminDelete)
+
+			// ===== EMAIL OUTBOX =====
+			admin.With(middleware.RequirePermission(rbac.EmailOutboxList)).Get("/email-outbox", outboxH.AdminList)
+			admin.With(middleware.RequirePermission(rbac.EmailOutboxRead)).Get("/email-outbox/{id}", outboxH.AdminGet)
+			admin.With(middleware.RequirePermission(rbac.EmailOutboxManage)).Post("/email-outbox/{id}/resend", outboxH.AdminResend)
 
 			// ===== ROADMAPS =====
-			admin.With(middleware.RequirePermission("roadmaps:create")).Post("/roadmaps", roadmapH.Create)
-			admin.With(middleware.RequirePermission("roadmaps:list")).Get("/roadmaps", roadmapH.List)
-			admin.With(middleware.RequirePermission("roadmaps:read")).Get("/roadmaps/{id}", roadmapH.Get)
-			admin.With(middleware.RequirePermission("roadmaps:update")).Patch("/roadmaps/{id}", roadmapH.Update)
-			admin.With(middleware.RequirePermission("roadmaps:delete")).Delete("/roadmaps/{id}", roadmapH.Delete)
+			admin.With(middleware.RequirePermission(rbac.RoadmapsCreate)).Post("/roadmaps", roadmapH.Create)
+			admin.With(middleware.RequirePermission(rbac.RoadmapsList)).Get("/roadmaps", roadmapH.List)
+			admin.With(middleware.RequirePermission(rbac.RoadmapsRead)).Get("/roadmaps/{id}", roadmapH.Get)
+			admin.With(middleware.RequirePermission(rbac.RoadmapsUpdate)).Patch("/roadmaps/{id}", roadmapH.Update)
+			admin.With(middleware.RequirePermission(rbac.RoadmapsDelete)).Delete("/roadmaps/{id}", roadmapH.Delete)
 
 			// ===== ROADMAP ITEMS =====
-			admin.With(middleware.RequirePermission("roadmap_items:create")).Post("/roadmap-items", itemH.Create)
-			admin.With(middleware.RequirePermission("roadmap_items:list")).Get("/roadmap-items", itemH.List)
-			admin.With(middleware.RequirePermission("roadmap_items:read")).Get("/roadmap-items/{id}", itemH.Get)
-			admin.With(middleware.RequirePermission("roadmap_items:update")).Patch("/roadmap-items/{id}", itemH.Update)
-			admin.With(middleware.RequirePermission("roadmap_items:delete")).Delete("/roadmap-items/{id}", itemH.Delete)
-			admin.With(middleware.RequirePermission("roadmap_items:create")).Post("/roadmaps/{roadmap_id}/items", itemH.CreateUnderRoadmap)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsCreate)).Post("/roadmap-items", itemH.Create)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsList)).Get("/roadmap-items", itemH.List)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsRead)).Get("/roadmap-items/{id}", itemH.Get)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsUpdate)).Patch("/roadmap-items/{id}", itemH.Update)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsDelete)).Delete("/roadmap-items/{id}", itemH.Delete)
+			admin.With(middleware.RequirePermission(rbac.RoadmapItemsCreate)).Post("/roadmaps/{roadmap_id}/items", itemH.CreateUnderRoadmap)
 
 			// ===== MENTORS =====
-			admin.With(middleware.RequirePermission("mentors:create")).Post("/mentors", mentorH.Create)
-			admin.With(middleware.RequirePermission("mentors:list")).Get("/mentors", mentorH.List)
-			admin.With(middleware.RequirePermission("mentors:read")).Get("/mentors/{id}", mentorH.Get)
-			admin.With(middleware.RequirePermission("mentors:update")).Patch("/mentors/{id}", mentorH.Update)
-			admin.With(middleware.RequirePermission("mentors:activate")).Patch("/mentors/{id}/active", mentorH.SetActive)
-			admin.With(middleware.RequirePermission("mentors:update")).Patch("/mentors/{id}/priority", mentorH.SetPriority)
-			admin.With(middleware.RequirePermission("mentors:delete")).Delete("/mentors/{id}", mentorH.Delete)
+			admin.With(middleware.RequirePermission(rbac.MentorsCreate)).Post("/mentors", mentorH.Create)
+			admin.With(middleware.RequirePermission(rbac.MentorsList)).Get("/mentors", mentorH.List)
+			admin.With(middleware.RequirePermission(rbac.MentorsRead)).Get("/mentors/{id}", mentorH.Get)
+			admin.With(middleware.RequirePermission(rbac.MentorsUpdate)).Patch("/mentors/{id}", mentorH.Update)
+			admin.With(middleware.RequirePermission(rbac.MentorsActivate)).Patch("/mentors/{id}/active", mentorH.SetActive)
+			admin.With(middleware.RequirePermission(rbac.MentorsUpdate)).Patch("/mentors/{id}/priority", mentorH.SetPriority)
+			admin.With(middleware.RequirePermission(rbac.MentorsDelete)).Delete("/mentors/{id}", mentorH.Delete)
 
 			// ===== PARTNERS =====
-			admin.With(middleware.RequirePermission("partners:create")).Post("/partners", partnerH.Create)
-			admin.With(middleware.RequirePermission("partners:list")).Get("/partners", partnerH.List)
-			admin.With(middleware.RequirePermission("partners:read")).Get("/partners/{id}", partnerH.Get)
-			admin.With(middleware.RequirePermission("partners:update")).Patch("/partners/{id}", partnerH.Update)
-			admin.With(middleware.RequirePermission("partners:activate")).Patch("/partners/{id}/active", partnerH.SetActive)
-			admin.With(middleware.RequirePermission("partners:update")).Patch("/partners/{id}/priority", partnerH.SetPriority)
-			admin.With(middleware.RequirePermission("partners:delete")).Delete("/partners/{id}", partnerH.Delete)
+			admin.With(middleware.RequirePermission(rbac.PartnersCreate)).Post("/partners", partnerH.Create)
+			admin.With(middleware.RequirePermission(rbac.PartnersList)).Get("/partners", partnerH.List)
+			admin.With(middleware.RequirePermission(rbac.PartnersRead)).Get("/partners/{id}", partnerH.Get)
+			admin.With(middleware.RequirePermission(rbac.PartnersUpdate)).Patch("/partners/{id}", partnerH.Update)
+			admin.With(middleware.RequirePermission(rbac.PartnersActivate)).Patch("/partners/{id}/active", partnerH.SetActive)
+			admin.With(middleware.RequirePermission(rbac.PartnersUpdate)).Patch("/partners/{id}/priority", partnerH.SetPriority)
+			admin.With(middleware.RequirePermission(rbac.PartnersDelete)).Delete("/partners/{id}", partnerH.Delete)
 
 			// ===== EVENTS =====
-			admin.With(middleware.RequirePermission("events:create")).Post("/events", eventH.CreateEvent)
-			admin.With(middleware.RequirePermission("events:list")).Get("/events", eventH.ListEvents)
-			admin.With(middleware.RequirePermission("events:read")).Get("/events/{id}", eventH.GetEvent)
-			admin.With(middleware.RequirePermission("events:update")).Patch("/events/{id}", eventH.UpdateEvent)
-			admin.With(middleware.RequirePermission("events:delete")).Delete("/events/{id}", eventH.DeleteEvent)
-			admin.With(middleware.RequirePermission("events:update")).Patch("/events/{id}/status", eventH.SetEventStatus)
+			admin.With(middleware.RequirePermission(rbac.EventsCreate)).Post("/events", eventH.CreateEvent)
+			admin.With(middleware.RequirePermission(rbac.EventsList)).Get("/events", eventH.ListEvents)
+			admin.With(middleware.RequirePermission(rbac.EventsRead)).Get("/events/{id}", eventH.GetEvent)
+			admin.With(middleware.RequirePermission(rbac.EventsUpdate)).Patch("/events/{id}", eventH.UpdateEvent)
+			admin.With(middleware.RequirePermission(rbac.EventsDelete)).Delete("/events/{id}", eventH.DeleteEvent)
+			admin.With(middleware.RequirePermission(rbac.EventsUpdate)).Patch("/events/{id}/status", eventH.SetEventStatus)
 
 			// ===== EVENT SPEAKERS =====
-			admin.With(middleware.RequirePermission("event_speakers:list")).Get("/event-speakers", eventH.ListSpeakers)
-			admin.With(middleware.RequirePermission("event_speakers:create")).Post("/events/{event_id}/speakers", eventH.AddSpeaker)
-			admin.With(middleware.RequirePermission("event_speakers:update")).Patch("/event-speakers/{id}", eventH.UpdateSpeaker)
-			admin.With(middleware.RequirePermission("event_speakers:delete")).Delete("/event-speakers/{id}", eventH.DeleteSpeaker)
+			admin.With(middleware.RequirePermission(rbac.EventSpeakersList)).Get("/event-speakers", eventH.ListSpeakers)
+			admin.With(middleware.RequirePermission(rbac.EventSpeakersCreate)).Post("/events/{event_id}/speakers", eventH.AddSpeaker)
+			admin.With(middleware.RequirePermission(rbac.EventSpeakersUpdate)).Patch("/event-speakers/{id}", eventH.UpdateSpeaker)
+			admin.With(middleware.RequirePermission(rbac.EventSpeakersDelete)).Delete("/event-speakers/{id}", eventH.DeleteSpeaker)
 
 			// ===== EVENT REGISTRATIONS =====
-			admin.With(middleware.RequirePermission("event_registrations:list")).Get("/event-registrations", eventH.ListRegistrations)
-			admin.With(middleware.RequirePermission("event_registrations:delete")).Delete("/event-registrations/{id}", eventH.Unregister)
+			admin.With(middleware.RequirePermission(rbac.EventRegistrationsList)).Get("/event-registrations", eventH.ListRegistrations)
+			admin.With(middleware.RequirePermission(rbac.EventRegistrationsDelete)).Delete("/event-registrations/{id}", eventH.Unregister)
 		})
 	})
 }